@@ -0,0 +1,22 @@
+// Command signalrun runs a file of JSONL signals through an engine defined
+// in YAML until it is idle, writing the terminal signals as JSONL and a
+// summary of errors and latencies to stderr:
+//
+//	signalrun -def engine.yaml -in signals.jsonl -out results.jsonl
+//
+// This binary knows the "remote" agent kind, for agents served by a
+// signal.Server. Programs with in-process agents build their own registry
+// and call runner.Main.
+package main
+
+import (
+	"os"
+
+	"github.com/taipm/go-signal-agent/runner"
+)
+
+func main() {
+	registry := runner.NewRegistry()
+	registry.Register("remote", runner.RemoteAgents(registry.Codec()))
+	os.Exit(runner.Main(os.Args[1:], registry))
+}
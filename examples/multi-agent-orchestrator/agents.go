@@ -5,9 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -68,6 +67,11 @@ func (c *CoordinatorAgent) ID() string {
 	return c.id
 }
 
+// Subscriptions declares that the coordinator receives user requests
+func (c *CoordinatorAgent) Subscriptions() []signal.Subscription {
+	return []signal.Subscription{{Types: []signal.SignalType{SignalUserRequest}}}
+}
+
 // Process analyzes the user request and routes to appropriate workers
 func (c *CoordinatorAgent) Process(ctx context.Context, sig *signal.Signal) signal.AgentResult {
 	req, ok := sig.Payload.(*UserRequest)
@@ -91,16 +95,16 @@ func (c *CoordinatorAgent) Process(ctx context.Context, sig *signal.Signal) sign
 	response, err := c.ollamaClient.Chat(ctx, messages)
 	if err != nil {
 		// Fallback: use all workers on error
-		log.Printf("Coordinator LLM error, using fallback: %v", err)
-		return c.createTaskAssignment(sig, req, c.config.AvailableWorkers, "fallback due to LLM error")
+		signal.LoggerFrom(ctx).Warn("coordinator LLM error, using fallback", "error", err)
+		return c.createTaskAssignment(ctx, sig, req, c.config.AvailableWorkers, "fallback due to LLM error")
 	}
 
 	// Parse JSON response
 	var decision RoutingDecision
 	if err := json.Unmarshal([]byte(extractJSON(response)), &decision); err != nil {
 		// Fallback: use first worker
-		log.Printf("Failed to parse routing decision: %v", err)
-		return c.createTaskAssignment(sig, req, []string{c.config.AvailableWorkers[0]}, "fallback due to parse error")
+		signal.LoggerFrom(ctx).Warn("failed to parse routing decision", "error", err)
+		return c.createTaskAssignment(ctx, sig, req, []string{c.config.AvailableWorkers[0]}, "fallback due to parse error")
 	}
 
 	// Validate workers
@@ -114,26 +118,28 @@ func (c *CoordinatorAgent) Process(ctx context.Context, sig *signal.Signal) sign
 		validWorkers = validWorkers[:c.config.MaxWorkers]
 	}
 
-	return c.createTaskAssignment(sig, req, validWorkers, decision.Reason)
+	return c.createTaskAssignment(ctx, sig, req, validWorkers, decision.Reason)
 }
 
-func (c *CoordinatorAgent) createTaskAssignment(sig *signal.Signal, req *UserRequest, workers []string, context string) signal.AgentResult {
+func (c *CoordinatorAgent) createTaskAssignment(ctx context.Context, sig *signal.Signal, req *UserRequest, workers []string, reason string) signal.AgentResult {
 	taskID := uuid.New().String()[:8]
 
 	assignment := &TaskAssignment{
 		TaskID:          taskID,
 		OriginalRequest: req,
 		SelectedWorkers: workers,
-		Context:         context,
+		Context:         reason,
 	}
 
 	// Create signals for each selected worker
+	factory := signal.FactoryFrom(ctx)
 	signals := make([]*signal.Signal, len(workers))
 	for i, workerID := range workers {
-		signals[i] = sig.Derive(SignalTaskAssignment, assignment).
+		signals[i] = factory.Derive(sig, SignalTaskAssignment, assignment).
 			WithDestination(workerID).
 			WithMetadata("task_id", taskID).
-			WithMetadata("worker_index", fmt.Sprintf("%d", i))
+			WithMetadata("worker_index", fmt.Sprintf("%d", i)).
+			WithMetadata(signal.MetaJoinExpected, fmt.Sprintf("%d", len(workers)))
 	}
 
 	return signal.OK(signals...)
@@ -191,6 +197,13 @@ func (w *WorkerAgent) ID() string {
 	return w.id
 }
 
+// Subscriptions declares that the worker receives task assignments.
+// The coordinator addresses each assignment explicitly, so this only
+// applies to assignments without a destination (fanout to all workers).
+func (w *WorkerAgent) Subscriptions() []signal.Subscription {
+	return []signal.Subscription{{Types: []signal.SignalType{SignalTaskAssignment}}}
+}
+
 // Process handles the task assignment and produces a result
 func (w *WorkerAgent) Process(ctx context.Context, sig *signal.Signal) signal.AgentResult {
 	assignment, ok := sig.Payload.(*TaskAssignment)
@@ -228,7 +241,7 @@ func (w *WorkerAgent) Process(ctx context.Context, sig *signal.Signal) signal.Ag
 		Confidence: 0.8, // Default confidence
 	}
 
-	resultSig := sig.Derive(SignalWorkerResult, result).
+	resultSig := signal.FactoryFrom(ctx).Derive(sig, SignalWorkerResult, result).
 		WithDestination("output").
 		WithMetadata("task_id", assignment.TaskID).
 		WithMetadata("worker_id", w.id)
@@ -267,6 +280,17 @@ func (w *WorkerAgent) GetMemoryStats() map[string]int {
 	return w.memoryStore.Stats()
 }
 
+// Close persists the worker's memory when the engine stops
+func (w *WorkerAgent) Close(ctx context.Context) error {
+	if w.memoryStore == nil {
+		return nil
+	}
+	if err := w.memoryStore.Save(); err != nil {
+		return fmt.Errorf("worker %s save memory: %w", w.id, err)
+	}
+	return nil
+}
+
 // ClearMemory clears the worker's memory
 func (w *WorkerAgent) ClearMemory() {
 	if w.memoryStore != nil {
@@ -278,33 +302,39 @@ func (w *WorkerAgent) ClearMemory() {
 // OUTPUT AGENT
 // =============================================================================
 
-// TaskCollector tracks results for a single task
-type TaskCollector struct {
-	TaskID        string
-	ExpectedCount int
-	Results       []*WorkerResult
-	CreatedAt     time.Time
-	mu            sync.Mutex
-}
-
-// OutputAgent collects and consolidates worker results
+// OutputAgent collects and consolidates worker results.
+// Collection is delegated to a signal.Join keyed by task ID; the expected
+// worker count is announced in-band by the coordinator.
 type OutputAgent struct {
 	id           string
 	config       *config.OutputConfig
 	ollamaClient LLMClient
-	pendingTasks map[string]*TaskCollector
-	mu           sync.RWMutex
+	join         *signal.Join
 	resultChan   chan *signal.Signal // Channel to send final responses
 }
 
 // NewOutputAgent creates a new output agent
 func NewOutputAgent(cfg *config.OutputConfig, client LLMClient, resultChan chan *signal.Signal) *OutputAgent {
+	// Incomplete tasks are discarded after the response timeout
+	ttl, _ := time.ParseDuration(cfg.ResponseTimeout)
+
 	return &OutputAgent{
 		id:           cfg.ID,
 		config:       cfg,
 		ollamaClient: client,
-		pendingTasks: make(map[string]*TaskCollector),
-		resultChan:   resultChan,
+		join: signal.NewJoin(cfg.ID, signal.JoinConfig{
+			Key: func(sig *signal.Signal) string {
+				if r, ok := sig.Payload.(*WorkerResult); ok {
+					return r.TaskID
+				}
+				return ""
+			},
+			TTL: ttl,
+			OnExpired: func(taskID string, signals []*signal.Signal) {
+				slog.Warn("task expired with partial results", "task_id", taskID, "results", len(signals))
+			},
+		}),
+		resultChan: resultChan,
 	}
 }
 
@@ -313,69 +343,39 @@ func (o *OutputAgent) ID() string {
 	return o.id
 }
 
-// RegisterTask registers a new task with expected worker count
-func (o *OutputAgent) RegisterTask(taskID string, expectedCount int) {
-	o.mu.Lock()
-	defer o.mu.Unlock()
+// Subscriptions declares that the output agent receives worker results
+func (o *OutputAgent) Subscriptions() []signal.Subscription {
+	return []signal.Subscription{{Types: []signal.SignalType{SignalWorkerResult}}}
+}
 
-	o.pendingTasks[taskID] = &TaskCollector{
-		TaskID:        taskID,
-		ExpectedCount: expectedCount,
-		Results:       make([]*WorkerResult, 0),
-		CreatedAt:     time.Now(),
-	}
+// RegisterTask announces the expected worker count for a task.
+// Only needed for results that do not carry the count in their metadata.
+func (o *OutputAgent) RegisterTask(taskID string, expectedCount int) {
+	o.join.Expect(taskID, expectedCount)
 }
 
 // Process receives worker results and consolidates when complete.
-// Thread-safe: properly handles concurrent result submissions.
+// Thread-safe: the join serializes concurrent result submissions.
 func (o *OutputAgent) Process(ctx context.Context, sig *signal.Signal) signal.AgentResult {
-	result, ok := sig.Payload.(*WorkerResult)
-	if !ok {
+	if _, ok := sig.Payload.(*WorkerResult); !ok {
 		return signal.Err(fmt.Errorf("%w: expected *WorkerResult", ErrInvalidPayload))
 	}
 
-	// Lock outer mutex and keep it while adding result to prevent race
-	// between getting collector and modifying it
-	o.mu.Lock()
-	collector, exists := o.pendingTasks[result.TaskID]
-	if !exists {
-		// Task not registered, create a single-result collector
-		collector = &TaskCollector{
-			TaskID:        result.TaskID,
-			ExpectedCount: 1,
-			Results:       make([]*WorkerResult, 0, 1),
-			CreatedAt:     time.Now(),
-		}
-		o.pendingTasks[result.TaskID] = collector
+	joined := o.join.Process(ctx, sig)
+	if joined.Error != nil || len(joined.Signals) == 0 {
+		// Error, or not all results yet (waiting)
+		return joined
 	}
 
-	// Add result while still holding outer lock to prevent race with consolidateResults
-	collector.Results = append(collector.Results, result)
-	receivedCount := len(collector.Results)
-	expectedCount := collector.ExpectedCount
-
-	// If all results received, remove from pending before releasing lock
-	// This prevents other goroutines from adding more results
-	shouldConsolidate := receivedCount >= expectedCount
-	if shouldConsolidate {
-		delete(o.pendingTasks, collector.TaskID)
-	}
-	o.mu.Unlock()
-
-	// Check if all results received
-	if shouldConsolidate {
-		return o.consolidateResults(ctx, sig, collector)
+	group := joined.Signals[0].Payload.(*signal.JoinResult)
+	results := make([]*WorkerResult, 0, len(group.Signals))
+	for _, s := range group.Signals {
+		results = append(results, s.Payload.(*WorkerResult))
 	}
-
-	// Not all results yet, return empty (waiting)
-	return signal.OK()
+	return o.consolidateResults(ctx, sig, group.Key, results)
 }
 
-func (o *OutputAgent) consolidateResults(ctx context.Context, sig *signal.Signal, collector *TaskCollector) signal.AgentResult {
-	// Note: collector has already been removed from pendingTasks in Process()
-	// No need to lock collector.mu as we're the only owner now
-	results := collector.Results
-
+func (o *OutputAgent) consolidateResults(ctx context.Context, sig *signal.Signal, taskID string, results []*WorkerResult) signal.AgentResult {
 	var finalContent string
 	contributors := make([]string, 0, len(results))
 
@@ -392,13 +392,13 @@ func (o *OutputAgent) consolidateResults(ctx context.Context, sig *signal.Signal
 	}
 
 	response := &FinalResponse{
-		TaskID:       collector.TaskID,
+		TaskID:       taskID,
 		Content:      finalContent,
 		Contributors: contributors,
 	}
 
-	finalSig := sig.Derive(SignalFinalResponse, response).
-		WithMetadata("task_id", collector.TaskID).
+	finalSig := signal.FactoryFrom(ctx).Derive(sig, SignalFinalResponse, response).
+		WithMetadata("task_id", taskID).
 		WithMetadata("contributors", strings.Join(contributors, ","))
 
 	// Send to result channel for CLI display
@@ -406,7 +406,7 @@ func (o *OutputAgent) consolidateResults(ctx context.Context, sig *signal.Signal
 		select {
 		case o.resultChan <- finalSig:
 		default:
-			log.Printf("Warning: result channel full, dropping signal")
+			signal.LoggerFrom(ctx).Warn("result channel full, dropping signal", "task_id", taskID)
 		}
 	}
 
@@ -432,7 +432,7 @@ func (o *OutputAgent) mergeResults(ctx context.Context, results []*WorkerResult)
 	o.ollamaClient.SetModel(o.config.Model)
 	response, err := o.ollamaClient.Chat(ctx, messages)
 	if err != nil {
-		log.Printf("LLM merge failed, using template: %v", err)
+		signal.LoggerFrom(ctx).Warn("LLM merge failed, using template", "error", err)
 		return o.templateMerge(results)
 	}
 
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -10,7 +11,9 @@ import (
 	"github.com/taipm/go-signal-agent/examples/multi-agent-orchestrator/config"
 	"github.com/taipm/go-signal-agent/examples/multi-agent-orchestrator/memory"
 	"github.com/taipm/go-signal-agent/examples/multi-agent-orchestrator/testutil"
+	"github.com/taipm/go-signal-agent/runner"
 	"github.com/taipm/go-signal-agent/signal"
+	"github.com/taipm/go-signal-agent/signal/signaltest"
 )
 
 // =============================================================================
@@ -329,6 +332,26 @@ func TestWorkerAgent_ClearMemory(t *testing.T) {
 	}
 }
 
+func TestWorkerAgent_Close_SavesMemory(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.WorkerConfig{ID: "writing"}
+	store := memory.NewStore("writing", "conversation", 100, time.Hour, dir)
+	store.Add(memory.Entry{Role: "user", Content: "Remember me"})
+
+	agent := NewWorkerAgent(cfg, store, testutil.NewMockOllamaClient())
+	if err := agent.Close(context.Background()); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	reloaded := memory.NewStore("writing", "conversation", 100, time.Hour, dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if entries := reloaded.GetAll(); len(entries) != 1 || entries[0].Content != "Remember me" {
+		t.Errorf("Reloaded entries = %v, want the saved entry", entries)
+	}
+}
+
 // =============================================================================
 // OUTPUT AGENT TESTS
 // =============================================================================
@@ -486,6 +509,41 @@ func TestOutputAgent_Process_ConcurrentResults(t *testing.T) {
 	}
 }
 
+func TestOutputAgent_Process_InBandExpectedCount(t *testing.T) {
+	cfg := &config.OutputConfig{
+		ID:            "output",
+		MergeStrategy: "template",
+	}
+	agent := NewOutputAgent(cfg, testutil.NewMockOllamaClient(), nil)
+
+	// The coordinator announces the worker count on each assignment,
+	// and workers inherit it when deriving their result signals.
+	coordinator := NewCoordinatorAgent(&config.CoordinatorConfig{
+		ID:               "coordinator",
+		MaxWorkers:       2,
+		AvailableWorkers: []string{"writing", "summary"},
+	}, testutil.NewMockOllamaClient().WithResponse(`{"workers": ["writing", "summary"]}`))
+	assignments := coordinator.Process(context.Background(),
+		signal.NewSignal(SignalUserRequest, &UserRequest{Message: "Test"})).Signals
+
+	var last signal.AgentResult
+	for _, a := range assignments {
+		task := a.Payload.(*TaskAssignment)
+		last = agent.Process(context.Background(), a.Derive(SignalWorkerResult, &WorkerResult{
+			TaskID:   task.TaskID,
+			WorkerID: a.Destination,
+			Content:  a.Destination + " content",
+		}))
+	}
+
+	if len(last.Signals) != 1 {
+		t.Fatalf("Process() returned %d signals after all results, want 1", len(last.Signals))
+	}
+	if resp := last.Signals[0].Payload.(*FinalResponse); len(resp.Contributors) != 2 {
+		t.Errorf("FinalResponse.Contributors = %v, want 2 contributors", resp.Contributors)
+	}
+}
+
 func TestOutputAgent_Process_InvalidPayload(t *testing.T) {
 	cfg := &config.OutputConfig{ID: "output"}
 	agent := NewOutputAgent(cfg, testutil.NewMockOllamaClient(), nil)
@@ -501,6 +559,224 @@ func TestOutputAgent_Process_InvalidPayload(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// ROUTING TESTS
+// =============================================================================
+
+func TestAgentSubscriptions_Routing(t *testing.T) {
+	mock := testutil.NewMockOllamaClient()
+	router := signal.NewRouter()
+	router.Register(NewCoordinatorAgent(&config.CoordinatorConfig{ID: "coordinator"}, mock))
+	router.Register(NewWorkerAgent(&config.WorkerConfig{ID: "writing"}, nil, mock))
+	router.Register(NewWorkerAgent(&config.WorkerConfig{ID: "summary"}, nil, mock))
+	router.Register(NewOutputAgent(&config.OutputConfig{ID: "output"}, mock, nil))
+
+	tests := []struct {
+		name     string
+		sig      *signal.Signal
+		expected []string
+	}{
+		{"user request to coordinator", signal.NewSignal(SignalUserRequest, nil), []string{"coordinator"}},
+		{"addressed assignment to one worker", signal.NewSignal(SignalTaskAssignment, nil).WithDestination("summary"), []string{"summary"}},
+		{"unaddressed assignment to all workers", signal.NewSignal(SignalTaskAssignment, nil), []string{"writing", "summary"}},
+		{"worker result to output", signal.NewSignal(SignalWorkerResult, nil), []string{"output"}},
+		{"final response is terminal", signal.NewSignal(SignalFinalResponse, nil), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := router.Route(tt.sig)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Route() = %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Route()[%d] = %q, want %q", i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+// =============================================================================
+// FLOW TESTS
+// =============================================================================
+
+func TestOrchestrationFlow(t *testing.T) {
+	router := signal.NewRouter()
+	router.Register(NewCoordinatorAgent(&config.CoordinatorConfig{
+		ID:               "coordinator",
+		MaxWorkers:       2,
+		AvailableWorkers: []string{"writing", "summary"},
+	}, testutil.NewMockOllamaClient().WithResponse(`{"workers": ["writing", "summary"]}`)))
+	router.Register(NewWorkerAgent(&config.WorkerConfig{ID: "writing"}, nil,
+		testutil.NewMockOllamaClient().WithResponse("Dear team")))
+	router.Register(NewWorkerAgent(&config.WorkerConfig{ID: "summary"}, nil,
+		testutil.NewMockOllamaClient().WithResponse("In short")))
+	router.Register(NewOutputAgent(&config.OutputConfig{ID: "output", MergeStrategy: "template"},
+		testutil.NewMockOllamaClient(), nil))
+
+	engine := signaltest.NewEngine(router, signaltest.Config{})
+	request := signal.NewSignal(SignalUserRequest, &UserRequest{Message: "Write and summarize", Language: "en"})
+	engine.Submit(request)
+	if err := engine.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	signaltest.AssertNoFailures(t, engine)
+
+	schemas := newSchemaRegistry()
+	for _, s := range append([]*signal.Signal{request}, engine.Emitted()...) {
+		if err := schemas.Validate(s); err != nil {
+			t.Errorf("Schema violation: %v", err)
+		}
+	}
+
+	cfg, err := config.LoadConfig("agents.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	for _, s := range engine.Emitted() {
+		if !cfg.Policy.Allows(s.Source, s.Type, s.Destination) {
+			t.Errorf("Policy denies %s emitting %s to %q", s.Source, s.Type, s.Destination)
+		}
+	}
+
+	signaltest.AssertSignals(t, engine.Emitted(),
+		signaltest.Want{Type: SignalTaskAssignment, Source: "coordinator", Destination: "writing", ParentID: request.ID},
+		signaltest.Want{Type: SignalTaskAssignment, Source: "coordinator", Destination: "summary", ParentID: request.ID},
+		signaltest.Want{Type: SignalWorkerResult, Source: "writing", Destination: "output"},
+		signaltest.Want{Type: SignalWorkerResult, Source: "summary", Destination: "output"},
+		signaltest.Want{Type: SignalFinalResponse, Source: "output"},
+	)
+
+	final := engine.Terminal()
+	signaltest.AssertTypes(t, final, SignalFinalResponse)
+	signaltest.AssertLineage(t, engine.Lineage(final[0]),
+		SignalUserRequest, SignalTaskAssignment, SignalWorkerResult, SignalFinalResponse)
+
+	response := final[0].Payload.(*FinalResponse)
+	if want := "\n[writing]\nDear team\n\n[summary]\nIn short\n"; response.Content != want {
+		t.Errorf("FinalResponse.Content = %q, want %q", response.Content, want)
+	}
+	if strings.Join(response.Contributors, ",") != "writing,summary" {
+		t.Errorf("FinalResponse.Contributors = %v, want [writing summary]", response.Contributors)
+	}
+}
+
+// TestOrchestrationUnderChaos runs many requests while the workers fail,
+// panic, lose and duplicate their results. Every request whose workers both
+// delivered a result must be answered exactly once, with each worker's
+// contribution once; the others must fail without an answer.
+func TestOrchestrationUnderChaos(t *testing.T) {
+	chaos := signal.NewChaos(signal.ChaosConfig{Seed: 2024, Faults: []signal.Fault{
+		{Kind: signal.FaultError, Probability: 0.1, Agents: []string{"writing", "summary"}},
+		{Kind: signal.FaultPanic, Probability: 0.1, Agents: []string{"writing", "summary"}},
+		{Kind: signal.FaultDrop, Probability: 0.1, Agents: []string{"writing", "summary"}},
+		{Kind: signal.FaultDuplicate, Probability: 0.3, Agents: []string{"writing", "summary"}},
+	}})
+	router := signal.NewRouter()
+	router.Register(NewCoordinatorAgent(&config.CoordinatorConfig{
+		ID:               "coordinator",
+		MaxWorkers:       2,
+		AvailableWorkers: []string{"writing", "summary"},
+	}, testutil.NewMockOllamaClient().WithResponse(`{"workers": ["writing", "summary"]}`)))
+	router.Register(chaos.Wrap(NewWorkerAgent(&config.WorkerConfig{ID: "writing"}, nil,
+		testutil.NewMockOllamaClient().WithResponse("Dear team"))))
+	router.Register(chaos.Wrap(NewWorkerAgent(&config.WorkerConfig{ID: "summary"}, nil,
+		testutil.NewMockOllamaClient().WithResponse("In short"))))
+	router.Register(NewOutputAgent(&config.OutputConfig{ID: "output", MergeStrategy: "template"},
+		testutil.NewMockOllamaClient(), nil))
+
+	engine := signaltest.NewEngine(router, signaltest.Config{})
+	const requests = 50
+	for range requests {
+		engine.Submit(signal.NewSignal(SignalUserRequest, &UserRequest{Message: "Write and summarize", Language: "en"}))
+	}
+	if err := engine.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Results reaching the output agent, by trace and worker
+	delivered := make(map[string]map[string]bool)
+	for _, d := range engine.Deliveries() {
+		if d.Agent == "output" {
+			trace := signal.TraceID(d.Signal)
+			if delivered[trace] == nil {
+				delivered[trace] = make(map[string]bool)
+			}
+			delivered[trace][d.Signal.Source] = true
+		}
+	}
+	answered := make(map[string]bool)
+	for _, final := range engine.Terminal() {
+		trace := signal.TraceID(final)
+		if answered[trace] {
+			t.Errorf("Request %s answered twice", trace)
+		}
+		answered[trace] = true
+		if !delivered[trace]["writing"] || !delivered[trace]["summary"] {
+			t.Errorf("Request %s answered without both results", trace)
+		}
+		response := final.Payload.(*FinalResponse)
+		if strings.Join(response.Contributors, ",") != "writing,summary" && strings.Join(response.Contributors, ",") != "summary,writing" {
+			t.Errorf("FinalResponse.Contributors = %v, want each worker once", response.Contributors)
+		}
+	}
+	complete := 0
+	for trace, workers := range delivered {
+		if len(workers) == 2 {
+			complete++
+			if !answered[trace] {
+				t.Errorf("Request %s has both results but no answer", trace)
+			}
+		}
+	}
+
+	var panics int
+	for _, f := range engine.Failures() {
+		if errors.Is(f.Err, signal.ErrAgentPanic) {
+			panics++
+		} else if !errors.Is(f.Err, signal.ErrInjectedFault) {
+			t.Errorf("Unexpected failure of %s: %v", f.Agent, f.Err)
+		}
+	}
+	injected := chaos.Injected()
+	if panics == 0 || injected[signal.FaultDrop] == 0 || injected[signal.FaultDuplicate] == 0 || complete == 0 || complete == requests {
+		t.Errorf("Chaos should hit some requests and spare others: injected %v, %d of %d complete", injected, complete, requests)
+	}
+}
+
+func TestBatchDefinition(t *testing.T) {
+	cfg, err := config.LoadConfig("agents.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	cfg.Memory.StorageDir = t.TempDir()
+	factory, err := NewFactory(cfg, memory.NewManager(cfg.Memory), nil)
+	if err != nil {
+		t.Fatalf("NewFactory() error = %v", err)
+	}
+	def, err := runner.LoadDefinition("batch.yaml")
+	if err != nil {
+		t.Fatalf("LoadDefinition() error = %v", err)
+	}
+
+	router, engineCfg, err := def.Build(newBatchRegistry(factory))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if engineCfg.Schemas == nil || engineCfg.Fanout == nil || engineCfg.Policy == nil {
+		t.Error("Batch engine should validate schemas, fan out in parallel and enforce a policy")
+	}
+	if got := router.Route(signal.NewSignal(SignalUserRequest, nil)); len(got) != 1 || got[0] != "coordinator" {
+		t.Errorf("user_request routed to %v, want [coordinator]", got)
+	}
+	agent, _ := router.GetAgent("coordinator")
+	if workers := agent.(*CoordinatorAgent).config.AvailableWorkers; strings.Join(workers, ",") != "summary" {
+		t.Errorf("Coordinator workers = %v, want [summary]", workers)
+	}
+}
+
 // =============================================================================
 // UTILITY FUNCTION TESTS
 // =============================================================================
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/taipm/go-signal-agent/runner"
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// =============================================================================
+// BATCH MODE
+// =============================================================================
+
+// coordinatorSpec is the batch configuration of a coordinator agent.
+type coordinatorSpec struct {
+	// Workers restricts the workers the coordinator may select, for batch
+	// definitions that declare only some of the configured workers.
+	Workers []string `yaml:"workers"`
+}
+
+// runBatch runs the orchestrator's agents over a file of requests with the
+// signalrun command line (see package runner), e.g.
+//
+//	go run ./examples/multi-agent-orchestrator batch \
+//	    -def examples/multi-agent-orchestrator/batch.yaml -in requests.jsonl
+func runBatch(args []string, factory *Factory) int {
+	return runner.Main(args, newBatchRegistry(factory))
+}
+
+// newBatchRegistry exposes the orchestrator's agents as batch agent kinds:
+// "coordinator", "worker" (the agent ID selects the worker configuration),
+// "output" and "remote", with the flow's payload types and schemas.
+func newBatchRegistry(factory *Factory) *runner.Registry {
+	registry := runner.NewRegistry()
+	registry.Register("coordinator", func(spec runner.AgentSpec) (signal.Agent, error) {
+		var cs coordinatorSpec
+		if err := spec.Decode(&cs); err != nil {
+			return nil, err
+		}
+		cfg := factory.cfg.Coordinator
+		if len(cs.Workers) > 0 {
+			cfg.AvailableWorkers = cs.Workers
+		}
+		return NewCoordinatorAgent(&cfg, factory.ollamaClient), nil
+	})
+	registry.Register("worker", func(spec runner.AgentSpec) (signal.Agent, error) {
+		return factory.CreateWorker(spec.ID)
+	})
+	registry.Register("output", func(spec runner.AgentSpec) (signal.Agent, error) {
+		return factory.CreateOutputAgent(), nil
+	})
+	registry.Register("remote", runner.RemoteAgents(registry.Codec()))
+
+	registry.RegisterPayload(SignalUserRequest, &UserRequest{})
+	registry.RegisterPayload(SignalTaskAssignment, &TaskAssignment{})
+	registry.RegisterPayload(SignalWorkerResult, &WorkerResult{})
+	registry.RegisterPayload(SignalFinalResponse, &FinalResponse{})
+	registry.UseSchemas(newSchemaRegistry())
+	return registry
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/joho/godotenv"
+	"github.com/taipm/go-signal-agent/signal"
 	"gopkg.in/yaml.v3"
 )
 
@@ -56,6 +57,7 @@ type Config struct {
 	Coordinator CoordinatorConfig       `yaml:"coordinator"`
 	Workers     map[string]WorkerConfig `yaml:"workers"`
 	Output      OutputConfig            `yaml:"output"`
+	Policy      *signal.Policy          `yaml:"policy"` // What each agent may emit; nil allows everything
 }
 
 // OllamaConfig holds Ollama connection settings
@@ -191,6 +193,12 @@ func LoadConfig(path string) (*Config, error) {
 		cfg.Output.ResponseTimeout = "60s"
 	}
 
+	if cfg.Policy != nil {
+		if err := cfg.Policy.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
 	return &cfg, nil
 }
 
@@ -127,6 +127,31 @@ func TestLoadConfig_FileNotFound(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_Policy(t *testing.T) {
+	path := createTestYAML(t, `
+policy:
+  rules:
+    - source: coordinator
+      types: [task_assignment]
+      to: [writing]
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Policy == nil || !cfg.Policy.Allows("coordinator", "task_assignment", "writing") {
+		t.Errorf("Policy = %+v, want coordinator allowed to assign tasks", cfg.Policy)
+	}
+	if cfg.Policy.Allows("writing", "task_assignment", "writing") {
+		t.Error("Policy should deny workers assigning tasks")
+	}
+
+	path = createTestYAML(t, "policy:\n  rules:\n    - types: [task_assignment]\n")
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig() should reject a policy rule without source")
+	}
+}
+
 func TestLoadConfig_Defaults(t *testing.T) {
 	// Minimal config with no values set
 	content := `
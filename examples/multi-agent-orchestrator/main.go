@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"strings"
@@ -40,6 +41,16 @@ func main() {
 		log.Fatalf("Failed to initialize memory: %v", err)
 	}
 
+	// Batch mode: run a file of requests through the agents instead of the chat.
+	// Final responses are written as JSONL rather than sent to a result channel.
+	if len(os.Args) > 1 && os.Args[1] == "batch" {
+		factory, err := NewFactory(cfg, memMgr, nil)
+		if err != nil {
+			log.Fatalf("Failed to create factory: %v", err)
+		}
+		os.Exit(runBatch(os.Args[2:], factory))
+	}
+
 	// Create result channel for final responses
 	resultChan := make(chan *sig.Signal, resultChanSize)
 
@@ -55,7 +66,9 @@ func main() {
 		log.Fatalf("Failed to create agents: %v", err)
 	}
 
-	// Create router and register agents
+	// Create router and register agents.
+	// Each agent declares the signal types it consumes, so no routing
+	// rules are needed: adding a worker only requires registering it.
 	router := sig.NewRouter()
 	router.Register(coordinator)
 	for _, worker := range workers {
@@ -63,38 +76,23 @@ func main() {
 	}
 	router.Register(outputAgent)
 
-	// Create orchestrator and add routing rules
-	workerIDs := make([]string, len(workers))
-	for i, w := range workers {
-		workerIDs[i] = w.ID()
-	}
-	orchestrator := NewOrchestrator(outputAgent, workerIDs)
-	for _, rule := range orchestrator.CreateRoutingRules() {
-		router.AddRule(rule)
-	}
+	// Engine events and agent warnings go to stderr, away from the chat
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
 
 	// Create and start engine
 	engine := sig.NewEngine(sig.EngineConfig{
 		BufferSize:     50,
 		WorkerCount:    5,
 		ProcessTimeout: 180 * time.Second,
+		Fanout:         &sig.FanoutConfig{}, // Workers of one task answer in parallel
+		Schemas:        newSchemaRegistry(),
+		Policy:         cfg.Policy,
+		Logger:         logger,
 	}, router)
 
-	// Add hook to register tasks with output agent
-	engine.OnSignalProcessed(func(input *sig.Signal, result sig.AgentResult) {
-		if input.Type == SignalUserRequest && len(result.Signals) > 0 {
-			// Extract task assignment from first output signal
-			for _, outSig := range result.Signals {
-				if outSig.Type == SignalTaskAssignment {
-					if assignment, ok := outSig.Payload.(*TaskAssignment); ok {
-						orchestrator.RegisterTaskFromAssignment(assignment)
-					}
-				}
-			}
-		}
-	})
-
-	engine.Start()
+	if err := engine.Start(); err != nil {
+		log.Fatalf("Failed to start engine: %v", err)
+	}
 
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -115,10 +113,9 @@ func main() {
 	// Start CLI loop
 	runCLI(ctx, engine, resultChan, workers, memMgr)
 
-	// Cleanup
-	engine.Stop()
-	if err := memMgr.Shutdown(); err != nil {
-		log.Printf("Error saving memory: %v", err)
+	// Cleanup: stopping the engine closes the workers, which saves their memory
+	if err := engine.Stop(); err != nil {
+		log.Printf("Error stopping agents: %v", err)
 	}
 	fmt.Println("Goodbye! / Tam biet!")
 }
@@ -208,7 +205,7 @@ func runCLI(ctx context.Context, engine *sig.Engine, resultChan chan *sig.Signal
 				Language:  language,
 			}
 
-			userSignal := sig.NewSignal(SignalUserRequest, userReq).
+			userSignal := engine.Factory().New(SignalUserRequest, userReq).
 				WithMetadata("session_id", sessionID).
 				WithMetadata("language", language)
 
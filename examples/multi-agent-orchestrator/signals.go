@@ -51,3 +51,42 @@ type RoutingDecision struct {
 	Workers []string `json:"workers"`
 	Reason  string   `json:"reason"`
 }
+
+// newSchemaRegistry describes the payload of every signal type in the flow,
+// so the engine rejects signals carrying the wrong struct or missing fields.
+func newSchemaRegistry() *signal.SchemaRegistry {
+	schemas := signal.NewSchemaRegistry()
+	for signalType, schema := range map[signal.SignalType]signal.Schema{
+		SignalUserRequest: {
+			Prototype:   &UserRequest{},
+			JSON:        `{"type": "object", "properties": {"message": {"type": "string", "minLength": 1}}, "required": ["message"]}`,
+			Description: "User input, routed to the coordinator",
+		},
+		SignalTaskAssignment: {
+			Prototype: &TaskAssignment{},
+			JSON: `{"type": "object", "properties": {
+				"task_id": {"type": "string", "minLength": 1},
+				"selected_workers": {"type": "array", "minItems": 1, "items": {"type": "string"}}
+			}, "required": ["task_id", "selected_workers"]}`,
+			Description: "Coordinator's routing decision, one per selected worker",
+		},
+		SignalWorkerResult: {
+			Prototype: &WorkerResult{},
+			JSON: `{"type": "object", "properties": {
+				"task_id": {"type": "string", "minLength": 1},
+				"worker_id": {"type": "string", "minLength": 1},
+				"confidence": {"type": "number", "minimum": 0, "maximum": 1}
+			}, "required": ["task_id", "worker_id"]}`,
+			Description: "Output of one worker, collected by the output agent",
+		},
+		SignalFinalResponse: {
+			Prototype:   &FinalResponse{},
+			Description: "Consolidated answer shown to the user",
+		},
+	} {
+		if err := schemas.Register(signalType, schema); err != nil {
+			panic(err) // The schemas above are constants
+		}
+	}
+	return schemas
+}
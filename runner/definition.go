@@ -0,0 +1,193 @@
+// Package runner runs signal engines defined in YAML over files of signals.
+// A Definition names agents by kind, resolved through a Registry of agent
+// factories, adds routing rules and engine settings. Run feeds JSONL
+// envelopes through the engine until it is idle and writes the terminal
+// signals (those routed nowhere) as JSONL, returning a Summary of errors
+// and latencies. cmd/signalrun is the command-line front end.
+package runner
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+	"gopkg.in/yaml.v3"
+)
+
+// =============================================================================
+// DEFINITION
+// =============================================================================
+
+// Definition describes an engine: its settings, agents, routing rules and
+// access policy. Agents implementing signal.Subscriber route themselves;
+// rules add routes for the others.
+type Definition struct {
+	Engine EngineSpec     `yaml:"engine"`
+	Agents []AgentSpec    `yaml:"agents"`
+	Rules  []RuleSpec     `yaml:"rules,omitempty"`
+	Policy *signal.Policy `yaml:"policy,omitempty"` // What each agent may emit; absent allows everything
+}
+
+// EngineSpec holds engine settings. Zero values keep the defaults of
+// signal.DefaultConfig.
+type EngineSpec struct {
+	BufferSize     int           `yaml:"buffer_size,omitempty"`
+	WorkerCount    int           `yaml:"worker_count,omitempty"`
+	ProcessTimeout time.Duration `yaml:"process_timeout,omitempty"`
+	Fanout         *FanoutSpec   `yaml:"fanout,omitempty"` // Parallel destinations; absent is sequential
+}
+
+// FanoutSpec mirrors signal.FanoutConfig.
+type FanoutSpec struct {
+	MaxConcurrency int  `yaml:"max_concurrency,omitempty"`
+	Ordered        bool `yaml:"ordered,omitempty"`
+}
+
+// AgentSpec declares one agent: its ID, the registered kind that creates
+// it, and kind-specific configuration.
+type AgentSpec struct {
+	ID     string    `yaml:"id"`
+	Kind   string    `yaml:"kind"`
+	Config yaml.Node `yaml:"config,omitempty"`
+}
+
+// Decode decodes the agent's configuration into v. Without a config
+// section, v is left unchanged.
+func (s AgentSpec) Decode(v any) error {
+	if s.Config.Kind == 0 {
+		return nil
+	}
+	if err := s.Config.Decode(v); err != nil {
+		return fmt.Errorf("config of agent '%s': %w", s.ID, err)
+	}
+	return nil
+}
+
+// RuleSpec routes signals matching every set field to the agents in To.
+// Rules are tried in order and the first match decides, so list specific
+// rules before general ones.
+type RuleSpec struct {
+	Types    []signal.SignalType `yaml:"types,omitempty"`    // Any of these types; empty matches all
+	Source   string              `yaml:"source,omitempty"`   // Emitting agent
+	Metadata map[string]string   `yaml:"metadata,omitempty"` // Required metadata values
+	To       []string            `yaml:"to"`
+}
+
+// rule converts the spec to a routing rule.
+func (r RuleSpec) rule() signal.RoutingRule {
+	match := signal.Subscription{Types: r.Types, Metadata: r.Metadata}
+	return func(sig *signal.Signal) []string {
+		if (r.Source != "" && sig.Source != r.Source) || !match.Matches(sig) {
+			return nil
+		}
+		return r.To
+	}
+}
+
+// =============================================================================
+// LOADING
+// =============================================================================
+
+// ParseDefinition parses a YAML engine definition.
+func ParseDefinition(data []byte) (*Definition, error) {
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("parse engine definition: %w", err)
+	}
+	return &def, nil
+}
+
+// LoadDefinition reads and parses a YAML engine definition file.
+func LoadDefinition(path string) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read engine definition: %w", err)
+	}
+	return ParseDefinition(data)
+}
+
+// =============================================================================
+// VALIDATION AND BUILDING
+// =============================================================================
+
+// Validate checks the definition for structural errors: missing or
+// duplicate agent IDs, kinds unknown to the registry, rules without targets
+// or targeting unknown agents, policy rules without a source, and negative
+// engine settings.
+func (d *Definition) Validate(registry *Registry) error {
+	if len(d.Agents) == 0 {
+		return fmt.Errorf("engine definition has no agents")
+	}
+	if d.Engine.BufferSize < 0 || d.Engine.WorkerCount < 0 || d.Engine.ProcessTimeout < 0 {
+		return fmt.Errorf("engine settings must not be negative")
+	}
+	ids := make(map[string]bool, len(d.Agents))
+	for i, a := range d.Agents {
+		if a.ID == "" {
+			return fmt.Errorf("agent %d has no id", i)
+		}
+		if ids[a.ID] {
+			return fmt.Errorf("duplicate agent '%s'", a.ID)
+		}
+		if _, ok := registry.factory(a.Kind); !ok {
+			return fmt.Errorf("agent '%s' has unknown kind '%s'", a.ID, a.Kind)
+		}
+		ids[a.ID] = true
+	}
+	for i, r := range d.Rules {
+		if len(r.To) == 0 {
+			return fmt.Errorf("rule %d has no targets", i)
+		}
+		for _, id := range r.To {
+			if !ids[id] {
+				return fmt.Errorf("rule %d routes to unknown agent '%s'", i, id)
+			}
+		}
+	}
+	if d.Policy != nil {
+		return d.Policy.Validate()
+	}
+	return nil
+}
+
+// Build validates the definition, creates its agents and returns a router
+// with the agents and rules, and the engine configuration.
+func (d *Definition) Build(registry *Registry) (*signal.Router, signal.EngineConfig, error) {
+	config := signal.DefaultConfig()
+	if err := d.Validate(registry); err != nil {
+		return nil, config, err
+	}
+
+	router := signal.NewRouter()
+	for _, spec := range d.Agents {
+		factory, _ := registry.factory(spec.Kind)
+		agent, err := factory(spec)
+		if err != nil {
+			return nil, config, fmt.Errorf("create agent '%s' of kind '%s': %w", spec.ID, spec.Kind, err)
+		}
+		if agent.ID() != spec.ID {
+			return nil, config, fmt.Errorf("kind '%s' created agent '%s' for id '%s'", spec.Kind, agent.ID(), spec.ID)
+		}
+		router.Register(agent)
+	}
+	for _, r := range d.Rules {
+		router.AddRule(r.rule())
+	}
+
+	if d.Engine.BufferSize > 0 {
+		config.BufferSize = d.Engine.BufferSize
+	}
+	if d.Engine.WorkerCount > 0 {
+		config.WorkerCount = d.Engine.WorkerCount
+	}
+	if d.Engine.ProcessTimeout > 0 {
+		config.ProcessTimeout = d.Engine.ProcessTimeout
+	}
+	if f := d.Engine.Fanout; f != nil {
+		config.Fanout = &signal.FanoutConfig{MaxConcurrency: f.MaxConcurrency, Ordered: f.Ordered}
+	}
+	config.Schemas = registry.schemas
+	config.Policy = d.Policy
+	return router, config, nil
+}
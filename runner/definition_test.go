@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// echoConfig is the configuration of the test "echo" kind.
+type echoConfig struct {
+	Emit signal.SignalType `yaml:"emit"`
+}
+
+// testRegistry knows an "echo" kind, which derives a signal of its
+// configured type from every input, and a "fail" kind, which always errors.
+func testRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register("echo", func(spec AgentSpec) (signal.Agent, error) {
+		cfg := echoConfig{Emit: "echoed"}
+		if err := spec.Decode(&cfg); err != nil {
+			return nil, err
+		}
+		return signal.NewAgentFunc(spec.ID, func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			return signal.OK(sig.Derive(cfg.Emit, sig.Payload))
+		}), nil
+	})
+	registry.Register("fail", func(spec AgentSpec) (signal.Agent, error) {
+		return signal.NewAgentFunc(spec.ID, func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			return signal.Err(errBoom)
+		}), nil
+	})
+	return registry
+}
+
+var errBoom = errors.New("boom")
+
+const testDefinition = `
+engine:
+  worker_count: 2
+  process_timeout: 5s
+  fanout:
+    max_concurrency: 4
+agents:
+  - id: upper
+    kind: echo
+    config:
+      emit: shouted
+  - id: broken
+    kind: fail
+rules:
+  - types: [request]
+    metadata:
+      mode: risky
+    to: [upper, broken]
+  - types: [request]
+    to: [upper]
+policy:
+  rules:
+    - source: upper
+      types: [shouted]
+`
+
+func TestParseDefinition(t *testing.T) {
+	def, err := ParseDefinition([]byte(testDefinition))
+	if err != nil {
+		t.Fatalf("ParseDefinition error = %v", err)
+	}
+	if def.Engine.WorkerCount != 2 || def.Engine.ProcessTimeout != 5*time.Second {
+		t.Errorf("Unexpected engine settings %+v", def.Engine)
+	}
+	if len(def.Agents) != 2 || def.Agents[0].Kind != "echo" || len(def.Rules) != 2 {
+		t.Fatalf("Unexpected definition %+v", def)
+	}
+	var cfg echoConfig
+	if err := def.Agents[0].Decode(&cfg); err != nil || cfg.Emit != "shouted" {
+		t.Errorf("Decode = %+v, %v", cfg, err)
+	}
+
+	router, config, err := def.Build(testRegistry())
+	if err != nil {
+		t.Fatalf("Build error = %v", err)
+	}
+	if config.WorkerCount != 2 || config.BufferSize != signal.DefaultConfig().BufferSize {
+		t.Errorf("Unexpected engine config %+v", config)
+	}
+	if config.Policy == nil || !config.Policy.Allows("upper", "shouted", "") || config.Policy.Allows("broken", "shouted", "") {
+		t.Errorf("Policy not applied: %+v", config.Policy)
+	}
+	if config.Fanout == nil || config.Fanout.MaxConcurrency != 4 {
+		t.Errorf("Fanout not applied: %+v", config.Fanout)
+	}
+	plain := signal.NewSignal("request", nil)
+	if got := router.Route(plain); len(got) != 1 || got[0] != "upper" {
+		t.Errorf("Route(request) = %v", got)
+	}
+	risky := signal.NewSignal("request", nil).WithMetadata("mode", "risky")
+	if got := router.Route(risky); len(got) != 2 {
+		t.Errorf("Route(risky request) = %v", got)
+	}
+}
+
+func TestDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"no agents", "engine: {}", "no agents"},
+		{"missing id", "agents: [{kind: echo}]", "has no id"},
+		{"duplicate", "agents: [{id: a, kind: echo}, {id: a, kind: echo}]", "duplicate agent 'a'"},
+		{"unknown kind", "agents: [{id: a, kind: llm}]", "unknown kind 'llm'"},
+		{"rule without targets", "agents: [{id: a, kind: echo}]\nrules: [{types: [x]}]", "no targets"},
+		{"unknown target", "agents: [{id: a, kind: echo}]\nrules: [{to: [b]}]", "unknown agent 'b'"},
+		{"policy rule without source", "agents: [{id: a, kind: echo}]\npolicy: {rules: [{types: [x]}]}", "no source"},
+		{"negative settings", "engine: {worker_count: -1}\nagents: [{id: a, kind: echo}]", "negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, err := ParseDefinition([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("ParseDefinition error = %v", err)
+			}
+			err = def.Validate(testRegistry())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildChecksAgentIDs(t *testing.T) {
+	registry := testRegistry()
+	registry.Register("fixed", func(spec AgentSpec) (signal.Agent, error) {
+		return signal.NewAgentFunc("other", nil), nil
+	})
+	def, _ := ParseDefinition([]byte("agents: [{id: mine, kind: fixed}]"))
+	if _, _, err := def.Build(registry); err == nil || !strings.Contains(err.Error(), "created agent 'other'") {
+		t.Errorf("Build error = %v, want an ID mismatch", err)
+	}
+}
@@ -0,0 +1,130 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	ossignal "os/signal"
+	"strings"
+)
+
+// =============================================================================
+// COMMAND LINE
+// =============================================================================
+
+// Exit codes of Main.
+const (
+	ExitOK     = 0 // Run completed (errors, if any, are in the summary)
+	ExitFailed = 1 // Run could not complete
+	ExitUsage  = 2 // Invalid flags
+	ExitErrors = 3 // -strict and the summary has errors
+)
+
+// Main runs the signalrun command line with the agent kinds of registry and
+// returns the process exit code. Programs with their own agents call it
+// from main:
+//
+//	os.Exit(runner.Main(os.Args[1:], registry))
+func Main(args []string, registry *Registry) int {
+	return runMain(args, registry, os.Stdin, os.Stdout, os.Stderr)
+}
+
+func runMain(args []string, registry *Registry, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("signalrun", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	defPath := flags.String("def", "", "engine definition `file` (YAML, required)")
+	inPath := flags.String("in", "-", "input `file` of JSONL signals, - for stdin")
+	outPath := flags.String("out", "-", "output `file` for terminal signals, - for stdout")
+	summaryPath := flags.String("summary", "", "also write the summary as JSON to `file`")
+	timeout := flags.Duration("timeout", 0, "abort the run after this duration (0 = none)")
+	verbose := flags.Bool("v", false, "log engine events to stderr")
+	strict := flags.Bool("strict", false, "exit with status 3 if any input was rejected or any error occurred")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: signalrun -def engine.yaml [-in signals.jsonl] [-out results.jsonl] [flags]\n\n")
+		flags.PrintDefaults()
+		fmt.Fprintf(stderr, "\nagent kinds: %s\n", strings.Join(registry.Kinds(), ", "))
+	}
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if *defPath == "" || flags.NArg() > 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintf(stderr, "signalrun: %v\n", err)
+		return ExitFailed
+	}
+	def, err := LoadDefinition(*defPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	in := stdin
+	if *inPath != "-" {
+		f, err := os.Open(*inPath)
+		if err != nil {
+			return fail(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	out := stdout
+	var outFile *os.File
+	if *outPath != "-" {
+		if outFile, err = os.Create(*outPath); err != nil {
+			return fail(err)
+		}
+		out = outFile
+	}
+
+	var opts Options
+	if *verbose {
+		opts.Logger = slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	ctx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	summary, runErr := Run(ctx, def, registry, in, out, opts)
+	if outFile != nil {
+		if err := outFile.Close(); err != nil && runErr == nil {
+			runErr = fmt.Errorf("write terminal signals: %w", err)
+		}
+	}
+	if summary != nil {
+		summary.WriteText(stderr)
+		if *summaryPath != "" {
+			if err := writeSummary(*summaryPath, summary); err != nil && runErr == nil {
+				runErr = err
+			}
+		}
+	}
+	switch {
+	case runErr != nil:
+		return fail(runErr)
+	case *strict && summary.Failed():
+		return ExitErrors
+	}
+	return ExitOK
+}
+
+func writeSummary(path string, summary *Summary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode summary: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write summary: %w", err)
+	}
+	return nil
+}
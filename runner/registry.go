@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// =============================================================================
+// REGISTRY: Agent Kinds and Payload Types
+// =============================================================================
+
+// AgentFactory creates the agent declared by a spec. The agent's ID must be
+// spec.ID; kind-specific settings are read with spec.Decode.
+type AgentFactory func(spec AgentSpec) (signal.Agent, error)
+
+// Registry resolves the agent kinds of a Definition and knows the payload
+// types of the signals it reads and writes.
+type Registry struct {
+	kinds   map[string]AgentFactory
+	codec   *signal.Codec
+	schemas *signal.SchemaRegistry
+}
+
+// NewRegistry creates a registry without kinds or payload types.
+func NewRegistry() *Registry {
+	return &Registry{kinds: make(map[string]AgentFactory), codec: signal.NewCodec()}
+}
+
+// Register adds an agent kind, replacing any previous factory.
+func (r *Registry) Register(kind string, factory AgentFactory) {
+	r.kinds[kind] = factory
+}
+
+// RegisterPayload sets the Go type that input payloads of a signal type
+// are decoded into, as in signal.Codec.Register.
+func (r *Registry) RegisterPayload(signalType signal.SignalType, prototype any) {
+	r.codec.Register(signalType, prototype)
+}
+
+// UseSchemas validates every signal of the engine against schemas (see
+// signal.EngineConfig.Schemas).
+func (r *Registry) UseSchemas(schemas *signal.SchemaRegistry) {
+	r.schemas = schemas
+}
+
+// Codec returns the codec used for input and output signals.
+func (r *Registry) Codec() *signal.Codec {
+	return r.codec
+}
+
+// Kinds returns the registered agent kinds, sorted.
+func (r *Registry) Kinds() []string {
+	kinds := make([]string, 0, len(r.kinds))
+	for k := range r.kinds {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (r *Registry) factory(kind string) (AgentFactory, bool) {
+	f, ok := r.kinds[kind]
+	return f, ok
+}
+
+// RemoteConfig is the configuration of a "remote" agent.
+type RemoteConfig struct {
+	Address     string        `yaml:"address"`
+	RemoteID    string        `yaml:"remote_id,omitempty"`
+	Transport   string        `yaml:"transport,omitempty"` // "tcp" (default) or "unix"
+	DialTimeout time.Duration `yaml:"dial_timeout,omitempty"`
+}
+
+// RemoteAgents returns a factory of signal.RemoteAgent proxies for agents
+// exposed by a signal.Server, encoding signals with codec.
+func RemoteAgents(codec *signal.Codec) AgentFactory {
+	return func(spec AgentSpec) (signal.Agent, error) {
+		var cfg RemoteConfig
+		if err := spec.Decode(&cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Address == "" {
+			return nil, fmt.Errorf("remote agent '%s' has no address", spec.ID)
+		}
+		remote := signal.RemoteConfig{
+			Address:     cfg.Address,
+			RemoteID:    cfg.RemoteID,
+			Codec:       codec,
+			DialTimeout: cfg.DialTimeout,
+		}
+		switch cfg.Transport {
+		case "", "tcp":
+		case "unix":
+			remote.Transport = signal.UnixTransport()
+		default:
+			return nil, fmt.Errorf("remote agent '%s' has unknown transport '%s'", spec.ID, cfg.Transport)
+		}
+		return signal.NewRemoteAgent(spec.ID, remote), nil
+	}
+}
@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// =============================================================================
+// RUN: Feeding a Signal File Through an Engine
+// =============================================================================
+
+// maxLine bounds the size of one input envelope.
+const maxLine = 16 << 20
+
+// Options configures Run.
+type Options struct {
+	// Logger receives the engine's log events (see signal.LogConfig).
+	// Nil discards them; errors are reported in the Summary either way.
+	Logger *slog.Logger
+}
+
+// Run builds the engine of def and submits every envelope read from in, one
+// JSON object per line. Blank lines are skipped; lines that fail to decode
+// or are rejected on submission are recorded in the Summary and the run
+// continues. Signals without an ID or timestamp get them from the engine.
+//
+// After the input is exhausted Run waits until the engine is idle, then
+// stops it. Terminal signals, those routed to no agent, are written to out
+// as JSONL envelopes as they occur; they are usually a flow's final
+// outputs. Agent and engine errors are collected in the Summary.
+//
+// The returned error reports failures of the run itself: an invalid
+// definition, an unreadable input, an unwritable output or ctx ending
+// before the input is fed or the engine is idle. The Summary is returned even then, covering
+// the work done so far.
+func Run(ctx context.Context, def *Definition, registry *Registry, in io.Reader, out io.Writer, opts Options) (*Summary, error) {
+	router, config, err := def.Build(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &run{codec: registry.Codec(), out: bufio.NewWriter(out), started: make(map[string]time.Time), finished: make(map[string]time.Time), calls: make(map[string][]call)}
+	config.Logger = opts.Logger
+
+	engine := signal.NewEngine(config, router)
+	engine.OnError(r.engineError)
+	engine.OnAgentCall(r.recordCall)
+	if err := engine.Start(); err != nil {
+		return nil, fmt.Errorf("start engine: %w", err)
+	}
+	begin := time.Now()
+
+	readErr := r.feed(ctx, engine, in)
+	idleErr := engine.WaitIdle(ctx)
+	stopErr := engine.Stop()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	summary := r.summarize(time.Since(begin))
+	if r.writeErr == nil {
+		r.writeErr = r.out.Flush()
+	}
+	switch {
+	case readErr != nil:
+		return summary, readErr
+	case idleErr != nil:
+		return summary, fmt.Errorf("wait for engine: %w", idleErr)
+	case r.writeErr != nil:
+		return summary, fmt.Errorf("write terminal signals: %w", r.writeErr)
+	case stopErr != nil:
+		return summary, fmt.Errorf("stop engine: %w", stopErr)
+	}
+	return summary, nil
+}
+
+// run is the state of one Run, shared with the engine's callbacks.
+type run struct {
+	codec *signal.Codec
+
+	mu       sync.Mutex
+	out      *bufio.Writer
+	writeErr error
+	inputs   int
+	rejected int
+	terminal int
+	errs     []ErrorRecord
+	started  map[string]time.Time // Submission time per input trace
+	finished map[string]time.Time // Latest terminal signal or error per trace
+	calls    map[string][]call    // Agent calls per agent
+}
+
+type call struct {
+	duration time.Duration
+	failed   bool
+}
+
+// feed decodes and submits the input lines until the input ends or ctx is
+// done. A submission waiting for room in a full inbox gives up with ctx.
+func (r *run) feed(ctx context.Context, engine *signal.Engine, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+	factory := engine.Factory()
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("feed input at line %d: %w", line, err)
+		}
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		sig, err := r.codec.Decode(data)
+		if err == nil {
+			if sig.ID == "" {
+				sig.ID = factory.NewID()
+			}
+			if sig.Timestamp.IsZero() {
+				sig.Timestamp = factory.Now()
+			}
+			r.begin(signal.TraceID(sig))
+			if err = r.codec.Accept(sig); err == nil {
+				if err = engine.SubmitContext(ctx, sig); err != nil {
+					r.codec.Forget(sig) // Accepted again if fed again
+				}
+			}
+		}
+
+		r.mu.Lock()
+		r.inputs++
+		if err != nil {
+			r.rejected++
+			rec := ErrorRecord{Line: line, Error: err.Error()}
+			if sig != nil {
+				rec.Trace, rec.SignalID, rec.Type = signal.TraceID(sig), sig.ID, sig.Type
+				delete(r.started, rec.Trace)
+			}
+			r.errs = append(r.errs, rec)
+		}
+		r.mu.Unlock()
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read input: %w", err)
+	}
+	return nil
+}
+
+// begin records the submission time of an input's trace.
+func (r *run) begin(trace string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started[trace] = time.Now()
+}
+
+// engineError writes terminal signals and records every other error.
+func (r *run) engineError(sig *signal.Signal, err error) {
+	now := time.Now()
+	trace := signal.TraceID(sig)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished[trace] = now
+	if !errors.Is(err, signal.ErrNoDestination) {
+		r.errs = append(r.errs, ErrorRecord{
+			Trace:    trace,
+			SignalID: sig.ID,
+			Type:     sig.Type,
+			Agent:    sig.Destination,
+			Error:    err.Error(),
+		})
+		return
+	}
+	r.terminal++
+	if r.writeErr != nil {
+		return
+	}
+	data, encErr := r.codec.Encode(sig)
+	if encErr != nil {
+		r.errs = append(r.errs, ErrorRecord{Trace: trace, SignalID: sig.ID, Type: sig.Type, Error: encErr.Error()})
+		return
+	}
+	if _, r.writeErr = r.out.Write(data); r.writeErr == nil {
+		r.writeErr = r.out.WriteByte('\n')
+	}
+}
+
+// recordCall records an agent call reported by the engine.
+func (r *run) recordCall(c signal.AgentCall) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[c.Agent] = append(r.calls[c.Agent], call{duration: c.Duration, failed: c.Result.Error != nil})
+}
+
+// summarize builds the Summary; r.mu must be held.
+func (r *run) summarize(elapsed time.Duration) *Summary {
+	s := &Summary{
+		Inputs:   r.inputs,
+		Rejected: r.rejected,
+		Terminal: r.terminal,
+		Errors:   r.errs,
+		Elapsed:  elapsed,
+	}
+	var latencies []time.Duration
+	for trace, start := range r.started {
+		end, ok := r.finished[trace]
+		if !ok {
+			s.Silent++
+			continue
+		}
+		latencies = append(latencies, end.Sub(start))
+	}
+	s.Latency = newLatencyStats(latencies)
+	for agent, calls := range r.calls {
+		durations := make([]time.Duration, len(calls))
+		failed := 0
+		for i, c := range calls {
+			durations[i] = c.duration
+			if c.failed {
+				failed++
+			}
+		}
+		s.Agents = append(s.Agents, AgentStats{Agent: agent, Failed: failed, Latency: newLatencyStats(durations)})
+	}
+	sortAgents(s.Agents)
+	return s
+}
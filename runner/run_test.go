@@ -0,0 +1,193 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+type request struct {
+	Text string `json:"text"`
+}
+
+const testInput = `{"type":"request","payload":{"text":"a"}}
+
+{"type":"request","payload":{"text":"b"},"metadata":{"mode":"risky"}}
+not json
+{"id":"given","type":"request","payload":{"text":"c"}}
+`
+
+func TestRun(t *testing.T) {
+	def, _ := ParseDefinition([]byte(testDefinition))
+	registry := testRegistry()
+	registry.RegisterPayload("request", request{})
+	registry.RegisterPayload("shouted", request{})
+
+	var out bytes.Buffer
+	summary, err := Run(context.Background(), def, registry, strings.NewReader(testInput), &out, Options{})
+	if err != nil {
+		t.Fatalf("Run error = %v", err)
+	}
+
+	if summary.Inputs != 4 || summary.Rejected != 1 || summary.Terminal != 3 {
+		t.Errorf("Unexpected counts %+v", summary)
+	}
+	if len(summary.Errors) != 2 {
+		t.Fatalf("Expected a decode error and an agent error, got %+v", summary.Errors)
+	}
+	if summary.Errors[0].Line != 4 {
+		t.Errorf("Decode error should name line 4, got %+v", summary.Errors[0])
+	}
+	if e := summary.Errors[1]; e.Agent != "broken" || !strings.Contains(e.Error, "boom") {
+		t.Errorf("Unexpected agent error %+v", e)
+	}
+	if summary.Latency.Count != 3 || summary.Silent != 0 {
+		t.Errorf("Expected latencies of 3 inputs, got %+v (silent %d)", summary.Latency, summary.Silent)
+	}
+	if len(summary.Agents) != 2 || summary.Agents[0].Agent != "broken" || summary.Agents[0].Failed != 1 ||
+		summary.Agents[1].Latency.Count != 3 {
+		t.Errorf("Unexpected agent stats %+v", summary.Agents)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 terminal signals, got %q", out.String())
+	}
+	traces := make(map[string]bool)
+	for _, line := range lines {
+		sig, err := registry.Codec().Decode([]byte(line))
+		if err != nil {
+			t.Fatalf("Decode output: %v", err)
+		}
+		if sig.Type != "shouted" || sig.Source != "upper" {
+			t.Errorf("Unexpected terminal signal %+v", sig)
+		}
+		if _, ok := sig.Payload.(request); !ok {
+			t.Errorf("Payload should decode to request, got %T", sig.Payload)
+		}
+		traces[signal.TraceID(sig)] = true
+	}
+	if !traces["given"] {
+		t.Errorf("Outputs should carry the input's trace, got %v", traces)
+	}
+}
+
+func TestRunSilentAndRejected(t *testing.T) {
+	registry := testRegistry()
+	registry.Register("sink", func(spec AgentSpec) (signal.Agent, error) {
+		return signal.NewAgentFunc(spec.ID, func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			return signal.OK()
+		}), nil
+	})
+	schemas := signal.NewSchemaRegistry()
+	schemas.Register("request", signal.Schema{JSON: `{"type":"object","required":["text"]}`})
+	registry.UseSchemas(schemas)
+	def, _ := ParseDefinition([]byte("agents: [{id: sink, kind: sink}]\nrules: [{to: [sink]}]"))
+
+	input := `{"type":"request","payload":{"text":"a"}}
+{"type":"request","payload":{}}
+`
+	var out bytes.Buffer
+	summary, err := Run(context.Background(), def, registry, strings.NewReader(input), &out, Options{})
+	if err != nil {
+		t.Fatalf("Run error = %v", err)
+	}
+	if summary.Silent != 1 || summary.Rejected != 1 || summary.Latency.Count != 0 || out.Len() != 0 {
+		t.Errorf("Unexpected summary %+v, output %q", summary, out.String())
+	}
+	if !summary.Failed() {
+		t.Error("A rejected input should fail the summary")
+	}
+}
+
+func TestRunStopsFeedingWhenContextEnds(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	registry := testRegistry()
+	registry.Register("stuck", func(spec AgentSpec) (signal.Agent, error) {
+		return signal.NewAgentFunc(spec.ID, func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-release
+			return signal.OK()
+		}), nil
+	})
+	def, _ := ParseDefinition([]byte("engine: {buffer_size: 1, worker_count: 1}\nagents: [{id: stuck, kind: stuck}]\nrules: [{to: [stuck]}]"))
+	input := strings.Repeat(`{"type":"request","payload":{"text":"a"}}`+"\n", 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	type outcome struct {
+		summary *Summary
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		summary, err := Run(ctx, def, registry, strings.NewReader(input), &bytes.Buffer{}, Options{})
+		done <- outcome{summary, err}
+	}()
+
+	// The agent holds the only worker, so the feed blocks on the full inbox
+	<-started
+	cancel()
+	close(release) // Lets Stop drain what was submitted
+
+	got := <-done
+	if !errors.Is(got.err, context.Canceled) || !strings.Contains(got.err.Error(), "feed input") {
+		t.Fatalf("Run error = %v, want the feed cancelled", got.err)
+	}
+	if got.summary.Inputs >= 10 {
+		t.Errorf("Inputs = %d, want the feed to stop before the end", got.summary.Inputs)
+	}
+}
+
+func TestMainCommand(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	defPath := write("engine.yaml", testDefinition)
+	inPath := write("in.jsonl", testInput)
+	outPath := filepath.Join(dir, "out.jsonl")
+	summaryPath := filepath.Join(dir, "summary.json")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-def", defPath, "-in", inPath, "-out", outPath, "-summary", summaryPath}
+	if code := runMain(args, testRegistry(), nil, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("exit code = %d, stderr:\n%s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "inputs: 4 (1 rejected)") || !strings.Contains(stderr.String(), "broken") {
+		t.Errorf("Unexpected text summary:\n%s", stderr.String())
+	}
+	if data, _ := os.ReadFile(outPath); strings.Count(string(data), "\n") != 3 {
+		t.Errorf("Expected 3 terminal signals in the output file, got %q", data)
+	}
+	var summary Summary
+	if data, err := os.ReadFile(summaryPath); err != nil || json.Unmarshal(data, &summary) != nil || summary.Terminal != 3 {
+		t.Errorf("Unexpected JSON summary %+v (%v)", summary, err)
+	}
+
+	stderr.Reset()
+	stdin := strings.NewReader(testInput)
+	if code := runMain([]string{"-def", defPath, "-strict"}, testRegistry(), stdin, &stdout, &stderr); code != ExitErrors {
+		t.Errorf("-strict with errors: exit code = %d, want %d", code, ExitErrors)
+	}
+	if code := runMain(nil, testRegistry(), nil, &stdout, &stderr); code != ExitUsage {
+		t.Errorf("Missing -def: exit code = %d, want %d", code, ExitUsage)
+	}
+	if code := runMain([]string{"-def", filepath.Join(dir, "missing.yaml")}, testRegistry(), nil, &stdout, &stderr); code != ExitFailed {
+		t.Errorf("Missing definition: exit code = %d, want %d", code, ExitFailed)
+	}
+}
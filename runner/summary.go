@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// =============================================================================
+// SUMMARY
+// =============================================================================
+
+// Summary reports the outcome of a Run.
+type Summary struct {
+	Inputs   int           `json:"inputs"`   // Non-blank input lines
+	Rejected int           `json:"rejected"` // Inputs that failed to decode or submit
+	Terminal int           `json:"terminal"` // Terminal signals written
+	Silent   int           `json:"silent"`   // Inputs whose trace produced neither output nor error
+	Errors   []ErrorRecord `json:"errors,omitempty"`
+	Latency  LatencyStats  `json:"latency"` // From submission to the trace's last terminal signal or error
+	Agents   []AgentStats  `json:"agents,omitempty"`
+	Elapsed  time.Duration `json:"elapsed"`
+}
+
+// ErrorRecord is a rejected input or an error reported by the engine.
+type ErrorRecord struct {
+	Line     int               `json:"line,omitempty"` // Input line, for rejected inputs
+	Trace    string            `json:"trace,omitempty"`
+	SignalID string            `json:"signal_id,omitempty"`
+	Type     signal.SignalType `json:"type,omitempty"`
+	Agent    string            `json:"agent,omitempty"`
+	Error    string            `json:"error"`
+}
+
+// LatencyStats summarizes a set of durations.
+type LatencyStats struct {
+	Count int           `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// AgentStats summarizes the calls of one agent.
+type AgentStats struct {
+	Agent   string       `json:"agent"`
+	Failed  int          `json:"failed"`
+	Latency LatencyStats `json:"latency"`
+}
+
+// newLatencyStats computes stats with nearest-rank percentiles.
+func newLatencyStats(durations []time.Duration) LatencyStats {
+	if len(durations) == 0 {
+		return LatencyStats{}
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	rank := func(p int) time.Duration {
+		i := (p*len(sorted)+99)/100 - 1
+		return sorted[max(i, 0)]
+	}
+	return LatencyStats{
+		Count: len(sorted),
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   rank(50),
+		P90:   rank(90),
+		P99:   rank(99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+func sortAgents(agents []AgentStats) {
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Agent < agents[j].Agent })
+}
+
+// Failed reports whether any input was rejected or any error occurred.
+func (s *Summary) Failed() bool {
+	return s.Rejected > 0 || len(s.Errors) > 0
+}
+
+func (l LatencyStats) String() string {
+	if l.Count == 0 {
+		return "n=0"
+	}
+	return fmt.Sprintf("n=%d mean=%v p50=%v p90=%v p99=%v max=%v",
+		l.Count, round(l.Mean), round(l.P50), round(l.P90), round(l.P99), round(l.Max))
+}
+
+// round shortens a duration for display.
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d
+}
+
+// WriteText writes a human-readable summary.
+func (s *Summary) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "inputs: %d (%d rejected), terminal signals: %d, errors: %d, silent: %d, elapsed: %v\n",
+		s.Inputs, s.Rejected, s.Terminal, len(s.Errors), s.Silent, round(s.Elapsed))
+	fmt.Fprintf(&b, "latency: %v\n", s.Latency)
+
+	if len(s.Agents) > 0 {
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "agent\tcalls\tfailed\tmean\tp50\tp90\tp99\tmax")
+		for _, a := range s.Agents {
+			l := a.Latency
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%v\t%v\t%v\n",
+				a.Agent, l.Count, a.Failed, round(l.Mean), round(l.P50), round(l.P90), round(l.P99), round(l.Max))
+		}
+		tw.Flush()
+	}
+
+	if len(s.Errors) > 0 {
+		b.WriteString("errors:\n")
+		for _, e := range s.Errors {
+			switch {
+			case e.Line > 0:
+				fmt.Fprintf(&b, "  line %d: %s\n", e.Line, e.Error)
+			case e.Agent != "":
+				fmt.Fprintf(&b, "  %s %s (%s): %s\n", e.Agent, e.SignalID, e.Type, e.Error)
+			default:
+				fmt.Fprintf(&b, "  %s (%s): %s\n", e.SignalID, e.Type, e.Error)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
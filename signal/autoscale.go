@@ -0,0 +1,243 @@
+package signal
+
+import (
+	"fmt"
+	"time"
+)
+
+// =============================================================================
+// AUTOSCALING: Adaptive Worker Pool
+// =============================================================================
+
+// AutoscaleConfig lets the Engine grow and shrink its worker pool with load.
+// The pool starts at EngineConfig.WorkerCount, clamped to [MinWorkers,
+// MaxWorkers]. Every Interval the engine samples the inbox: it grows by Step
+// after UpAfter consecutive samples over a threshold, and shrinks by Step
+// after DownAfter consecutive idle samples. Requiring consecutive samples
+// (hysteresis) keeps short bursts from making the pool flap.
+type AutoscaleConfig struct {
+	// MinWorkers is the smallest pool size. Defaults to 1.
+	MinWorkers int
+
+	// MaxWorkers is the largest pool size.
+	// Defaults to the larger of MinWorkers and WorkerCount.
+	MaxWorkers int
+
+	// QueueDepth is the number of queued signals that counts as overload.
+	// Defaults to half of BufferSize (at least 1).
+	QueueDepth int
+
+	// Wait is the queue wait time that counts as overload: the longest
+	// wait of a signal dequeued since the previous sample. 0 disables it.
+	Wait time.Duration
+
+	// Interval between samples. Defaults to 1 second.
+	Interval time.Duration
+
+	// UpAfter is the number of consecutive overloaded samples before
+	// growing. Defaults to 2.
+	UpAfter int
+
+	// DownAfter is the number of consecutive idle samples (empty inbox and
+	// at least one worker waiting) before shrinking. Defaults to 5.
+	DownAfter int
+
+	// Step is the number of workers added or removed at once. Defaults to 1.
+	Step int
+}
+
+// ScaleEvent describes a change of the worker pool size.
+type ScaleEvent struct {
+	From       int           // Pool size before
+	To         int           // Pool size after
+	Reason     string        // "queue depth", "wait time", "idle" or "manual"
+	QueueDepth int           // Signals queued when sampled
+	Wait       time.Duration // Longest queue wait since the previous sample
+	Manual     bool          // Whether the change came from Resize
+}
+
+// ScaleHook is called after the worker pool is resized.
+type ScaleHook func(event ScaleEvent)
+
+// OnScale sets a hook called whenever the worker pool is resized, either by
+// the autoscaler or through Resize.
+func (e *Engine) OnScale(hook ScaleHook) {
+	e.onScale = hook
+}
+
+// autoscaler holds the sampling state. Guarded by e.mu.
+type autoscaler struct {
+	config     AutoscaleConfig
+	timer      Timer
+	generation int // Invalidates timers armed before a Stop
+	upStreak   int
+	downStreak int
+}
+
+// newAutoscaler applies defaults and clamps the initial worker count.
+func newAutoscaler(config AutoscaleConfig, engine *EngineConfig) *autoscaler {
+	if config.MinWorkers <= 0 {
+		config.MinWorkers = 1
+	}
+	if config.MaxWorkers < config.MinWorkers {
+		config.MaxWorkers = max(config.MinWorkers, engine.WorkerCount)
+	}
+	if config.QueueDepth <= 0 {
+		config.QueueDepth = max(engine.BufferSize/2, 1)
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.UpAfter <= 0 {
+		config.UpAfter = 2
+	}
+	if config.DownAfter <= 0 {
+		config.DownAfter = 5
+	}
+	if config.Step <= 0 {
+		config.Step = 1
+	}
+	engine.WorkerCount = min(max(engine.WorkerCount, config.MinWorkers), config.MaxWorkers)
+	return &autoscaler{config: config}
+}
+
+// Resize sets the worker pool size. On a running engine workers are added
+// or retired immediately; retired workers finish their current signal
+// first. On a stopped engine it sets the size used by the next Start.
+// With autoscaling enabled, n must lie within [MinWorkers, MaxWorkers] and
+// the autoscaler keeps adjusting from the new size.
+func (e *Engine) Resize(n int) error {
+	if n < 1 {
+		return fmt.Errorf("worker count must be at least 1, got %d", n)
+	}
+	if a := e.autoscaler; a != nil && (n < a.config.MinWorkers || n > a.config.MaxWorkers) {
+		return fmt.Errorf("worker count %d outside autoscale range [%d, %d]", n, a.config.MinWorkers, a.config.MaxWorkers)
+	}
+
+	e.mu.Lock()
+	from := e.workers
+	e.setWorkers(n)
+	if e.autoscaler != nil {
+		e.autoscaler.upStreak, e.autoscaler.downStreak = 0, 0
+	}
+	e.mu.Unlock()
+
+	if from != n && e.onScale != nil {
+		e.onScale(ScaleEvent{From: from, To: n, Reason: "manual", QueueDepth: len(e.inbox), Manual: true})
+	}
+	return nil
+}
+
+// setWorkers records the pool size and, while running, spawns or retires
+// workers to match it. Caller must hold e.mu.
+func (e *Engine) setWorkers(n int) {
+	e.workers = n
+	if !e.running {
+		return
+	}
+	for len(e.workerQuit) < n {
+		quit := make(chan struct{})
+		e.workerQuit = append(e.workerQuit, quit)
+		e.wg.Add(1)
+		go e.worker(quit)
+	}
+	for len(e.workerQuit) > n {
+		last := len(e.workerQuit) - 1
+		close(e.workerQuit[last])
+		e.workerQuit = e.workerQuit[:last]
+	}
+}
+
+// observeWait records the queue wait of a dequeued signal for the next sample.
+func (e *Engine) observeWait(wait time.Duration) {
+	for {
+		current := e.maxWait.Load()
+		if int64(wait) <= current || e.maxWait.CompareAndSwap(current, int64(wait)) {
+			return
+		}
+	}
+}
+
+// startAutoscaler begins sampling. Caller must hold e.mu.
+func (e *Engine) startAutoscaler() {
+	a := e.autoscaler
+	if a == nil {
+		return
+	}
+	a.upStreak, a.downStreak = 0, 0
+	e.maxWait.Store(0)
+	e.armAutoscaler()
+}
+
+// stopAutoscaler stops sampling. Caller must hold e.mu.
+func (e *Engine) stopAutoscaler() {
+	a := e.autoscaler
+	if a == nil || a.timer == nil {
+		return
+	}
+	a.timer.Stop()
+	a.timer = nil
+	a.generation++
+}
+
+// armAutoscaler schedules the next sample. Caller must hold e.mu.
+func (e *Engine) armAutoscaler() {
+	a := e.autoscaler
+	generation := a.generation
+	a.timer = e.config.Clock.AfterFunc(a.config.Interval, func() { e.sampleLoad(generation) })
+}
+
+// sampleLoad takes one sample, resizes the pool when a streak completes and
+// schedules the next sample.
+func (e *Engine) sampleLoad(generation int) {
+	e.mu.Lock()
+	a := e.autoscaler
+	if !e.running || a.generation != generation {
+		e.mu.Unlock()
+		return // Stopped since this sample was armed
+	}
+
+	depth := len(e.inbox)
+	wait := time.Duration(e.maxWait.Swap(0))
+	busy := int(e.busy.Load())
+	from := e.workers
+
+	var reason string
+	switch {
+	case depth >= a.config.QueueDepth:
+		reason = "queue depth"
+	case a.config.Wait > 0 && wait >= a.config.Wait:
+		reason = "wait time"
+	}
+
+	to := from
+	switch {
+	case reason != "":
+		a.upStreak++
+		a.downStreak = 0
+		if a.upStreak >= a.config.UpAfter && from < a.config.MaxWorkers {
+			to = min(from+a.config.Step, a.config.MaxWorkers)
+			a.upStreak = 0
+		}
+	case depth == 0 && busy < from:
+		a.downStreak++
+		a.upStreak = 0
+		if a.downStreak >= a.config.DownAfter && from > a.config.MinWorkers {
+			to = max(from-a.config.Step, a.config.MinWorkers)
+			reason = "idle"
+			a.downStreak = 0
+		}
+	default:
+		a.upStreak, a.downStreak = 0, 0
+	}
+
+	if to != from {
+		e.setWorkers(to)
+	}
+	e.armAutoscaler()
+	e.mu.Unlock()
+
+	if to != from && e.onScale != nil {
+		e.onScale(ScaleEvent{From: from, To: to, Reason: reason, QueueDepth: depth, Wait: wait})
+	}
+}
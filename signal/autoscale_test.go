@@ -0,0 +1,183 @@
+package signal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scaleHarness runs an engine whose agent blocks until released, with the
+// autoscaler driven by a manual clock.
+type scaleHarness struct {
+	engine  *Engine
+	clock   *ManualClock
+	release chan struct{}
+
+	mu     sync.Mutex
+	events []ScaleEvent
+}
+
+func newScaleHarness(t *testing.T, workers int, autoscale AutoscaleConfig) *scaleHarness {
+	t.Helper()
+	h := &scaleHarness{
+		clock:   NewManualClock(time.Unix(0, 0)),
+		release: make(chan struct{}),
+	}
+	config := DefaultConfig()
+	config.BufferSize = 10
+	config.WorkerCount = workers
+	config.Clock = h.clock
+	config.Autoscale = &autoscale
+	h.engine = newTestEngine(t, config, nil, NewAgentFunc("slow", func(ctx context.Context, sig *Signal) AgentResult {
+		<-h.release
+		return OK()
+	}))
+	h.engine.OnScale(func(event ScaleEvent) {
+		h.mu.Lock()
+		h.events = append(h.events, event)
+		h.mu.Unlock()
+	})
+	startEngine(t, h.engine)
+	t.Cleanup(func() { close(h.release) }) // Runs before Stop
+	return h
+}
+
+func (h *scaleHarness) submit(n int) {
+	for i := 0; i < n; i++ {
+		h.engine.Submit(NewSignal("task", i).WithDestination("slow"))
+	}
+}
+
+func (h *scaleHarness) scaled() []ScaleEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]ScaleEvent(nil), h.events...)
+}
+
+func TestAutoscaleGrowsOnQueueDepth(t *testing.T) {
+	h := newScaleHarness(t, 1, AutoscaleConfig{MaxWorkers: 3, QueueDepth: 2, UpAfter: 2})
+
+	h.submit(5)
+	waitFor(t, func() bool { return h.engine.Stats().BusyWorkers == 1 })
+
+	h.clock.Advance(time.Second)
+	if len(h.scaled()) != 0 {
+		t.Fatal("Pool grew after a single overloaded sample")
+	}
+	h.clock.Advance(time.Second)
+	waitFor(t, func() bool { return h.engine.Stats().BusyWorkers == 2 })
+
+	events := h.scaled()
+	if len(events) != 1 || events[0].From != 1 || events[0].To != 2 || events[0].Reason != "queue depth" || events[0].Manual {
+		t.Fatalf("Events = %+v, want one growth to 2 on queue depth", events)
+	}
+
+	for i := 0; i < 6; i++ {
+		h.clock.Advance(time.Second)
+	}
+	if stats := h.engine.Stats(); stats.WorkerCount != 3 {
+		t.Errorf("WorkerCount = %d, want capped at MaxWorkers 3", stats.WorkerCount)
+	}
+}
+
+func TestAutoscaleGrowsOnWaitTime(t *testing.T) {
+	h := newScaleHarness(t, 1, AutoscaleConfig{MaxWorkers: 2, QueueDepth: 100, Wait: 500 * time.Millisecond, UpAfter: 1})
+
+	h.submit(2)
+	waitFor(t, func() bool { return h.engine.Stats().BusyWorkers == 1 })
+
+	h.clock.Advance(time.Second) // Queue is shallow, nothing dequeued yet
+	if len(h.scaled()) != 0 {
+		t.Fatal("Pool grew without a long wait")
+	}
+	h.release <- struct{}{} // Second signal is dequeued after waiting 1s
+	waitFor(t, func() bool { return h.engine.maxWait.Load() != 0 })
+
+	h.clock.Advance(time.Second)
+	events := h.scaled()
+	if len(events) != 1 || events[0].Reason != "wait time" || events[0].Wait != time.Second {
+		t.Fatalf("Events = %+v, want growth on a 1s wait", events)
+	}
+}
+
+func TestAutoscaleShrinksWhenIdle(t *testing.T) {
+	h := newScaleHarness(t, 3, AutoscaleConfig{MinWorkers: 2, MaxWorkers: 4, DownAfter: 3})
+
+	for i := 0; i < 2; i++ {
+		h.clock.Advance(time.Second)
+	}
+	if len(h.scaled()) != 0 {
+		t.Fatal("Pool shrank before DownAfter consecutive idle samples")
+	}
+	for i := 0; i < 10; i++ {
+		h.clock.Advance(time.Second)
+	}
+	events := h.scaled()
+	if len(events) != 1 || events[0].From != 3 || events[0].To != 2 || events[0].Reason != "idle" {
+		t.Fatalf("Events = %+v, want one shrink to MinWorkers", events)
+	}
+}
+
+func TestResize(t *testing.T) {
+	release := make(chan struct{})
+	router := NewRouter()
+	router.Register(NewAgentFunc("slow", func(ctx context.Context, sig *Signal) AgentResult {
+		<-release
+		return OK()
+	}))
+	config := DefaultConfig()
+	config.WorkerCount = 1
+	engine := NewEngine(config, router)
+	var events []ScaleEvent
+	engine.OnScale(func(event ScaleEvent) { events = append(events, event) })
+
+	if err := engine.Resize(2); err != nil { // Stopped: sets the start size
+		t.Fatalf("Resize() error = %v", err)
+	}
+	engine.Start()
+	defer engine.Stop()
+	defer close(release)
+
+	if err := engine.Resize(3); err != nil {
+		t.Fatalf("Resize() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		engine.Submit(NewSignal("task", i).WithDestination("slow"))
+	}
+	waitFor(t, func() bool { return engine.Stats().BusyWorkers == 3 })
+
+	if err := engine.Resize(1); err != nil {
+		t.Fatalf("Resize() error = %v", err)
+	}
+	if stats := engine.Stats(); stats.WorkerCount != 1 || stats.BusyWorkers != 3 {
+		t.Errorf("Stats = %+v, want 1 worker with retiring workers still busy", stats)
+	}
+	if err := engine.Resize(0); err == nil {
+		t.Error("Resize(0) should fail")
+	}
+
+	if len(events) != 3 || !events[1].Manual || events[1].From != 2 || events[1].To != 3 || events[2].Reason != "manual" {
+		t.Errorf("Events = %+v, want three manual resizes", events)
+	}
+}
+
+func TestResizeWithinAutoscaleRange(t *testing.T) {
+	config := DefaultConfig()
+	config.WorkerCount = 10
+	config.Autoscale = &AutoscaleConfig{MinWorkers: 2, MaxWorkers: 4}
+	engine := NewEngine(config, NewRouter())
+
+	if stats := engine.Stats(); stats.WorkerCount != 4 {
+		t.Errorf("WorkerCount = %d, want clamped to MaxWorkers", stats.WorkerCount)
+	}
+	if err := engine.Resize(5); err == nil {
+		t.Error("Resize() above MaxWorkers should fail")
+	}
+	if err := engine.Resize(1); err == nil {
+		t.Error("Resize() below MinWorkers should fail")
+	}
+	if err := engine.Resize(3); err != nil {
+		t.Errorf("Resize() error = %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// BATCH: Size and Time Windows
+// =============================================================================
+
+// BatchHandler processes a batch of signals. Output signals whose ParentID
+// is the ID of a batch item are attributed to that item; other outputs are
+// attributed to the first successful item. Returning a BatchItemErrors
+// error fails only the listed items; any other error fails the whole batch.
+type BatchHandler func(ctx context.Context, signals []*Signal) AgentResult
+
+// BatchItemErrors maps the IDs of failed signals in a batch to their errors.
+type BatchItemErrors map[string]error
+
+// Error lists the failed items.
+func (e BatchItemErrors) Error() string {
+	ids := make([]string, 0, len(e))
+	for id := range e {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("%s: %v", truncateID(id), e[id])
+	}
+	return fmt.Sprintf("%d batch items failed (%s)", len(e), strings.Join(parts, "; "))
+}
+
+// BatchConfig configures a BatchAgent.
+type BatchConfig struct {
+	// MaxSize flushes a batch once it holds this many signals. Defaults to 100.
+	MaxSize int
+
+	// MaxWait flushes a batch this long after its first signal arrived.
+	// Defaults to 1 second.
+	MaxWait time.Duration
+
+	// Key groups signals into separate batches. Defaults to a single batch.
+	Key func(signal *Signal) string
+
+	// Timeout bounds each handler call. Defaults to 30 seconds.
+	Timeout time.Duration
+
+	// Clock drives MaxWait. Defaults to the system clock.
+	Clock Clock
+}
+
+// batchItem is a buffered signal and the completion of its Process call.
+type batchItem struct {
+	signal   *Signal
+	complete func(AgentResult)
+}
+
+// batchBuffer holds the pending items of one key.
+type batchBuffer struct {
+	items []batchItem
+	timer Timer
+}
+
+// BatchAgent is an adapter that buffers signals per key and hands them to a
+// batch handler when MaxSize or MaxWait is reached. Each signal's Process
+// call is deferred (see Defer) until its batch has been handled, so every
+// signal still gets its own result: hooks, errors and outputs are reported
+// per signal. Buffered batches are flushed when the Engine stops.
+//
+// When called outside an Engine, Process cannot defer and the handler is
+// invoked with a batch of one.
+type BatchAgent struct {
+	id      string
+	handler BatchHandler
+	config  BatchConfig
+
+	mu      sync.Mutex
+	buffers map[string]*batchBuffer
+}
+
+// NewBatchAgent creates a batching adapter around handler.
+func NewBatchAgent(id string, handler BatchHandler, config BatchConfig) *BatchAgent {
+	if config.MaxSize <= 0 {
+		config.MaxSize = 100
+	}
+	if config.MaxWait <= 0 {
+		config.MaxWait = time.Second
+	}
+	if config.Key == nil {
+		config.Key = func(*Signal) string { return "" }
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.Clock == nil {
+		config.Clock = SystemClock()
+	}
+	return &BatchAgent{
+		id:      id,
+		handler: handler,
+		config:  config,
+		buffers: make(map[string]*batchBuffer),
+	}
+}
+
+// ID returns the agent's unique identifier.
+func (b *BatchAgent) ID() string {
+	return b.id
+}
+
+// Pending returns the number of buffered signals across all keys.
+func (b *BatchAgent) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, buf := range b.buffers {
+		n += len(buf.items)
+	}
+	return n
+}
+
+// Process buffers the signal, flushing its batch if it is full.
+func (b *BatchAgent) Process(ctx context.Context, signal *Signal) AgentResult {
+	complete, ok := Defer(ctx)
+	if !ok {
+		var result AgentResult
+		b.run(ctx, []batchItem{{signal: signal, complete: func(r AgentResult) { result = r }}})
+		return result
+	}
+
+	key := b.config.Key(signal)
+	b.mu.Lock()
+	buf, exists := b.buffers[key]
+	if !exists {
+		buf = &batchBuffer{}
+		b.buffers[key] = buf
+		buf.timer = b.config.Clock.AfterFunc(b.config.MaxWait, func() { b.flushKey(key, buf) })
+	}
+	buf.items = append(buf.items, batchItem{signal: signal, complete: complete})
+	if len(buf.items) < b.config.MaxSize {
+		b.mu.Unlock()
+		return OK()
+	}
+	items := b.take(key, buf)
+	b.mu.Unlock()
+
+	b.handle(items)
+	return OK()
+}
+
+// Flush hands every buffered batch to the handler and waits for them.
+func (b *BatchAgent) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	batches := make([][]batchItem, 0, len(b.buffers))
+	for key, buf := range b.buffers {
+		batches = append(batches, b.take(key, buf))
+	}
+	b.mu.Unlock()
+
+	for _, items := range batches {
+		b.run(ctx, items)
+	}
+	return ctx.Err()
+}
+
+// Close flushes batches buffered after the engine's Flush.
+func (b *BatchAgent) Close(ctx context.Context) error {
+	return b.Flush(ctx)
+}
+
+// flushKey handles the batch of a key when its MaxWait elapses.
+func (b *BatchAgent) flushKey(key string, buf *batchBuffer) {
+	b.mu.Lock()
+	if b.buffers[key] != buf {
+		b.mu.Unlock()
+		return // Already flushed by size or Flush
+	}
+	items := b.take(key, buf)
+	b.mu.Unlock()
+
+	b.handle(items)
+}
+
+// take removes a key's buffer and returns its items. Caller must hold b.mu.
+func (b *BatchAgent) take(key string, buf *batchBuffer) []batchItem {
+	delete(b.buffers, key)
+	buf.timer.Stop()
+	return buf.items
+}
+
+// handle runs the handler for a batch with the configured timeout.
+func (b *BatchAgent) handle(items []batchItem) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.Timeout)
+	defer cancel()
+	b.run(ctx, items)
+}
+
+// call invokes the handler, recovering a panic as an ErrAgentPanic error so
+// that it fails every item of the batch: on a MaxWait flush the handler runs
+// on a timer goroutine, where a panic would crash the process.
+func (b *BatchAgent) call(ctx context.Context, signals []*Signal) (result AgentResult) {
+	defer func() {
+		if v := recover(); v != nil {
+			result = Err(fmt.Errorf("handler %w: %v", ErrAgentPanic, v))
+		}
+	}()
+	return b.handler(ctx, signals)
+}
+
+// run calls the handler and completes every item with its share of the result.
+func (b *BatchAgent) run(ctx context.Context, items []batchItem) {
+	if len(items) == 0 {
+		return
+	}
+	signals := make([]*Signal, len(items))
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		signals[i] = item.signal
+		index[item.signal.ID] = i
+	}
+
+	result := b.call(ctx, signals)
+
+	var itemErrs BatchItemErrors
+	perItem := errors.As(result.Error, &itemErrs)
+
+	outputs := make([][]*Signal, len(items))
+	var unmatched []*Signal
+	for _, out := range result.Signals {
+		if i, ok := index[out.ParentID]; ok {
+			outputs[i] = append(outputs[i], out)
+		} else {
+			unmatched = append(unmatched, out)
+		}
+	}
+
+	for i, item := range items {
+		var err error
+		switch {
+		case perItem:
+			err = itemErrs[item.signal.ID]
+		case result.Error != nil:
+			err = fmt.Errorf("batch '%s' of %d: %w", b.id, len(items), result.Error)
+		}
+		if err != nil {
+			item.complete(Err(err))
+			continue
+		}
+		item.complete(OK(append(outputs[i], unmatched...)...))
+		unmatched = nil
+	}
+}
@@ -0,0 +1,314 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchRecorder is a batch handler that records batch sizes and echoes each
+// item as an "embedded" signal, failing items whose payload is "bad" and
+// panicking on a batch holding "panic".
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]*Signal
+	calls   chan int
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{calls: make(chan int, 16)}
+}
+
+func (r *batchRecorder) handle(ctx context.Context, signals []*Signal) AgentResult {
+	r.mu.Lock()
+	r.batches = append(r.batches, signals)
+	r.mu.Unlock()
+
+	var outputs []*Signal
+	failed := BatchItemErrors{}
+	for _, sig := range signals {
+		if sig.Payload == "panic" {
+			panic("embedding model crashed")
+		}
+		if sig.Payload == "bad" {
+			failed[sig.ID] = errors.New("cannot embed")
+			continue
+		}
+		outputs = append(outputs, sig.Derive("embedded", sig.Payload))
+	}
+	r.calls <- len(signals)
+	if len(failed) > 0 {
+		return AgentResult{Signals: outputs, Error: failed}
+	}
+	return OK(outputs...)
+}
+
+func (r *batchRecorder) expectBatch(t *testing.T, size int) {
+	t.Helper()
+	select {
+	case got := <-r.calls:
+		if got != size {
+			t.Fatalf("Batch size = %d, want %d", got, size)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a batch of %d", size)
+	}
+}
+
+// batchHarness runs a BatchAgent on an engine and collects the processed
+// results of the batch agent and errors per signal payload.
+type batchHarness struct {
+	engine   *Engine
+	clock    *ManualClock
+	recorder *batchRecorder
+
+	mu      sync.Mutex
+	results map[any]AgentResult
+	errs    map[any]error
+	sunk    chan *Signal
+}
+
+func newBatchHarness(t *testing.T, config BatchConfig) *batchHarness {
+	t.Helper()
+	h := &batchHarness{
+		clock:    NewManualClock(time.Unix(0, 0)),
+		recorder: newBatchRecorder(),
+		results:  make(map[any]AgentResult),
+		errs:     make(map[any]error),
+		sunk:     make(chan *Signal, 16),
+	}
+	config.Clock = h.clock
+	route := func(sig *Signal) []string {
+		if sig.Type == "embedded" {
+			return []string{"store"}
+		}
+		return []string{"embedder"}
+	}
+	h.engine = newTestEngine(t, DefaultConfig(), route,
+		NewBatchAgent("embedder", h.recorder.handle, config),
+		NewAgentFunc("store", func(ctx context.Context, sig *Signal) AgentResult {
+			h.sunk <- sig
+			return OK()
+		}),
+	)
+	h.engine.OnSignalProcessed(func(sig *Signal, result AgentResult) {
+		if sig.Destination == "embedder" {
+			h.mu.Lock()
+			h.results[sig.Payload] = result
+			h.mu.Unlock()
+		}
+	})
+	h.engine.OnError(func(sig *Signal, err error) {
+		h.mu.Lock()
+		h.errs[sig.Payload] = err
+		h.mu.Unlock()
+	})
+	startEngine(t, h.engine)
+	return h
+}
+
+func (h *batchHarness) submit(payloads ...string) {
+	for _, p := range payloads {
+		h.engine.Submit(NewSignal("memory", p))
+	}
+}
+
+func (h *batchHarness) expectStored(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-h.sunk:
+		case <-time.After(time.Second):
+			t.Fatalf("Stored %d signals, want %d", i, n)
+		}
+	}
+}
+
+func TestBatchAgentFlushesOnSize(t *testing.T) {
+	h := newBatchHarness(t, BatchConfig{MaxSize: 3, MaxWait: time.Hour})
+
+	h.submit("a", "b", "c", "d")
+	h.recorder.expectBatch(t, 3)
+	h.expectStored(t, 3)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, p := range []string{"a", "b", "c"} {
+		result, ok := h.results[p]
+		if !ok || len(result.Signals) != 1 || result.Signals[0].Payload != p {
+			t.Errorf("Result for %s = %+v, want its own embedded output", p, result)
+		}
+	}
+	if _, ok := h.results["d"]; ok {
+		t.Error("d should still be buffered")
+	}
+}
+
+func TestBatchAgentFlushesOnMaxWait(t *testing.T) {
+	h := newBatchHarness(t, BatchConfig{MaxSize: 10, MaxWait: time.Second})
+
+	h.submit("a", "b")
+	waitFor(t, func() bool { return h.clock.Pending() == 1 && pendingIn(h.engine, "embedder") == 2 })
+
+	h.clock.Advance(999 * time.Millisecond)
+	if pendingIn(h.engine, "embedder") != 2 {
+		t.Fatal("Batch flushed before MaxWait")
+	}
+	h.clock.Advance(time.Millisecond)
+	h.recorder.expectBatch(t, 2)
+	h.expectStored(t, 2)
+}
+
+func TestBatchAgentPerItemErrors(t *testing.T) {
+	h := newBatchHarness(t, BatchConfig{MaxSize: 3, MaxWait: time.Hour})
+
+	h.submit("a", "bad", "c")
+	h.recorder.expectBatch(t, 3)
+	h.expectStored(t, 2)
+
+	h.engine.Stop()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.errs) != 1 || h.errs["bad"] == nil {
+		t.Errorf("Errors = %v, want only the bad item", h.errs)
+	}
+	if h.results["a"].Error != nil || h.results["c"].Error != nil {
+		t.Error("Successful items should not carry an error")
+	}
+}
+
+func TestBatchAgentRecoversHandlerPanics(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSize int
+		flush   func(h *batchHarness)
+	}{
+		{"size", 3, func(h *batchHarness) {}},
+		{"max wait", 10, func(h *batchHarness) {
+			waitFor(t, func() bool { return pendingIn(h.engine, "embedder") == 3 })
+			h.clock.Advance(time.Second) // The handler runs on the timer
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newBatchHarness(t, BatchConfig{MaxSize: tt.maxSize, MaxWait: time.Second})
+			h.submit("a", "panic", "c")
+			tt.flush(h)
+			waitFor(t, func() bool {
+				h.mu.Lock()
+				defer h.mu.Unlock()
+				return len(h.errs) == 3
+			})
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			for payload, err := range h.errs {
+				if !errors.Is(err, ErrAgentPanic) {
+					t.Errorf("Error of %v = %v, want ErrAgentPanic", payload, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchAgentFlushesOnStop(t *testing.T) {
+	h := newBatchHarness(t, BatchConfig{MaxSize: 10, MaxWait: time.Hour})
+
+	h.submit("a", "b")
+	waitFor(t, func() bool { return pendingIn(h.engine, "embedder") == 2 })
+
+	if err := h.engine.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	h.recorder.expectBatch(t, 2)
+	h.expectStored(t, 2) // Outputs are still routed during Stop
+}
+
+func TestBatchAgentFlushesItemsDrainedOnStop(t *testing.T) {
+	recorder := newBatchRecorder()
+	gate := make(chan struct{})
+	stored := make(chan *Signal, 4)
+	router := NewRouter()
+	router.Register(NewBatchAgent("embedder", recorder.handle, BatchConfig{MaxSize: 10, MaxWait: time.Hour}))
+	router.Register(NewAgentFunc("gate", func(ctx context.Context, sig *Signal) AgentResult {
+		<-gate
+		return OK()
+	}))
+	router.Register(NewAgentFunc("store", func(ctx context.Context, sig *Signal) AgentResult {
+		stored <- sig
+		return OK()
+	}))
+	router.AddRule(func(sig *Signal) []string {
+		switch sig.Type {
+		case "gate":
+			return []string{"gate"}
+		case "embedded":
+			return []string{"store"}
+		}
+		return []string{"embedder"}
+	})
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+
+	// The single worker is busy, so both items are still in the inbox when
+	// Stop flushes the agents the first time
+	engine.Submit(NewSignal("gate", nil))
+	engine.Submit(NewSignal("memory", "a"))
+	engine.Submit(NewSignal("memory", "b"))
+	stopped := make(chan error, 1)
+	go func() { stopped <- engine.Stop() }()
+	waitFor(t, func() bool { return !engine.IsRunning() })
+	close(gate)
+
+	if err := <-stopped; err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	recorder.expectBatch(t, 2)
+	if len(stored) != 2 {
+		t.Errorf("Stored %d signals, want the outputs of both drained items", len(stored))
+	}
+}
+
+func TestBatchAgentKeys(t *testing.T) {
+	recorder := newBatchRecorder()
+	batcher := NewBatchAgent("embedder", recorder.handle, BatchConfig{
+		MaxSize: 2,
+		Key:     func(sig *Signal) string { return sig.Metadata["tenant"] },
+	})
+	router := NewRouter()
+	router.Register(batcher)
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+	defer engine.Stop()
+
+	for _, tenant := range []string{"t1", "t2", "t1"} {
+		engine.Submit(NewSignal("memory", tenant).WithDestination("embedder").WithMetadata("tenant", tenant))
+	}
+	recorder.expectBatch(t, 2)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, sig := range recorder.batches[0] {
+		if sig.Metadata["tenant"] != "t1" {
+			t.Errorf("Batch mixes keys: %v", sig.Metadata["tenant"])
+		}
+	}
+}
+
+func TestBatchAgentWithoutEngine(t *testing.T) {
+	recorder := newBatchRecorder()
+	batcher := NewBatchAgent("embedder", recorder.handle, BatchConfig{})
+
+	result := batcher.Process(context.Background(), NewSignal("memory", "a"))
+	if result.Error != nil || len(result.Signals) != 1 {
+		t.Errorf("Process() = %+v, want one output", result)
+	}
+	recorder.expectBatch(t, 1)
+}
+
+// pendingIn returns the number of signals buffered by a BatchAgent.
+func pendingIn(engine *Engine, id string) int {
+	agent, _ := engine.Router().GetAgent(id)
+	return agent.(*BatchAgent).Pending()
+}
@@ -0,0 +1,340 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// MIDDLEWARE: Wrapping Agents
+// =============================================================================
+
+// Middleware wraps an agent to add behavior around its Process calls, such
+// as fault injection (see Chaos). The returned agent keeps the wrapped
+// agent's ID.
+type Middleware func(Agent) Agent
+
+// Chain combines middleware into one; the first wraps outermost.
+func Chain(middleware ...Middleware) Middleware {
+	return func(agent Agent) Agent {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			agent = middleware[i](agent)
+		}
+		return agent
+	}
+}
+
+// wrappedAgent forwards the optional agent interfaces to the agent it wraps,
+// so middleware does not hide subscriptions or lifecycle methods. Middleware
+// agents embed it and implement Process.
+type wrappedAgent struct {
+	inner Agent
+}
+
+func (w wrappedAgent) ID() string {
+	return w.inner.ID()
+}
+
+func (w wrappedAgent) Subscriptions() []Subscription {
+	if sub, ok := w.inner.(Subscriber); ok {
+		return sub.Subscriptions()
+	}
+	return nil
+}
+
+func (w wrappedAgent) Init(ctx context.Context) error {
+	if init, ok := w.inner.(Initializer); ok {
+		return init.Init(ctx)
+	}
+	return nil
+}
+
+func (w wrappedAgent) Flush(ctx context.Context) error {
+	if flusher, ok := w.inner.(Flusher); ok {
+		return flusher.Flush(ctx)
+	}
+	return nil
+}
+
+func (w wrappedAgent) Close(ctx context.Context) error {
+	if closer, ok := w.inner.(Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
+func (w wrappedAgent) Health(ctx context.Context) error {
+	if checker, ok := w.inner.(HealthChecker); ok {
+		return checker.Health(ctx)
+	}
+	return nil
+}
+
+// =============================================================================
+// CHAOS: Fault Injection for Resilience Tests
+// =============================================================================
+
+// ErrInjectedFault is the default error of FaultError; match it with errors.Is.
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultKind is a way a wrapped agent misbehaves.
+type FaultKind int
+
+const (
+	FaultLatency   FaultKind = iota + 1 // Delay the call by Latency
+	FaultError                          // Fail the call without calling the agent
+	FaultDrop                           // Lose an output signal
+	FaultDuplicate                      // Emit an output signal twice
+	FaultPanic                          // Panic instead of calling the agent
+)
+
+// String returns the fault kind's name.
+func (k FaultKind) String() string {
+	switch k {
+	case FaultLatency:
+		return "latency"
+	case FaultError:
+		return "error"
+	case FaultDrop:
+		return "drop"
+	case FaultDuplicate:
+		return "duplicate"
+	case FaultPanic:
+		return "panic"
+	}
+	return fmt.Sprintf("FaultKind(%d)", int(k))
+}
+
+// Fault injects one kind of misbehavior with a probability.
+type Fault struct {
+	Kind FaultKind
+
+	// Probability of the fault: per call, or per output signal for
+	// FaultDrop and FaultDuplicate. 0 never injects it, 1 always does.
+	Probability float64
+
+	// Agents limits the fault to these agent IDs. Empty: every wrapped agent.
+	Agents []string
+
+	// Types limits the fault to calls with these input signal types.
+	// Empty: every type.
+	Types []SignalType
+
+	// Latency is the delay of FaultLatency. A call whose context ends
+	// during the delay fails with the context's error.
+	Latency time.Duration
+
+	// Err is the error of FaultError. Defaults to ErrInjectedFault.
+	Err error
+}
+
+// matches reports whether the fault applies to a call.
+func (f Fault) matches(agent string, t SignalType) bool {
+	return (len(f.Agents) == 0 || slices.Contains(f.Agents, agent)) &&
+		(len(f.Types) == 0 || slices.Contains(f.Types, t))
+}
+
+// ChaosConfig configures a Chaos.
+type ChaosConfig struct {
+	// Faults are drawn independently for every call they match.
+	Faults []Fault
+
+	// Seed makes the injected faults reproducible: each agent draws from
+	// its own generator seeded with Seed and its ID, so with the same seed
+	// an agent's Nth call sees the same faults in every run.
+	Seed uint64
+
+	// Clock times FaultLatency. Defaults to the system clock.
+	Clock Clock
+}
+
+// Chaos injects faults into agents for resilience tests: wrap workers with
+// its Middleware and check that the rest of the flow copes with their
+// latency, errors, lost and duplicated outputs and panics (the Engine
+// recovers panics as ErrAgentPanic failures). Injected faults are logged at
+// debug level to LoggerFrom(ctx) and counted. A Chaos is safe for
+// concurrent use.
+type Chaos struct {
+	config ChaosConfig
+
+	mu       sync.Mutex
+	streams  map[string]*rand.Rand // Per agent ID
+	injected map[FaultKind]uint64
+}
+
+// NewChaos creates a fault injector.
+func NewChaos(config ChaosConfig) *Chaos {
+	if config.Clock == nil {
+		config.Clock = SystemClock()
+	}
+	return &Chaos{
+		config:   config,
+		streams:  make(map[string]*rand.Rand),
+		injected: make(map[FaultKind]uint64),
+	}
+}
+
+// Middleware returns middleware injecting the configured faults.
+func (c *Chaos) Middleware() Middleware {
+	return c.Wrap
+}
+
+// Wrap returns the agent with the configured faults injected.
+func (c *Chaos) Wrap(agent Agent) Agent {
+	return &chaosAgent{wrappedAgent: wrappedAgent{inner: agent}, chaos: c}
+}
+
+// Injected returns how many faults of each kind were injected so far.
+func (c *Chaos) Injected() map[FaultKind]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[FaultKind]uint64, len(c.injected))
+	for kind, n := range c.injected {
+		counts[kind] = n
+	}
+	return counts
+}
+
+// draw decides the call faults of one call. Output faults are drawn after
+// the call, from the returned generator: it is seeded from the agent's
+// stream when the call starts, so concurrent calls of the agent do not
+// interleave their draws.
+func (c *Chaos) draw(agent string, t SignalType) ([]Fault, *rand.Rand) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stream := c.stream(agent)
+	var fired []Fault
+	for _, fault := range c.config.Faults {
+		if fault.Kind == FaultDrop || fault.Kind == FaultDuplicate || !fault.matches(agent, t) {
+			continue
+		}
+		if stream.Float64() < fault.Probability {
+			fired = append(fired, fault)
+			c.injected[fault.Kind]++
+		}
+	}
+	return fired, rand.New(rand.NewPCG(stream.Uint64(), stream.Uint64()))
+}
+
+// drawOutput decides the output faults of one output signal.
+func (c *Chaos) drawOutput(agent string, t SignalType, call *rand.Rand) []Fault {
+	var fired []Fault
+	for _, fault := range c.config.Faults {
+		if (fault.Kind != FaultDrop && fault.Kind != FaultDuplicate) || !fault.matches(agent, t) {
+			continue
+		}
+		if call.Float64() < fault.Probability {
+			fired = append(fired, fault)
+		}
+	}
+	if len(fired) > 0 {
+		c.mu.Lock()
+		for _, fault := range fired {
+			c.injected[fault.Kind]++
+		}
+		c.mu.Unlock()
+	}
+	return fired
+}
+
+// stream returns the random generator of an agent. Caller must hold mu.
+func (c *Chaos) stream(agent string) *rand.Rand {
+	stream, ok := c.streams[agent]
+	if !ok {
+		h := fnv.New64a()
+		h.Write([]byte(agent))
+		stream = rand.New(rand.NewPCG(c.config.Seed, h.Sum64()))
+		c.streams[agent] = stream
+	}
+	return stream
+}
+
+// sleep waits d on the chaos clock, or until ctx is done.
+func (c *Chaos) sleep(ctx context.Context, d time.Duration) error {
+	elapsed := make(chan struct{})
+	timer := c.config.Clock.AfterFunc(d, func() { close(elapsed) })
+	select {
+	case <-elapsed:
+		return nil
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	}
+}
+
+// chaosAgent is an agent wrapped by a Chaos.
+type chaosAgent struct {
+	wrappedAgent
+	chaos *Chaos
+}
+
+// Process injects the faults drawn for the call around the wrapped agent:
+// latency first, then a panic or an error instead of the call, then lost
+// and duplicated outputs. When the wrapped agent defers its result, so
+// does the wrapper, applying the output faults on completion.
+func (a *chaosAgent) Process(ctx context.Context, signal *Signal) AgentResult {
+	id := a.ID()
+	faults, draws := a.chaos.draw(id, signal.Type)
+	logger := LoggerFrom(ctx)
+	for _, fault := range faults {
+		logger.DebugContext(ctx, "fault injected", "fault", fault.Kind.String())
+	}
+
+	for _, fault := range faults {
+		if fault.Kind == FaultLatency {
+			if err := a.chaos.sleep(ctx, fault.Latency); err != nil {
+				return Err(fmt.Errorf("agent '%s' injected latency: %w", id, err))
+			}
+		}
+	}
+	for _, fault := range faults {
+		switch fault.Kind {
+		case FaultPanic:
+			panic(fmt.Sprintf("injected panic (signal %s)", truncateID(signal.ID)))
+		case FaultError:
+			if fault.Err != nil {
+				return Err(fault.Err)
+			}
+			return Err(fmt.Errorf("agent '%s': %w", id, ErrInjectedFault))
+		}
+	}
+
+	return call(ctx, a.inner, signal, true, func(result AgentResult) AgentResult {
+		return a.outputFaults(ctx, signal, result, draws)
+	})
+}
+
+// outputFaults drops and duplicates the outputs of a call. ctx is only
+// used for logging, so it may be done by the time a deferred result comes.
+func (a *chaosAgent) outputFaults(ctx context.Context, signal *Signal, result AgentResult, draws *rand.Rand) AgentResult {
+	if result.Error != nil || len(result.Signals) == 0 {
+		return result
+	}
+	logger := LoggerFrom(ctx)
+	signals := make([]*Signal, 0, len(result.Signals))
+	for _, out := range result.Signals {
+		copies := 1
+		for _, fault := range a.chaos.drawOutput(a.ID(), signal.Type, draws) {
+			logger.DebugContext(ctx, "fault injected", "fault", fault.Kind.String(), LogKeySignalType, string(out.Type))
+			switch fault.Kind {
+			case FaultDrop:
+				copies = 0
+			case FaultDuplicate:
+				if copies > 0 {
+					copies++
+				}
+			}
+		}
+		for range copies {
+			signals = append(signals, out)
+		}
+	}
+	result.Signals = signals
+	return result
+}
@@ -0,0 +1,271 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// echoAgent returns n "out" signals for every call.
+func echoAgent(id string, n int) Agent {
+	return NewAgentFunc(id, func(ctx context.Context, sig *Signal) AgentResult {
+		outputs := make([]*Signal, n)
+		for i := range outputs {
+			outputs[i] = sig.Derive("out", i)
+		}
+		return OK(outputs...)
+	})
+}
+
+// outcomes calls a chaos-wrapped agent n times and describes each result.
+func outcomes(chaos *Chaos, agent Agent, n int) []string {
+	wrapped := chaos.Wrap(agent)
+	var got []string
+	for range n {
+		result := wrapped.Process(context.Background(), NewSignal("task", nil))
+		if result.Error != nil {
+			got = append(got, "error")
+		} else {
+			got = append(got, strings.Repeat("o", len(result.Signals)))
+		}
+	}
+	return got
+}
+
+func TestChaosIsReproducible(t *testing.T) {
+	config := ChaosConfig{Seed: 42, Faults: []Fault{
+		{Kind: FaultError, Probability: 0.3},
+		{Kind: FaultDrop, Probability: 0.3},
+		{Kind: FaultDuplicate, Probability: 0.3},
+	}}
+	first := outcomes(NewChaos(config), echoAgent("worker", 2), 50)
+	second := outcomes(NewChaos(config), echoAgent("worker", 2), 50)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Same seed, different faults:\n%v\n%v", first, second)
+	}
+
+	config.Seed = 7
+	if other := outcomes(NewChaos(config), echoAgent("worker", 2), 50); reflect.DeepEqual(first, other) {
+		t.Error("Different seeds should inject different faults")
+	}
+
+	// Calls of one agent do not shift the faults of another
+	chaos := NewChaos(ChaosConfig{Seed: 42, Faults: config.Faults})
+	outcomes(chaos, echoAgent("other", 2), 10)
+	if got := outcomes(chaos, echoAgent("worker", 2), 50); !reflect.DeepEqual(first, got) {
+		t.Errorf("Faults of worker changed with calls of another agent:\n%v\n%v", first, got)
+	}
+}
+
+func TestChaosFaults(t *testing.T) {
+	custom := errors.New("disk full")
+	tests := []struct {
+		name  string
+		fault Fault
+		check func(t *testing.T, result AgentResult)
+	}{
+		{"error", Fault{Kind: FaultError}, func(t *testing.T, result AgentResult) {
+			if !errors.Is(result.Error, ErrInjectedFault) {
+				t.Errorf("Error = %v, want ErrInjectedFault", result.Error)
+			}
+		}},
+		{"custom error", Fault{Kind: FaultError, Err: custom}, func(t *testing.T, result AgentResult) {
+			if result.Error != custom {
+				t.Errorf("Error = %v, want %v", result.Error, custom)
+			}
+		}},
+		{"drop", Fault{Kind: FaultDrop}, func(t *testing.T, result AgentResult) {
+			if result.Error != nil || len(result.Signals) != 0 {
+				t.Errorf("Expected every output dropped, got %+v", result)
+			}
+		}},
+		{"duplicate", Fault{Kind: FaultDuplicate}, func(t *testing.T, result AgentResult) {
+			if len(result.Signals) != 4 || result.Signals[0] != result.Signals[1] || result.Signals[2] != result.Signals[3] {
+				t.Errorf("Expected every output twice, got %v", result.Signals)
+			}
+		}},
+		{"other agent", Fault{Kind: FaultError, Agents: []string{"coordinator"}}, func(t *testing.T, result AgentResult) {
+			if result.Error != nil || len(result.Signals) != 2 {
+				t.Errorf("Faults of other agents should not apply, got %+v", result)
+			}
+		}},
+		{"other type", Fault{Kind: FaultDrop, Types: []SignalType{"report"}}, func(t *testing.T, result AgentResult) {
+			if result.Error != nil || len(result.Signals) != 2 {
+				t.Errorf("Faults of other types should not apply, got %+v", result)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fault.Probability = 1
+			chaos := NewChaos(ChaosConfig{Faults: []Fault{tt.fault}})
+			tt.check(t, chaos.Wrap(echoAgent("worker", 2)).Process(context.Background(), NewSignal("task", nil)))
+		})
+	}
+
+	chaos := NewChaos(ChaosConfig{Faults: []Fault{
+		{Kind: FaultDrop, Probability: 1},
+		{Kind: FaultError, Probability: 0},
+	}})
+	outcomes(chaos, echoAgent("worker", 2), 3)
+	if got := chaos.Injected(); !reflect.DeepEqual(got, map[FaultKind]uint64{FaultDrop: 6}) {
+		t.Errorf("Injected() = %v", got)
+	}
+}
+
+func TestChaosLatency(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	chaos := NewChaos(ChaosConfig{Clock: clock, Faults: []Fault{
+		{Kind: FaultLatency, Probability: 1, Latency: time.Second},
+	}})
+	agent := chaos.Wrap(echoAgent("worker", 1))
+
+	done := make(chan AgentResult, 1)
+	go func() { done <- agent.Process(context.Background(), NewSignal("task", nil)) }()
+	waitFor(t, func() bool { return clock.Pending() == 1 })
+	select {
+	case <-done:
+		t.Fatal("The call should wait for the injected latency")
+	default:
+	}
+	clock.Advance(time.Second)
+	if result := <-done; result.Error != nil || len(result.Signals) != 1 {
+		t.Errorf("Delayed call = %+v", result)
+	}
+
+	// A deadline during the latency fails the call
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if result := agent.Process(ctx, NewSignal("task", nil)); !errors.Is(result.Error, context.DeadlineExceeded) {
+		t.Errorf("Error = %v, want DeadlineExceeded", result.Error)
+	}
+}
+
+func TestChaosDefersWithWrappedAgent(t *testing.T) {
+	chaos := NewChaos(ChaosConfig{Faults: []Fault{{Kind: FaultDuplicate, Probability: 1}}})
+	batch := NewBatchAgent("embedder", func(ctx context.Context, signals []*Signal) AgentResult {
+		outputs := make([]*Signal, len(signals))
+		for i, sig := range signals {
+			outputs[i] = sig.Derive("embedded", sig.Payload)
+		}
+		return OK(outputs...)
+	}, BatchConfig{MaxSize: 3, MaxWait: time.Hour, Clock: NewManualClock(time.Unix(0, 0))})
+	stored := make(chan *Signal, 16)
+	route := func(sig *Signal) []string {
+		if sig.Type == "embedded" {
+			return []string{"store"}
+		}
+		return []string{"embedder"}
+	}
+	config := DefaultConfig()
+	config.WorkerCount = 1 // A wrapper waiting for its batch would hold the only worker
+	engine := newTestEngine(t, config, route, chaos.Wrap(batch),
+		NewAgentFunc("store", func(ctx context.Context, sig *Signal) AgentResult {
+			stored <- sig
+			return OK()
+		}))
+	startEngine(t, engine)
+
+	for _, p := range []string{"a", "b", "c"} {
+		engine.Submit(NewSignal("memory", p))
+	}
+	counts := map[any]int{}
+	for range 6 {
+		select {
+		case sig := <-stored:
+			counts[sig.Payload]++
+		case <-time.After(time.Second):
+			t.Fatalf("Stored %v, want every batched output twice", counts)
+		}
+	}
+	if !reflect.DeepEqual(counts, map[any]int{"a": 2, "b": 2, "c": 2}) {
+		t.Errorf("Stored %v, want every output duplicated", counts)
+	}
+}
+
+func TestEngineRecoversPanics(t *testing.T) {
+	chaos := NewChaos(ChaosConfig{Faults: []Fault{
+		{Kind: FaultPanic, Probability: 1, Types: []SignalType{"poison"}},
+	}})
+	var mu sync.Mutex
+	var errs []error
+	var processed []SignalType
+	router := NewRouter()
+	router.Register(chaos.Wrap(NewAgentFunc("worker", func(ctx context.Context, sig *Signal) AgentResult {
+		mu.Lock()
+		processed = append(processed, sig.Type)
+		mu.Unlock()
+		return OK()
+	})))
+	router.AddRule(func(sig *Signal) []string { return []string{"worker"} })
+	engine := NewEngine(DefaultConfig(), router)
+	engine.OnError(func(sig *Signal, err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+	engine.Start()
+	defer engine.Stop()
+
+	engine.Submit(NewSignal("poison", nil))
+	engine.Submit(NewSignal("task", nil))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := engine.WaitIdle(ctx); err != nil {
+		t.Fatalf("WaitIdle error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 || !errors.Is(errs[0], ErrAgentPanic) || !strings.Contains(errs[0].Error(), "injected panic") {
+		t.Errorf("Expected one recovered panic, got %v", errs)
+	}
+	if !reflect.DeepEqual(processed, []SignalType{"task"}) {
+		t.Errorf("Processed = %v, want the signal after the panic", processed)
+	}
+	if result := Call(context.Background(), router.agents["worker"], NewSignal("poison", nil)); !errors.Is(result.Error, ErrAgentPanic) {
+		t.Errorf("Call should recover panics, got %v", result.Error)
+	}
+}
+
+func TestMiddlewareKeepsOptionalInterfaces(t *testing.T) {
+	var order []string
+	tag := func(name string) Middleware {
+		return func(agent Agent) Agent {
+			return NewAgentFunc(agent.ID(), func(ctx context.Context, sig *Signal) AgentResult {
+				order = append(order, name)
+				return Call(ctx, agent, sig)
+			})
+		}
+	}
+	chain := Chain(tag("outer"), tag("inner"))
+	chain(echoAgent("worker", 0)).Process(context.Background(), NewSignal("task", nil))
+	if !reflect.DeepEqual(order, []string{"outer", "inner"}) {
+		t.Errorf("Chain order = %v", order)
+	}
+
+	// Subscriptions and lifecycle methods reach the wrapped agent
+	chaos := NewChaos(ChaosConfig{})
+	router := NewRouter()
+	router.Register(chaos.Wrap(&subscribingAgent{
+		mockAgent: mockAgent{id: "worker"},
+		subs:      []Subscription{{Types: []SignalType{"task"}}},
+	}))
+	if got := router.Route(NewSignal("task", nil)); !reflect.DeepEqual(got, []string{"worker"}) {
+		t.Errorf("Route = %v, want the wrapped subscriber", got)
+	}
+
+	agent := &lifecycleAgent{mockAgent: mockAgent{id: "res"}, healthErr: errors.New("down")}
+	router.Register(chaos.Wrap(agent))
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+	health := engine.Health(context.Background())
+	engine.Stop()
+	if agent.inits.Load() != 1 || agent.closes.Load() != 1 || health.Agents["res"] == nil {
+		t.Errorf("Init = %d, Close = %d, health = %v", agent.inits.Load(), agent.closes.Load(), health.Agents["res"])
+	}
+}
@@ -0,0 +1,150 @@
+package signal
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// CLOCK: Injectable Time Source
+// =============================================================================
+
+// Clock abstracts the passage of time so schedules can be tested without
+// sleeping. The zero EngineConfig uses the system clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine once d has elapsed.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending AfterFunc call.
+type Timer interface {
+	// Stop prevents the call from running. It reports whether the call
+	// was stopped before it ran.
+	Stop() bool
+}
+
+// SystemClock returns the Clock backed by the time package.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// ManualClock is a Clock that only moves when told to. Timers fire
+// synchronously, in time order, from Advance and Set.
+// ManualClock is safe for concurrent use.
+type ManualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+	seq    uint64 // Orders timers due at the same instant
+}
+
+type manualTimer struct {
+	clock *ManualClock
+	when  time.Time
+	seq   uint64
+	f     func()
+}
+
+// NewManualClock creates a manual clock set to start.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now returns the clock's current time.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f to run when the clock reaches Now()+d.
+// A non-positive d runs f on the next Advance or Set.
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	t := &manualTimer{clock: c, when: c.now.Add(d), seq: c.seq, f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer that becomes due.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to t, firing every timer due at or before t. Each
+// timer runs with the clock set to its due time, so timers it schedules
+// fire within the same call if they are due by t.
+func (c *ManualClock) Set(t time.Time) {
+	for {
+		c.mu.Lock()
+		next := c.nextDue(t)
+		if next == nil {
+			if t.After(c.now) {
+				c.now = t
+			}
+			c.mu.Unlock()
+			return
+		}
+		c.remove(next)
+		if next.when.After(c.now) {
+			c.now = next.when
+		}
+		c.mu.Unlock()
+		next.f()
+	}
+}
+
+// Pending returns the number of timers that have not fired or been stopped.
+func (c *ManualClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// nextDue returns the earliest timer due at or before t. Caller must hold c.mu.
+func (c *ManualClock) nextDue(t time.Time) *manualTimer {
+	sort.Slice(c.timers, func(i, j int) bool {
+		a, b := c.timers[i], c.timers[j]
+		if a.when.Equal(b.when) {
+			return a.seq < b.seq
+		}
+		return a.when.Before(b.when)
+	})
+	if len(c.timers) == 0 || c.timers[0].when.After(t) {
+		return nil
+	}
+	return c.timers[0]
+}
+
+// remove drops a timer. Caller must hold c.mu.
+func (c *ManualClock) remove(t *manualTimer) bool {
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Stop cancels the timer.
+func (t *manualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
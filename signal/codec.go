@@ -0,0 +1,226 @@
+package signal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// CODEC: Serializing Signals
+// =============================================================================
+
+// Envelope is the wire form of a Signal. Routing and lineage fields are
+// plain JSON values; the payload is kept as raw JSON so it can be decoded
+// into the Go type registered for the signal type.
+type Envelope struct {
+	ID             string            `json:"id"`
+	Type           SignalType        `json:"type"`
+	Timestamp      time.Time         `json:"timestamp"`
+	Source         string            `json:"source,omitempty"`
+	Destination    string            `json:"destination,omitempty"`
+	ParentID       string            `json:"parent_id,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
+	Payload        json.RawMessage   `json:"payload,omitempty"`
+	Signature      *Signature        `json:"signature,omitempty"` // See sign.go
+}
+
+// Codec converts signals to and from JSON. Payloads are encoded with
+// encoding/json; on decode, the payload of a registered signal type is
+// unmarshaled into its registered Go type, and any other payload into
+// generic JSON values (map[string]any, []any, string, float64, bool).
+// A Codec is safe for concurrent use.
+type Codec struct {
+	mu       sync.RWMutex
+	types    map[SignalType]reflect.Type
+	keys     *Keyring  // Signs wrapped envelopes; nil disables signing
+	verifier *Verifier // Verifies unwrapped envelopes; nil disables verification
+}
+
+// NewCodec creates a codec with no registered payload types.
+func NewCodec() *Codec {
+	return &Codec{types: make(map[SignalType]reflect.Type)}
+}
+
+// Register sets the Go type of a signal type's payload from a prototype
+// value: Register("task", Task{}) decodes payloads as Task values and
+// Register("task", &Task{}) as *Task. A nil prototype removes the type.
+func (c *Codec) Register(signalType SignalType, prototype any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prototype == nil {
+		delete(c.types, signalType)
+		return
+	}
+	c.types[signalType] = reflect.TypeOf(prototype)
+}
+
+// SignWith makes Wrap and Encode sign every envelope with the keyring's
+// signing key (see sign.go). nil disables signing.
+func (c *Codec) SignWith(keys *Keyring) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = keys
+}
+
+// VerifyWith makes Unwrap and Decode reject envelopes the verifier does not
+// accept: unsigned, tampered with, stale or replayed. Use it on the codec
+// of a receiver, such as a Server or a process reading signals to Submit;
+// not for replaying old recordings. nil disables verification.
+//
+// Unwrapping does not record a signal as accepted: receivers call Accept
+// when handing the signal to the engine and Forget if that fails.
+func (c *Codec) VerifyWith(v *Verifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.verifier = v
+}
+
+// Accept records an unwrapped signal as accepted by the codec's verifier, so
+// that it is rejected as a replay from then on (see Verifier.Accept). It
+// does nothing without a verifier.
+func (c *Codec) Accept(signal *Signal) error {
+	c.mu.RLock()
+	verifier := c.verifier
+	c.mu.RUnlock()
+	if verifier == nil {
+		return nil
+	}
+	return verifier.Accept(signal)
+}
+
+// Forget drops the acceptance of a signal that then failed (see
+// Verifier.Forget). It does nothing without a verifier.
+func (c *Codec) Forget(signal *Signal) {
+	c.mu.RLock()
+	verifier := c.verifier
+	c.mu.RUnlock()
+	if verifier != nil {
+		verifier.Forget(signal)
+	}
+}
+
+// PayloadType returns the Go type registered for a signal type.
+func (c *Codec) PayloadType(signalType SignalType) (reflect.Type, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	t, ok := c.types[signalType]
+	return t, ok
+}
+
+// Types returns the registered signal types, sorted.
+func (c *Codec) Types() []SignalType {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	types := make([]SignalType, 0, len(c.types))
+	for t := range c.types {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Wrap converts a signal to its envelope, encoding the payload.
+func (c *Codec) Wrap(signal *Signal) (Envelope, error) {
+	env := Envelope{
+		ID:             signal.ID,
+		Type:           signal.Type,
+		Timestamp:      signal.Timestamp,
+		Source:         signal.Source,
+		Destination:    signal.Destination,
+		ParentID:       signal.ParentID,
+		Metadata:       signal.Metadata,
+		IdempotencyKey: signal.IdempotencyKey,
+	}
+	if signal.Payload != nil {
+		payload, err := json.Marshal(signal.Payload)
+		if err != nil {
+			return Envelope{}, fmt.Errorf("encode payload of signal type '%s': %w", signal.Type, err)
+		}
+		env.Payload = payload
+	}
+	c.mu.RLock()
+	keys := c.keys
+	c.mu.RUnlock()
+	if keys != nil {
+		if err := keys.Sign(&env); err != nil {
+			return Envelope{}, err
+		}
+	}
+	return env, nil
+}
+
+// Unwrap converts an envelope back to a signal, decoding the payload.
+func (c *Codec) Unwrap(env Envelope) (*Signal, error) {
+	c.mu.RLock()
+	verifier := c.verifier
+	c.mu.RUnlock()
+	if verifier != nil {
+		if err := verifier.Verify(env); err != nil {
+			return nil, err
+		}
+	}
+	payload, err := c.DecodePayload(env.Type, env.Payload)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]string, len(env.Metadata))
+	for k, v := range env.Metadata {
+		metadata[k] = v
+	}
+	return &Signal{
+		ID:             env.ID,
+		Type:           env.Type,
+		Timestamp:      env.Timestamp,
+		Source:         env.Source,
+		Destination:    env.Destination,
+		Payload:        payload,
+		ParentID:       env.ParentID,
+		Metadata:       metadata,
+		IdempotencyKey: env.IdempotencyKey,
+	}, nil
+}
+
+// DecodePayload decodes raw JSON into the payload type registered for the
+// signal type. Empty or null input decodes to a nil payload.
+func (c *Codec) DecodePayload(signalType SignalType, raw json.RawMessage) (any, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	t, ok := c.PayloadType(signalType)
+	if !ok {
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("decode payload of signal type '%s': %w", signalType, err)
+		}
+		return v, nil
+	}
+
+	target := reflect.New(t)
+	if err := json.Unmarshal(raw, target.Interface()); err != nil {
+		return nil, fmt.Errorf("decode payload of signal type '%s' as %s: %w", signalType, t, err)
+	}
+	return target.Elem().Interface(), nil
+}
+
+// Encode serializes a signal to JSON.
+func (c *Codec) Encode(signal *Signal) ([]byte, error) {
+	env, err := c.Wrap(signal)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+// Decode deserializes a signal from JSON produced by Encode.
+func (c *Codec) Decode(data []byte) (*Signal, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("decode signal: %w", err)
+	}
+	return c.Unwrap(env)
+}
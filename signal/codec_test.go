@@ -0,0 +1,90 @@
+package signal
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type codecTask struct {
+	Title    string   `json:"title"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	codec := NewCodec()
+	codec.Register("task", codecTask{})
+	codec.Register("task_ref", &codecTask{})
+
+	parent := NewSignal("request", nil)
+	original := parent.Derive("task", codecTask{Title: "summarize", Priority: 2}).
+		WithDestination("worker").
+		WithMetadata("tenant", "acme").
+		WithIdempotencyKey("k1")
+
+	data, err := codec.Encode(original)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	decoded, err := codec.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !decoded.Timestamp.Equal(original.Timestamp) {
+		t.Errorf("Timestamp = %v, want %v", decoded.Timestamp, original.Timestamp)
+	}
+	decoded.Timestamp = original.Timestamp
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("Decode() = %+v, want %+v", decoded, original)
+	}
+
+	ref, err := codec.Decode(mustEncode(t, codec, NewSignal("task_ref", &codecTask{Title: "x"})))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if p, ok := ref.Payload.(*codecTask); !ok || p.Title != "x" {
+		t.Errorf("Payload = %#v, want *codecTask", ref.Payload)
+	}
+}
+
+func TestCodecUnregisteredPayload(t *testing.T) {
+	codec := NewCodec()
+
+	decoded, err := codec.Decode(mustEncode(t, codec, NewSignal("note", map[string]any{"n": 1})))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got := decoded.Payload.(map[string]any)["n"]; got != float64(1) {
+		t.Errorf("Payload n = %#v, want generic JSON number", got)
+	}
+
+	empty, _ := codec.Decode(mustEncode(t, codec, NewSignal("ping", nil)))
+	if empty.Payload != nil || empty.Metadata == nil {
+		t.Errorf("Decoded = %+v, want nil payload and empty metadata", empty)
+	}
+}
+
+func TestCodecDecodeErrors(t *testing.T) {
+	codec := NewCodec()
+	codec.Register("task", codecTask{})
+
+	if _, err := codec.Decode([]byte("{")); err == nil {
+		t.Error("Decode() of malformed JSON should fail")
+	}
+	if _, err := codec.DecodePayload("task", json.RawMessage(`{"priority":"high"}`)); err == nil {
+		t.Error("DecodePayload() of a mistyped field should fail")
+	}
+	if types := codec.Types(); len(types) != 1 || types[0] != "task" {
+		t.Errorf("Types() = %v, want [task]", types)
+	}
+}
+
+func mustEncode(t *testing.T, codec *Codec, sig *Signal) []byte {
+	t.Helper()
+	data, err := codec.Encode(sig)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	return data
+}
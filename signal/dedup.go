@@ -0,0 +1,280 @@
+package signal
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// DEDUPLICATION: Idempotency Keys
+// =============================================================================
+
+// MetaIdempotencyKey is the metadata key read when Signal.IdempotencyKey is
+// empty, so keys can arrive from external callers as plain metadata.
+const MetaIdempotencyKey = "idempotency_key"
+
+// WithIdempotencyKey returns a new signal with the idempotency key set.
+func (s *Signal) WithIdempotencyKey(key string) *Signal {
+	newSig := s.WithSource(s.Source) // Copy with its own metadata map
+	newSig.IdempotencyKey = key
+	return newSig
+}
+
+// idempotencyKey returns the signal's explicit key, or the metadata key.
+func idempotencyKey(signal *Signal) string {
+	if signal.IdempotencyKey != "" {
+		return signal.IdempotencyKey
+	}
+	return signal.Metadata[MetaIdempotencyKey]
+}
+
+// DedupMode selects how the Engine answers a duplicate signal.
+type DedupMode int
+
+const (
+	// DedupDrop discards duplicates.
+	DedupDrop DedupMode = iota
+	// DedupReplay answers duplicates with the cached results of the first
+	// signal: the processed hook is called with the duplicate and each
+	// destination's cached AgentResult. No agent runs again and no output
+	// signals are resubmitted.
+	DedupReplay
+)
+
+// String returns the mode name.
+func (m DedupMode) String() string {
+	if m == DedupReplay {
+		return "replay"
+	}
+	return "drop"
+}
+
+// DedupConfig enables deduplication of signals carrying an idempotency key.
+// Signals without a key are never deduplicated.
+type DedupConfig struct {
+	// Window is how long a key is remembered after its first signal.
+	// Defaults to 10 minutes.
+	Window time.Duration
+
+	// MaxKeys bounds the number of remembered keys; the oldest are forgotten
+	// first. Defaults to 10000.
+	MaxKeys int
+
+	// Mode selects drop or replay. Defaults to DedupDrop.
+	Mode DedupMode
+}
+
+// Duplicate describes a signal recognized as a duplicate.
+type Duplicate struct {
+	Key        string        // Idempotency key
+	OriginalID string        // ID of the first signal with this key
+	Age        time.Duration // Time since the first signal
+	Action     DedupMode     // What the engine did with the duplicate
+}
+
+// DuplicateHook is called when a duplicate signal is detected.
+type DuplicateHook func(signal *Signal, dup Duplicate)
+
+// OnDuplicate sets a hook called for every duplicate signal, before it is
+// dropped or answered from the cache.
+func (e *Engine) OnDuplicate(hook DuplicateHook) {
+	e.onDuplicate = hook
+}
+
+// dedupEntry remembers the first signal with a key and, in replay mode,
+// the results of each of its destinations.
+type dedupEntry struct {
+	key        string
+	originalID string
+	seen       time.Time
+
+	results []destResult
+	pending int       // Destinations still processing the original
+	waiters []*Signal // Duplicates waiting for pending results (replay)
+}
+
+// destResult is the cached result of one destination agent.
+type destResult struct {
+	destID string
+	result AgentResult
+}
+
+// dedupFilter tracks idempotency keys within a time window.
+// Entries are kept in a list ordered by first sighting, so expired and
+// excess entries are evicted from the front.
+type dedupFilter struct {
+	config DedupConfig
+	clock  Clock
+
+	mu       sync.Mutex
+	entries  map[string]*dedupEntry
+	order    *list.List
+	dropped  uint64
+	orphaned []*Signal // Waiting duplicates of evicted originals
+}
+
+func newDedupFilter(config DedupConfig, clock Clock) *dedupFilter {
+	if config.Window <= 0 {
+		config.Window = 10 * time.Minute
+	}
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = 10000
+	}
+	return &dedupFilter{
+		config:  config,
+		clock:   clock,
+		entries: make(map[string]*dedupEntry),
+		order:   list.New(),
+	}
+}
+
+// check registers the first signal with a key or, for a duplicate, returns
+// the original's entry and the cached results available so far. waiting
+// reports that the duplicate was queued for the original's other results.
+func (f *dedupFilter) check(signal *Signal, key string, destinations int) (dup *Duplicate, cached []destResult, waiting bool) {
+	now := f.clock.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.evict(now)
+
+	if entry, ok := f.entries[key]; ok {
+		f.dropped++
+		dup = &Duplicate{
+			Key:        key,
+			OriginalID: entry.originalID,
+			Age:        now.Sub(entry.seen),
+			Action:     f.config.Mode,
+		}
+		if f.config.Mode == DedupReplay {
+			cached = append(cached, entry.results...)
+			if entry.pending > 0 {
+				entry.waiters = append(entry.waiters, signal)
+				waiting = true
+			}
+		}
+		return dup, cached, waiting
+	}
+
+	entry := &dedupEntry{key: key, originalID: signal.ID, seen: now, pending: destinations}
+	f.order.PushBack(entry)
+	f.entries[key] = entry
+	f.evict(now)
+	return nil, nil, false
+}
+
+// record stores a destination's result for the original signal and returns
+// the duplicates waiting for it. final reports that it was the last result,
+// so the waiters are released.
+func (f *dedupFilter) record(signal *Signal, destID string, result AgentResult) (waiters []*Signal, final bool) {
+	if f.config.Mode != DedupReplay {
+		return nil, false
+	}
+	key := idempotencyKey(signal)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry, ok := f.entries[key]
+	if !ok || entry.originalID != signal.ID || entry.pending == 0 {
+		return nil, false
+	}
+	entry.results = append(entry.results, destResult{destID: destID, result: result})
+	entry.pending--
+	waiters = entry.waiters
+	if entry.pending == 0 {
+		entry.waiters = nil
+	}
+	return waiters, entry.pending == 0
+}
+
+// evict forgets keys older than the window and keys beyond MaxKeys.
+// Duplicates still waiting for an evicted original are set aside as
+// orphaned; they will not be replayed. Caller must hold f.mu.
+func (f *dedupFilter) evict(now time.Time) {
+	for front := f.order.Front(); front != nil; front = f.order.Front() {
+		entry := front.Value.(*dedupEntry)
+		if f.order.Len() <= f.config.MaxKeys && now.Sub(entry.seen) < f.config.Window {
+			return
+		}
+		f.order.Remove(front)
+		delete(f.entries, entry.key)
+		f.orphaned = append(f.orphaned, entry.waiters...)
+	}
+}
+
+// takeOrphaned returns and forgets the duplicates orphaned by eviction.
+func (f *dedupFilter) takeOrphaned() []*Signal {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	orphaned := f.orphaned
+	f.orphaned = nil
+	return orphaned
+}
+
+// size returns the number of remembered keys.
+func (f *dedupFilter) size() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.order.Len()
+}
+
+// duplicates returns the number of duplicates detected.
+func (f *dedupFilter) duplicates() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dropped
+}
+
+// filterDuplicate reports whether a routed signal is a duplicate, handling
+// it (hook, drop or replay) if so.
+func (e *Engine) filterDuplicate(signal *Signal, destinations []string) bool {
+	if e.dedup == nil {
+		return false
+	}
+	key := idempotencyKey(signal)
+	if key == "" {
+		return false
+	}
+	// A duplicate waiting for the original's results stays in flight until
+	// they are replayed; count it before it can be queued
+	trace := TraceID(signal)
+	e.inflight.add(trace)
+	dup, cached, waiting := e.dedup.check(signal, key, len(destinations))
+	if !waiting {
+		e.inflight.done(trace)
+	}
+	for _, orphan := range e.dedup.takeOrphaned() {
+		e.inflight.done(TraceID(orphan))
+	}
+	if dup == nil {
+		return false
+	}
+	if e.onDuplicate != nil {
+		e.onDuplicate(signal, *dup)
+	}
+	for _, r := range cached {
+		e.replayResult(signal, r)
+	}
+	return true
+}
+
+// recordDedupResult caches a destination's result and replays it to the
+// duplicates that arrived while the original was processing.
+func (e *Engine) recordDedupResult(processingSignal *Signal, destID string, result AgentResult) {
+	if e.dedup == nil || idempotencyKey(processingSignal) == "" {
+		return
+	}
+	waiters, final := e.dedup.record(processingSignal, destID, result)
+	for _, waiter := range waiters {
+		e.replayResult(waiter, destResult{destID: destID, result: result})
+		if final {
+			e.inflight.done(TraceID(waiter))
+		}
+	}
+}
+
+// replayResult answers a duplicate with a cached result.
+func (e *Engine) replayResult(duplicate *Signal, r destResult) {
+	if e.onSignalProcessed != nil {
+		e.onSignalProcessed(duplicate.WithDestination(r.destID), r.result)
+	}
+}
@@ -0,0 +1,145 @@
+package signal
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newDedupEngine creates an engine with deduplication whose "worker" agent
+// counts its calls and replies with a "done" signal.
+func newDedupEngine(t *testing.T, config DedupConfig, clock Clock) (*Engine, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	route := func(sig *Signal) []string {
+		if sig.Type == "done" {
+			return []string{"sink"}
+		}
+		return []string{"worker"}
+	}
+	engineConfig := DefaultConfig()
+	engineConfig.WorkerCount = 1
+	engineConfig.Clock = clock
+	engineConfig.Dedup = &config
+	engine := newTestEngine(t, engineConfig, route,
+		NewAgentFunc("worker", func(ctx context.Context, sig *Signal) AgentResult {
+			calls.Add(1)
+			return OK(sig.Derive("done", sig.Payload))
+		}),
+		NewAgentFunc("sink", func(ctx context.Context, sig *Signal) AgentResult {
+			return OK()
+		}),
+	)
+	return engine, &calls
+}
+
+func TestDedupDropsDuplicates(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	engine, calls := newDedupEngine(t, DedupConfig{Window: time.Minute}, clock)
+
+	var mu sync.Mutex
+	var dups []Duplicate
+	engine.OnDuplicate(func(sig *Signal, d Duplicate) {
+		mu.Lock()
+		dups = append(dups, d)
+		mu.Unlock()
+	})
+	engine.Start()
+
+	first := NewSignal("request", "a").WithIdempotencyKey("req-1")
+	engine.Submit(first)
+	engine.Submit(NewSignal("request", "a").WithMetadata(MetaIdempotencyKey, "req-1"))
+	engine.Submit(NewSignal("request", "b")) // No key: never deduplicated
+	engine.Stop()
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("Worker calls = %d, want 2", got)
+	}
+	if len(dups) != 1 || dups[0].Key != "req-1" || dups[0].OriginalID != first.ID || dups[0].Action != DedupDrop {
+		t.Errorf("Duplicates = %+v, want one drop of req-1", dups)
+	}
+	if stats := engine.Stats(); stats.Duplicates != 1 || stats.DedupKeys != 1 {
+		t.Errorf("Stats = %+v, want 1 duplicate and 1 key", stats)
+	}
+
+	// Outside the window the key is processed again
+	clock.Advance(time.Minute)
+	engine.Start()
+	engine.Submit(NewSignal("request", "a").WithIdempotencyKey("req-1"))
+	engine.Stop()
+	if got := calls.Load(); got != 3 {
+		t.Errorf("Worker calls after window = %d, want 3", got)
+	}
+}
+
+func TestDedupReplaysCachedResult(t *testing.T) {
+	engine, calls := newDedupEngine(t, DedupConfig{Mode: DedupReplay}, SystemClock())
+
+	var mu sync.Mutex
+	replies := make(map[string]AgentResult) // By processed signal ID
+	engine.OnSignalProcessed(func(sig *Signal, result AgentResult) {
+		if sig.Destination == "worker" {
+			mu.Lock()
+			replies[sig.ID] = result
+			mu.Unlock()
+		}
+	})
+	var action DedupMode = -1
+	engine.OnDuplicate(func(sig *Signal, d Duplicate) { action = d.Action })
+	engine.Start()
+
+	first := NewSignal("request", "a").WithIdempotencyKey("req-1")
+	retry := NewSignal("request", "a").WithIdempotencyKey("req-1")
+	engine.Submit(first)
+	engine.Submit(retry)
+	engine.Stop()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Worker calls = %d, want 1", got)
+	}
+	if action != DedupReplay {
+		t.Errorf("Duplicate action = %v, want replay", action)
+	}
+	original, replayed := replies[first.ID], replies[retry.ID]
+	if len(replayed.Signals) != 1 || replayed.Signals[0] != original.Signals[0] {
+		t.Errorf("Replayed result = %+v, want the original result %+v", replayed, original)
+	}
+}
+
+func TestDedupBoundedKeys(t *testing.T) {
+	engine, calls := newDedupEngine(t, DedupConfig{MaxKeys: 2}, SystemClock())
+	engine.Start()
+
+	for _, key := range []string{"k1", "k2", "k3", "k1"} {
+		engine.Submit(NewSignal("request", nil).WithIdempotencyKey(key))
+	}
+	engine.Stop()
+
+	// k1 was evicted when k3 arrived, so its retry is processed again
+	if got := calls.Load(); got != 4 {
+		t.Errorf("Worker calls = %d, want 4", got)
+	}
+	if keys := engine.Stats().DedupKeys; keys != 2 {
+		t.Errorf("DedupKeys = %d, want 2", keys)
+	}
+}
+
+func TestDeriveDropsIdempotencyKey(t *testing.T) {
+	parent := NewSignal("request", nil).
+		WithIdempotencyKey("explicit").
+		WithMetadata(MetaIdempotencyKey, "meta").
+		WithMetadata("session", "s1")
+	child := parent.Derive("reply", nil)
+
+	if idempotencyKey(child) != "" {
+		t.Errorf("Child key = %q, want none", idempotencyKey(child))
+	}
+	if child.Metadata["session"] != "s1" {
+		t.Error("Derive should still copy other metadata")
+	}
+	if idempotencyKey(parent) != "explicit" {
+		t.Errorf("Parent key = %q, want the explicit key", idempotencyKey(parent))
+	}
+}
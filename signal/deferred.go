@@ -0,0 +1,162 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// =============================================================================
+// DEFERRED RESULTS: Completing a Process Call Later
+// =============================================================================
+
+// deferKey is the context key under which the Engine stores the deferral
+// for the current Process call.
+type deferKey struct{}
+
+// deferral tracks whether a Process call handed its result off to a
+// completion function instead of returning it.
+type deferral struct {
+	mu       sync.Mutex
+	sealed   bool // Process has returned; Defer can no longer be claimed
+	deferred bool
+	complete func(AgentResult)
+}
+
+// Defer detaches the result of the current Process call from its return value.
+// It is meant for agents that finish work after Process returns, such as a
+// join waiting for a deadline or a batch waiting to fill up.
+//
+// When ok is true, the value returned from Process is ignored and complete
+// must be called exactly once (further calls are no-ops) with the final
+// result; the Engine then runs its hooks and submits the output signals as
+// if Process had returned it. The signal remains in flight for the agent
+// until complete is called. The Process context is cancelled when Process
+// returns, so deferred work must not depend on it.
+//
+// ok is false when ctx does not come from an Engine (e.g. an agent called
+// directly in a test) or when Process has already returned. Agents must
+// then produce their result synchronously.
+func Defer(ctx context.Context) (complete func(AgentResult), ok bool) {
+	d, _ := ctx.Value(deferKey{}).(*deferral)
+	if d == nil {
+		return nil, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sealed {
+		return nil, false
+	}
+	d.deferred = true
+	return d.complete, true
+}
+
+// withDeferral attaches a deferral to ctx. complete is invoked at most once.
+func withDeferral(ctx context.Context, complete func(AgentResult)) (context.Context, *deferral) {
+	var once sync.Once
+	d := &deferral{
+		complete: func(result AgentResult) {
+			once.Do(func() { complete(result) })
+		},
+	}
+	return context.WithValue(ctx, deferKey{}, d), d
+}
+
+// seal marks Process as returned and reports whether the result was deferred.
+func (d *deferral) seal() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sealed = true
+	return d.deferred
+}
+
+// Call invokes agent.Process and returns its result. If the agent defers its
+// result, Call waits for the completion (or for ctx to be done); if it
+// panics, the result is an ErrAgentPanic error. Wrapper agents that delegate
+// to another agent use Call so deferred results of the wrapped agent are not
+// lost.
+func Call(ctx context.Context, agent Agent, signal *Signal) AgentResult {
+	return call(ctx, agent, signal, false, func(result AgentResult) AgentResult { return result })
+}
+
+// call invokes agent.Process like Call and returns then applied to the
+// result. With pass set, a deferred result of the agent is not waited for
+// when the caller can Defer its own: the caller's result is deferred and
+// completed with then applied to the agent's, and the returned value is to
+// be ignored.
+func call(ctx context.Context, agent Agent, signal *Signal, pass bool, then func(AgentResult) AgentResult) AgentResult {
+	// The agent may complete before its target is known, even from within
+	// Process, so its result is kept until then
+	var mu sync.Mutex
+	var target func(AgentResult)
+	var pending *AgentResult
+	callCtx, d := withDeferral(ctx, func(result AgentResult) {
+		mu.Lock()
+		if target == nil {
+			pending = &result
+			mu.Unlock()
+			return
+		}
+		mu.Unlock()
+		target(result)
+	})
+	forward := func(f func(AgentResult)) {
+		mu.Lock()
+		if pending == nil {
+			target = f
+			mu.Unlock()
+			return
+		}
+		mu.Unlock()
+		f(*pending)
+	}
+
+	result, panicked := safeProcess(callCtx, agent, signal)
+	if !d.seal() || panicked {
+		forward(func(AgentResult) {}) // A later completion is ignored
+		return then(result)
+	}
+
+	if pass {
+		if complete, ok := Defer(ctx); ok {
+			forward(func(result AgentResult) { complete(then(result)) })
+			return AgentResult{}
+		}
+	}
+	completed := make(chan AgentResult, 1)
+	forward(func(result AgentResult) { completed <- result })
+	select {
+	case result := <-completed:
+		return then(result)
+	case <-ctx.Done():
+		return Err(fmt.Errorf("agent '%s' deferred result: %w", agent.ID(), ctx.Err()))
+	}
+}
+
+// CallDeferred invokes agent.Process and hands its result to complete,
+// which is called exactly once: before CallDeferred returns if the agent
+// returns its result or panics, or whenever the agent completes it if it
+// defers (see Defer). It reports whether the result was deferred. Hosts
+// other than the Engine, such as test engines, use it to keep deferred
+// results pending instead of blocking on them.
+func CallDeferred(ctx context.Context, agent Agent, signal *Signal, complete func(AgentResult)) (deferred bool) {
+	callCtx, d := withDeferral(ctx, complete)
+	result, panicked := safeProcess(callCtx, agent, signal)
+	if d.seal() && !panicked {
+		return true
+	}
+	d.complete(result)
+	return false
+}
+
+// safeProcess invokes agent.Process, recovering a panic as an ErrAgentPanic
+// result.
+func safeProcess(ctx context.Context, agent Agent, signal *Signal) (result AgentResult, panicked bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			result = Err(fmt.Errorf("agent '%s' %w: %v", agent.ID(), ErrAgentPanic, v))
+			panicked = true
+		}
+	}()
+	return agent.Process(ctx, signal), false
+}
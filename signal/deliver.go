@@ -0,0 +1,95 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+)
+
+// =============================================================================
+// DELIVERY: Calling One Agent Through the Engine
+// =============================================================================
+
+// delivery is a signal sent to one agent by Deliver rather than routed:
+// its result goes to reply instead of having its outputs submitted.
+type delivery struct {
+	ctx   context.Context // Cancels the agent's call when done
+	reply func(AgentResult)
+}
+
+// Deliver sends a signal to one agent through the Engine and passes the
+// agent's result to reply instead of submitting its outputs. It is how
+// calls that need the answer, such as remote calls (see Server) and
+// workflow steps, reach agents.
+//
+// The delivery is treated as if the signal had been routed to the agent:
+// its payload is validated against its schema, the policy is checked, it
+// is held while the agent or engine is paused, counted in flight, seen by
+// hooks, taps, loggers and recorders, and pins the agent instance against
+// hot swaps. Outputs are checked as routed outputs are; those the policy
+// denies or whose payload is invalid are reported and left out of the
+// result. Idempotency keys are not deduplicated.
+//
+// reply is called exactly once, possibly after Deliver returns (for held
+// signals and deferred results). Cancelling ctx cancels the agent's call.
+func (e *Engine) Deliver(ctx context.Context, agentID string, signal *Signal, reply func(AgentResult)) {
+	if !e.IsRunning() {
+		reply(Err(ErrNotRunning))
+		return
+	}
+	if err := e.validate(signal); err != nil {
+		reply(Err(err))
+		return
+	}
+
+	trace := TraceID(signal)
+	e.inflight.add(trace)
+	defer e.inflight.done(trace)
+
+	if e.onSignalReceived != nil {
+		e.onSignalReceived(signal)
+	}
+	e.taps.notify(signal)
+
+	if len(e.permitRoute(signal, []string{agentID})) == 0 {
+		v := PolicyViolation{Stage: PolicyRoute, Source: signal.Source, Type: signal.Type, Destination: agentID}
+		reply(Err(fmt.Errorf("%w (id=%s)", v.Err(), truncateID(signal.ID))))
+		return
+	}
+
+	d := &delivery{ctx: ctx, reply: reply}
+	if len(e.holdPaused(signal, []string{agentID}, d)) == 0 {
+		return
+	}
+	slot := e.acquireSlots([]string{agentID})[0]
+	e.processInAgent(signal, agentID, slot, turn{}, d)
+}
+
+// finish handles the result of a delivered signal: hooks run as for a
+// routed signal, and the permitted outputs are passed to reply.
+func (e *Engine) finish(processingSignal *Signal, destID string, result AgentResult, d *delivery) {
+	if e.onSignalProcessed != nil {
+		e.onSignalProcessed(processingSignal, result)
+	}
+	if result.Error != nil {
+		if e.onError != nil {
+			e.onError(processingSignal, result.Error)
+		}
+		d.reply(result)
+		return
+	}
+
+	outputs := make([]*Signal, 0, len(result.Signals))
+	for _, outSignal := range result.Signals {
+		outSignal = outSignal.WithSource(destID)
+		stampTrace(outSignal, processingSignal)
+		if !e.permitEmit(outSignal, destID) {
+			continue
+		}
+		if err := e.validate(outSignal); err != nil {
+			e.reportError(outSignal, destID, fmt.Errorf("invalid output signal: %w", err))
+			continue
+		}
+		outputs = append(outputs, outSignal)
+	}
+	d.reply(OK(outputs...))
+}
@@ -0,0 +1,102 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEngineDeliver(t *testing.T) {
+	registry := NewSchemaRegistry()
+	registry.Register("task", Schema{JSON: taskSchema})
+	router := NewRouter()
+	router.Register(NewAgentFunc("worker", func(ctx context.Context, sig *Signal) AgentResult {
+		if sig.Metadata["fail"] != "" {
+			return Err(errors.New("boom"))
+		}
+		return OK(sig.Derive("done", "ok"), sig.Derive("task", map[string]any{}))
+	}))
+	config := DefaultConfig()
+	config.Schemas = registry
+	engine := NewEngine(config, router)
+
+	var mu sync.Mutex
+	var processed []string
+	var errs []error
+	engine.OnSignalProcessed(func(sig *Signal, result AgentResult) {
+		mu.Lock()
+		processed = append(processed, sig.Destination)
+		mu.Unlock()
+	})
+	engine.OnError(func(sig *Signal, err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+
+	deliver := func(agent string, sig *Signal) chan AgentResult {
+		replies := make(chan AgentResult, 2)
+		engine.Deliver(context.Background(), agent, sig, func(result AgentResult) { replies <- result })
+		return replies
+	}
+	if result := <-deliver("worker", NewSignal("ping", nil)); result.Error == nil {
+		t.Error("Deliver on a stopped engine should fail")
+	}
+
+	engine.Start()
+	defer engine.Stop()
+
+	// The result comes back instead of being routed; invalid outputs are left out
+	result := <-deliver("worker", NewSignal("ping", nil))
+	if result.Error != nil || len(result.Signals) != 1 || result.Signals[0].Type != "done" || result.Signals[0].Source != "worker" {
+		t.Errorf("Deliver result = %+v, want the done output from worker", result)
+	}
+	if result := <-deliver("worker", NewSignal("ping", nil).WithMetadata("fail", "1")); result.Error == nil {
+		t.Error("Expected the agent's error")
+	}
+	if result := <-deliver("missing", NewSignal("ping", nil)); result.Error == nil || !strings.Contains(result.Error.Error(), "not found") {
+		t.Errorf("Unknown agent: result = %+v", result)
+	}
+	var invalid *ValidationError
+	if result := <-deliver("worker", NewSignal("task", map[string]any{})); !errors.As(result.Error, &invalid) {
+		t.Errorf("Invalid payload: error = %v, want a *ValidationError", result.Error)
+	}
+
+	// A paused agent holds the delivery until resumed
+	engine.PauseAgent("worker")
+	replies := deliver("worker", NewSignal("ping", nil))
+	if held := engine.Stats().Held["worker"]; held != 1 {
+		t.Fatalf("Held = %d, want the delivery held", held)
+	}
+	engine.ResumeAgent("worker")
+	select {
+	case result := <-replies:
+		if result.Error != nil {
+			t.Errorf("Held delivery error = %v", result.Error)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Held delivery was not processed after ResumeAgent")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := engine.WaitIdle(ctx); err != nil {
+		t.Fatalf("WaitIdle error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(processed) != 3 {
+		t.Errorf("Processed hooks = %v, want one per call", processed)
+	}
+	for _, err := range errs {
+		if errors.Is(err, ErrNoDestination) {
+			t.Errorf("Delivered outputs should not be routed: %v", err)
+		}
+	}
+	if len(errs) != 4 { // The failed call, the unknown agent and two invalid outputs
+		t.Errorf("Errors = %v", errs)
+	}
+}
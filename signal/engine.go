@@ -2,8 +2,11 @@ package signal
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,6 +28,47 @@ type EngineConfig struct {
 	// ProcessTimeout is the maximum time allowed for a single agent.Process call.
 	// Prevents stuck agents from blocking the system indefinitely.
 	ProcessTimeout time.Duration
+
+	// Clock drives scheduled submissions (see schedule.go) and stamps the
+	// signals of the engine's SignalFactory. nil means the system clock.
+	Clock Clock
+
+	// IDs identifies the signals of the engine's SignalFactory, used for
+	// recurring schedule firings and by agents through FactoryFrom.
+	// nil means DefaultIDGenerator().
+	IDs IDGenerator
+
+	// HoldCapacity bounds the signals held per agent while it or the
+	// engine is paused (see pause.go). Defaults to 1000.
+	HoldCapacity int
+
+	// Dedup drops or replays signals whose idempotency key was already
+	// seen within a window (see dedup.go). nil disables deduplication.
+	Dedup *DedupConfig
+
+	// Fanout processes the destinations of a routed signal in parallel
+	// (see fanout.go). nil calls them one after another on the worker.
+	Fanout *FanoutConfig
+
+	// Autoscale grows and shrinks the worker pool with load, starting from
+	// WorkerCount (see autoscale.go). nil keeps the pool at a fixed size.
+	Autoscale *AutoscaleConfig
+
+	// Schemas validates the payload of every submitted signal, including
+	// agent outputs (see schema.go). nil disables validation.
+	Schemas *SchemaRegistry
+
+	// Policy restricts the signal types each agent may emit and the
+	// destinations they may reach (see policy.go). nil allows everything.
+	Policy *Policy
+
+	// Logger receives structured events about signals, agent calls and
+	// errors (see log.go), and is handed to agents through LoggerFrom.
+	// nil disables engine logging.
+	Logger *slog.Logger
+
+	// Logging sets the levels and sampling of Logger events.
+	Logging LogConfig
 }
 
 // DefaultConfig returns sensible default configuration.
@@ -49,6 +93,20 @@ type ProcessedHook func(signal *Signal, result AgentResult)
 // ErrorHook is called when an error occurs during signal processing.
 type ErrorHook func(signal *Signal, err error)
 
+// ErrNoDestination is reported through the error hook for signals that
+// route to no agent. Such terminal signals are often a flow's final output
+// rather than a failure; match them with errors.Is.
+var ErrNoDestination = errors.New("no destination")
+
+// ErrAgentPanic is the error of an agent call that panicked. The Engine
+// recovers the panic and handles the call as failed, so one misbehaving
+// agent cannot take the process down.
+var ErrAgentPanic = errors.New("panicked")
+
+// ErrNotRunning is returned for signals submitted to an engine that is not
+// running or stops during the submission.
+var ErrNotRunning = errors.New("engine not running")
+
 // =============================================================================
 // ENGINE: The Orchestrator
 // =============================================================================
@@ -59,16 +117,71 @@ type ErrorHook func(signal *Signal, err error)
 type Engine struct {
 	config  EngineConfig
 	router  *Router
-	inbox   chan *Signal
+	inbox   chan queuedSignal
 	done    chan struct{}
 	wg      sync.WaitGroup
 	running bool
 	mu      sync.Mutex
 
+	// While Stop drains the engine, agent outputs are queued here and
+	// processed by Stop once the workers are done (see emit)
+	stopping  bool
+	stopQueue []queuedSignal
+
+	// Agents initialized by the engine, keyed by ID (see lifecycle.go).
+	// lifecycle serializes Start, Stop, Register and Unregister.
+	initialized map[string]Agent
+	lifecycle   sync.Mutex
+
+	// In-flight tracking per agent instance (see hotswap.go)
+	slots   map[string]*agentSlot
+	slotsMu sync.Mutex
+
+	// Creates signals with the configured clock and IDs (see ids.go)
+	factory *SignalFactory
+
+	// Delayed and recurring submissions (see schedule.go)
+	scheduler *scheduler
+
+	// Idempotency key filter (see dedup.go); nil when disabled
+	dedup *dedupFilter
+
+	// Structured event logging (see log.go); nil when disabled
+	log *engineLog
+
+	// Work in flight per trace, for WaitIdle and WaitTrace (see idle.go)
+	inflight *inflight
+
+	// Worker pool (see autoscale.go). workers is the pool size, guarded by
+	// mu; workerQuit holds one retire channel per running worker.
+	workers    int
+	workerQuit []chan struct{}
+	busy       atomic.Int32
+	maxWait    atomic.Int64 // Longest queue wait since the last sample, in ns
+	autoscaler *autoscaler  // nil when autoscaling is disabled
+
+	// Holding areas for paused agents (see pause.go)
+	held         map[string]*holdQueue
+	enginePaused bool
+	drainStopped bool // Held signals are not re-dispatched while stopped
+	holdOverflow uint64
+	drains       sync.WaitGroup
+	holdMu       sync.Mutex
+
 	// Hooks for extensibility and observability
 	onSignalReceived  SignalHook
 	onSignalProcessed ProcessedHook
 	onError           ErrorHook
+	onDuplicate       DuplicateHook
+	onScale           ScaleHook
+	onPolicyViolation PolicyHook
+
+	// Violations of the policy (see policy.go)
+	policyViolations atomic.Uint64
+
+	// Observers of every dequeued signal and completed agent call (see tap.go)
+	taps  tapSet[*Signal]
+	calls tapSet[AgentCall]
 }
 
 // NewEngine creates a new signal engine with the given configuration and router.
@@ -79,13 +192,49 @@ func NewEngine(config EngineConfig, router *Router) *Engine {
 	if config.ProcessTimeout <= 0 {
 		config.ProcessTimeout = 30 * time.Second
 	}
+	if config.Clock == nil {
+		config.Clock = SystemClock()
+	}
+	if config.IDs == nil {
+		config.IDs = DefaultIDGenerator()
+	}
+	if config.HoldCapacity <= 0 {
+		config.HoldCapacity = 1000
+	}
 
-	return &Engine{
+	var dedup *dedupFilter
+	if config.Dedup != nil {
+		dedup = newDedupFilter(*config.Dedup, config.Clock)
+	}
+	var scaler *autoscaler
+	if config.Autoscale != nil {
+		scaler = newAutoscaler(*config.Autoscale, &config)
+	}
+
+	e := &Engine{
 		config: config,
 		router: router,
-		inbox:  make(chan *Signal, config.BufferSize),
+		inbox:  make(chan queuedSignal, config.BufferSize),
 		done:   make(chan struct{}),
+
+		initialized: make(map[string]Agent),
+		slots:       make(map[string]*agentSlot),
+		factory:     NewSignalFactory(config.Clock, config.IDs),
+		scheduler:   newScheduler(config.Clock),
+		dedup:       dedup,
+		inflight:    newInflight(),
+		workers:     config.WorkerCount,
+		autoscaler:  scaler,
+
+		held:         make(map[string]*holdQueue),
+		drainStopped: true,
 	}
+	if config.Logger != nil {
+		e.log = newEngineLog(config.Logger, config.Logging)
+		e.taps.add(e.log.received)
+		e.calls.add(e.log.call)
+	}
+	return e
 }
 
 // =============================================================================
@@ -115,14 +264,26 @@ func (e *Engine) OnError(hook ErrorHook) {
 // =============================================================================
 
 // Start begins processing signals with the configured number of workers.
+// Registered agents implementing Initializer are initialized first; if any
+// Init fails, the engine does not start and the error is returned.
 // Calling Start on an already running engine is a no-op.
-func (e *Engine) Start() {
+func (e *Engine) Start() error {
+	e.lifecycle.Lock()
+	defer e.lifecycle.Unlock()
+
 	e.mu.Lock()
-	defer e.mu.Unlock()
+	running := e.running
+	e.mu.Unlock()
+	if running {
+		return nil
+	}
 
-	if e.running {
-		return
+	if err := e.initAgents(); err != nil {
+		return err
 	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.running = true
 
 	// Reset done channel if restarting (in case Stop was called before)
@@ -135,26 +296,80 @@ func (e *Engine) Start() {
 	}
 
 	// Spin up worker goroutines
-	for i := 0; i < e.config.WorkerCount; i++ {
-		e.wg.Add(1)
-		go e.worker(i)
-	}
+	e.setWorkers(e.workers)
+	e.startAutoscaler()
+
+	e.armSchedules()
+	e.resumeDrains()
+	return nil
 }
 
 // Stop gracefully stops the engine, waiting for all workers to finish.
-// Any signals in the inbox will be processed before stopping.
+// Agents implementing Flusher are flushed first, while outputs can still
+// be routed. Any signals in the inbox will be processed before stopping,
+// and so are the outputs they lead to: Flusher agents are flushed again
+// once the inbox is drained, until no outputs are left or ProcessTimeout
+// elapses. Schedules are kept and resume on the next Start.
+// Afterwards, agents implementing Closer are closed; flush and close errors
+// are joined and returned.
 // Calling Stop on a stopped engine is a no-op.
-func (e *Engine) Stop() {
-	e.mu.Lock()
-	if !e.running {
-		e.mu.Unlock()
-		return
+func (e *Engine) Stop() error {
+	e.lifecycle.Lock()
+	defer e.lifecycle.Unlock()
+
+	if !e.IsRunning() {
+		return nil
 	}
+	e.stopDrains()
+	flushErr := e.flushAgents()
+
+	e.mu.Lock()
+	e.disarmSchedules()
+	e.stopAutoscaler()
 	e.running = false
+	e.stopping = true
 	e.mu.Unlock()
 
 	close(e.done)
 	e.wg.Wait()
+	flushErr = errors.Join(flushErr, e.settle())
+
+	e.mu.Lock()
+	e.workerQuit = nil
+	e.mu.Unlock()
+
+	return errors.Join(flushErr, e.closeAgents())
+}
+
+// settle processes the outputs queued while Stop drained the inbox,
+// flushing Flusher agents before each round so partial batches are routed
+// too. Outputs still queued after ProcessTimeout are dropped and reported.
+func (e *Engine) settle() error {
+	deadline := time.Now().Add(e.config.ProcessTimeout)
+	var errs []error
+	for {
+		errs = append(errs, e.flushAgents())
+
+		e.mu.Lock()
+		queued := e.stopQueue
+		e.stopQueue = nil
+		last := len(queued) == 0 || time.Now().After(deadline)
+		if last {
+			e.stopping = false
+		}
+		e.mu.Unlock()
+
+		if last {
+			for _, q := range queued {
+				e.inflight.done(TraceID(q.signal))
+				e.reportError(q.signal, q.signal.Source, fmt.Errorf("failed to submit output signal: %w", ErrNotRunning))
+			}
+			return errors.Join(errs...)
+		}
+		for _, q := range queued {
+			e.dispatch(q)
+		}
+	}
 }
 
 // IsRunning returns whether the engine is currently running.
@@ -169,7 +384,8 @@ func (e *Engine) IsRunning() bool {
 // =============================================================================
 
 // Submit sends a signal into the engine for processing.
-// Returns an error if the engine is not running.
+// Returns ErrNotRunning if the engine is not running, or a *ValidationError if
+// the payload violates its schema (see EngineConfig.Schemas).
 // This method blocks if the inbox buffer is full.
 func (e *Engine) Submit(signal *Signal) error {
 	e.mu.Lock()
@@ -177,19 +393,24 @@ func (e *Engine) Submit(signal *Signal) error {
 	e.mu.Unlock()
 
 	if !running {
-		return fmt.Errorf("engine not running")
+		return ErrNotRunning
+	}
+	if err := e.validate(signal); err != nil {
+		return err
 	}
 
 	select {
-	case e.inbox <- signal:
+	case e.inbox <- e.enqueue(signal):
 		return nil
 	case <-e.done:
-		return fmt.Errorf("engine stopped")
+		e.inflight.done(TraceID(signal))
+		return ErrNotRunning
 	}
 }
 
 // TrySubmit attempts to submit a signal without blocking.
-// Returns false if the inbox is full or the engine is stopped.
+// Returns false if the inbox is full, the engine is stopped, or the payload
+// violates its schema (reported to the error hook).
 func (e *Engine) TrySubmit(signal *Signal) bool {
 	e.mu.Lock()
 	running := e.running
@@ -198,11 +419,16 @@ func (e *Engine) TrySubmit(signal *Signal) bool {
 	if !running {
 		return false
 	}
+	if err := e.validate(signal); err != nil {
+		e.reportError(signal, "", err)
+		return false
+	}
 
 	select {
-	case e.inbox <- signal:
+	case e.inbox <- e.enqueue(signal):
 		return true
 	default:
+		e.inflight.done(TraceID(signal))
 		return false
 	}
 }
@@ -215,34 +441,98 @@ func (e *Engine) SubmitWithTimeout(signal *Signal, timeout time.Duration) error
 	e.mu.Unlock()
 
 	if !running {
-		return fmt.Errorf("engine not running")
+		return ErrNotRunning
+	}
+	if err := e.validate(signal); err != nil {
+		return err
 	}
 
 	timer := time.NewTimer(timeout)
 	defer timer.Stop()
 
 	select {
-	case e.inbox <- signal:
+	case e.inbox <- e.enqueue(signal):
 		return nil
 	case <-timer.C:
+		e.inflight.done(TraceID(signal))
 		return fmt.Errorf("submission timeout after %v", timeout)
 	case <-e.done:
-		return fmt.Errorf("engine stopped")
+		e.inflight.done(TraceID(signal))
+		return ErrNotRunning
+	}
+}
+
+// SubmitContext submits a signal, giving up when ctx is done.
+// Returns ctx's error if submission doesn't complete before then.
+func (e *Engine) SubmitContext(ctx context.Context, signal *Signal) error {
+	e.mu.Lock()
+	running := e.running
+	e.mu.Unlock()
+
+	if !running {
+		return ErrNotRunning
+	}
+	if err := e.validate(signal); err != nil {
+		return err
+	}
+
+	select {
+	case e.inbox <- e.enqueue(signal):
+		return nil
+	case <-ctx.Done():
+		e.inflight.done(TraceID(signal))
+		return ctx.Err()
+	case <-e.done:
+		e.inflight.done(TraceID(signal))
+		return ErrNotRunning
+	}
+}
+
+// emit submits an agent's output signal. While Stop drains the engine the
+// output is still accepted, and queued for Stop to process (see settle).
+func (e *Engine) emit(signal *Signal) error {
+	e.mu.Lock()
+	if !e.stopping {
+		e.mu.Unlock()
+		return e.Submit(signal)
+	}
+	defer e.mu.Unlock()
+	if err := e.validate(signal); err != nil {
+		return err
 	}
+	e.stopQueue = append(e.stopQueue, e.enqueue(signal))
+	return nil
 }
 
 // =============================================================================
 // WORKER IMPLEMENTATION
 // =============================================================================
 
+// queuedSignal is a signal waiting in the inbox.
+type queuedSignal struct {
+	signal   *Signal
+	enqueued time.Time
+}
+
+// enqueue wraps a signal for the inbox, recording when it was queued, and
+// counts it in flight (see idle.go). A caller whose send then fails must
+// release the count.
+func (e *Engine) enqueue(signal *Signal) queuedSignal {
+	e.inflight.add(TraceID(signal))
+	return queuedSignal{signal: signal, enqueued: e.config.Clock.Now()}
+}
+
 // worker is the main processing loop for each worker goroutine.
-func (e *Engine) worker(id int) {
+// Closing quit retires the worker (see autoscale.go).
+func (e *Engine) worker(quit <-chan struct{}) {
 	defer e.wg.Done()
 
 	for {
 		select {
-		case signal := <-e.inbox:
-			e.processSignal(signal)
+		case queued := <-e.inbox:
+			e.dispatch(queued)
+		case <-quit:
+			return
 		case <-e.done:
 			// Drain remaining signals in inbox before exiting
 			e.drainInbox()
@@ -255,63 +545,129 @@ func (e *Engine) worker(id int) {
 func (e *Engine) drainInbox() {
 	for {
 		select {
-		case signal := <-e.inbox:
-			e.processSignal(signal)
+		case queued := <-e.inbox:
+			e.dispatch(queued)
 		default:
 			return
 		}
 	}
 }
 
+// dispatch processes a dequeued signal, accounting for queue wait and
+// busy workers.
+func (e *Engine) dispatch(queued queuedSignal) {
+	e.observeWait(e.config.Clock.Now().Sub(queued.enqueued))
+	e.busy.Add(1)
+	defer e.busy.Add(-1)
+	defer e.inflight.done(TraceID(queued.signal))
+	e.processSignal(queued.signal)
+}
+
 // processSignal handles routing and processing of a single signal.
 func (e *Engine) processSignal(signal *Signal) {
 	// Call receive hook
 	if e.onSignalReceived != nil {
 		e.onSignalReceived(signal)
 	}
+	e.taps.notify(signal)
 
 	// Route the signal to destination(s)
 	destinations := e.router.Route(signal)
 	if len(destinations) == 0 {
-		if e.onError != nil {
-			e.onError(signal, fmt.Errorf("no destination for signal type '%s' (id=%s)",
-				signal.Type, truncateID(signal.ID)))
-		}
+		e.reportError(signal, "", fmt.Errorf("%w for signal type '%s' (id=%s)",
+			ErrNoDestination, signal.Type, truncateID(signal.ID)))
 		return
 	}
 
-	// Process in each destination agent (supports fanout)
-	for _, destID := range destinations {
-		e.processInAgent(signal, destID)
+	// Deliver only to the destinations the policy allows the source
+	if destinations = e.permitRoute(signal, destinations); len(destinations) == 0 {
+		return
+	}
+
+	// Drop or answer signals whose idempotency key was already seen
+	if e.filterDuplicate(signal, destinations) {
+		return
 	}
+
+	// Hold signals for paused agents until they are resumed
+	destinations = e.holdPaused(signal, destinations, nil)
+
+	// Pin the routed agent instances so a hot swap drains these calls
+	slots := e.acquireSlots(destinations)
+
+	// Process in each destination agent, in parallel if configured
+	e.fanout(signal, destinations, slots)
 }
 
 // processInAgent sends a signal to a specific agent for processing.
-func (e *Engine) processInAgent(signal *Signal, destID string) {
-	agent, exists := e.router.GetAgent(destID)
-	if !exists {
-		if e.onError != nil {
-			e.onError(signal, fmt.Errorf("agent '%s' not found", destID))
+// The slot pins the agent instance the signal was routed to; it is released
+// once processing completes. A synchronous result is handled in its turn
+// (see fanout.go); a deferred one whenever it completes. A delivered signal
+// (see Deliver) passes its result to the delivery instead of routing it.
+func (e *Engine) processInAgent(signal *Signal, destID string, slot *agentSlot, t turn, d *delivery) {
+	trace := TraceID(signal)
+	e.inflight.add(trace)
+	if slot == nil {
+		err := fmt.Errorf("agent '%s' not found", destID)
+		t.wait()
+		defer t.pass()
+		defer e.inflight.done(trace)
+		e.reportError(signal, destID, err)
+		if d != nil {
+			d.reply(Err(err))
+			return
 		}
+		e.recordDedupResult(signal, destID, Err(err))
 		return
 	}
-
-	// Create processing context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), e.config.ProcessTimeout)
-	defer cancel()
+	agent := slot.agent
 
 	// Update signal destination for this processing
 	processingSignal := signal.WithDestination(destID)
 
-	// Execute agent processing
-	result := agent.Process(ctx, processingSignal)
+	// Create processing context with timeout. Agents may Defer their result,
+	// in which case the completion finishes processing and releases the slot.
+	ctx, cancel := e.processContext(processingSignal, destID)
+	if d != nil {
+		stop := context.AfterFunc(d.ctx, cancel)
+		defer stop()
+	}
+	start := e.config.Clock.Now()
+	ctx, deferred := withDeferral(ctx, func(result AgentResult) {
+		defer e.inflight.done(trace) // After outputs are submitted
+		defer slot.release()
+		e.calls.notify(AgentCall{Agent: destID, Signal: processingSignal, Result: result, Duration: e.config.Clock.Now().Sub(start)})
+		if d != nil {
+			e.finish(processingSignal, destID, result, d)
+			return
+		}
+		e.handleResult(processingSignal, destID, result)
+	})
+
+	// Execute agent processing. A call that panics fails even if it deferred
+	// its result, since the completion may never come.
+	result, panicked := safeProcess(ctx, agent, processingSignal)
+	cancel()
+
+	if deferred.seal() && !panicked {
+		t.pass()
+		return
+	}
+	t.wait()
+	deferred.complete(result)
+	t.pass()
+}
+
+// handleResult runs hooks for a processed signal and submits its outputs.
+func (e *Engine) handleResult(processingSignal *Signal, destID string, result AgentResult) {
+	e.recordDedupResult(processingSignal, destID, result)
 
 	// Call processed hook
 	if e.onSignalProcessed != nil {
 		e.onSignalProcessed(processingSignal, result)
 	}
 
-	// Handle processing error
+	// Handle processing error (logged with the agent call, see log.go)
 	if result.Error != nil {
 		if e.onError != nil {
 			e.onError(processingSignal, result.Error)
@@ -321,12 +677,15 @@ func (e *Engine) processInAgent(signal *Signal, destID string) {
 
 	// Submit output signals back to the engine
 	for _, outSignal := range result.Signals {
-		// Set source to the agent that produced this signal
+		// Set source to the agent that produced this signal, and keep
+		// it in the trace of the signal it was emitted for
 		outSignal = outSignal.WithSource(destID)
-		if err := e.Submit(outSignal); err != nil {
-			if e.onError != nil {
-				e.onError(outSignal, fmt.Errorf("failed to submit output signal: %w", err))
-			}
+		stampTrace(outSignal, processingSignal)
+		if !e.permitEmit(outSignal, destID) {
+			continue
+		}
+		if err := e.emit(outSignal); err != nil {
+			e.reportError(outSignal, destID, fmt.Errorf("failed to submit output signal: %w", err))
 		}
 	}
 }
@@ -338,23 +697,70 @@ func (e *Engine) processInAgent(signal *Signal, destID string) {
 // EngineStats contains runtime statistics about the engine.
 type EngineStats struct {
 	Running     bool          // Whether the engine is running
-	WorkerCount int           // Number of worker goroutines
+	WorkerCount int           // Current worker pool size
+	BusyWorkers int           // Workers processing a signal
 	BufferSize  int           // Configured inbox buffer size
 	BufferUsed  int           // Current number of signals in inbox
 	Timeout     time.Duration // Processing timeout per agent
+	Schedules   int           // Pending delayed and recurring submissions
+	DedupKeys   int           // Idempotency keys remembered by the dedup filter
+	Duplicates  uint64        // Duplicate signals dropped or replayed
+	InFlight    int           // Units of work in flight (see WaitIdle)
+	Traces      int           // Traces with work in flight
+	Violations  uint64        // Policy violations, enforced or audited
+
+	// Pause state (see pause.go)
+	Paused       bool           // Whether the whole engine is paused
+	PausedAgents []string       // Individually paused agents, sorted
+	Held         map[string]int // Signals held per agent
+	HeldOverflow uint64         // Signals dropped because a holding area was full
 }
 
 // Stats returns current engine statistics.
 func (e *Engine) Stats() EngineStats {
+	e.scheduler.mu.Lock()
+	schedules := len(e.scheduler.entries)
+	e.scheduler.mu.Unlock()
+
+	var dedupKeys int
+	var duplicates uint64
+	if e.dedup != nil {
+		dedupKeys = e.dedup.size()
+		duplicates = e.dedup.duplicates()
+	}
+
+	inFlight, traces := e.inflight.counts()
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	return EngineStats{
+	stats := EngineStats{
 		Running:     e.running,
-		WorkerCount: e.config.WorkerCount,
+		WorkerCount: e.workers,
+		BusyWorkers: int(e.busy.Load()),
 		BufferSize:  e.config.BufferSize,
 		BufferUsed:  len(e.inbox),
 		Timeout:     e.config.ProcessTimeout,
+		Schedules:   schedules,
+		DedupKeys:   dedupKeys,
+		Duplicates:  duplicates,
+		InFlight:    inFlight,
+		Traces:      traces,
+		Violations:  e.policyViolations.Load(),
 	}
+	e.holdStats(&stats)
+	return stats
+}
+
+// Factory returns the engine's signal factory, which stamps signals with
+// the configured Clock and IDs.
+func (e *Engine) Factory() *SignalFactory {
+	return e.factory
+}
+
+// Clock returns the engine's clock, for components built on the engine
+// that keep time consistently with it.
+func (e *Engine) Clock() Clock {
+	return e.config.Clock
 }
 
 // Router returns the engine's router for agent management.
@@ -0,0 +1,78 @@
+package signal
+
+import "sync"
+
+// =============================================================================
+// FANOUT: Parallel Processing of Routed Destinations
+// =============================================================================
+
+// FanoutConfig runs the destinations of one signal in parallel, so a
+// coordinator fanout to three slow workers takes the longest of their
+// latencies rather than the sum. The worker that dequeued the signal waits
+// for every Process call to return before taking the next signal.
+type FanoutConfig struct {
+	// MaxConcurrency bounds the Process calls of one signal running at
+	// once. 0 runs every destination at once.
+	MaxConcurrency int
+
+	// Ordered handles results (hooks, errors and output submission) in
+	// route order: a destination's result waits for those routed before
+	// it. Otherwise each result is handled as soon as its agent returns.
+	// Deferred results (see Defer) are handled when they complete either
+	// way, and do not hold up later destinations.
+	Ordered bool
+}
+
+// turn orders the handling of fanned-out results: a call waits for the
+// previous turn, handles its result, then passes its own. The zero turn
+// does not order anything.
+type turn struct {
+	prev <-chan struct{}
+	next chan struct{}
+}
+
+func (t turn) wait() {
+	if t.prev != nil {
+		<-t.prev
+	}
+}
+
+func (t turn) pass() {
+	if t.next != nil {
+		close(t.next)
+	}
+}
+
+// fanout processes a signal in each of its destinations.
+func (e *Engine) fanout(signal *Signal, destinations []string, slots []*agentSlot) {
+	config := e.config.Fanout
+	if config == nil || len(destinations) < 2 {
+		for i, destID := range destinations {
+			e.processInAgent(signal, destID, slots[i], turn{}, nil)
+		}
+		return
+	}
+
+	limit := config.MaxConcurrency
+	if limit <= 0 || limit > len(destinations) {
+		limit = len(destinations)
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	var prev chan struct{}
+	for i, destID := range destinations {
+		var t turn
+		if config.Ordered {
+			t = turn{prev: prev, next: make(chan struct{})}
+			prev = t.next
+		}
+		sem <- struct{}{} // Calls start in route order, so earlier turns never wait on later ones
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			e.processInAgent(signal, destID, slots[i], t, nil)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,243 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fanoutHarness routes "job" signals to workers that each block until the
+// test releases them, and records concurrency and the order of handled
+// results.
+type fanoutHarness struct {
+	engine  *Engine
+	running atomic.Int32
+	peak    atomic.Int32
+	gates   map[string]chan struct{} // Closed to let a worker return
+	started chan string              // Workers that entered Process
+	done    chan string              // Workers that returned
+
+	mu        sync.Mutex
+	processed []string
+	errs      []string
+	outputs   chan *Signal
+}
+
+func newFanoutHarness(t *testing.T, fanout *FanoutConfig, order []string) *fanoutHarness {
+	t.Helper()
+	h := &fanoutHarness{
+		gates:   make(map[string]chan struct{}, len(order)),
+		started: make(chan string, len(order)),
+		done:    make(chan string, len(order)),
+		outputs: make(chan *Signal, 16),
+	}
+	agents := []Agent{NewAgentFunc("sink", func(ctx context.Context, sig *Signal) AgentResult {
+		h.outputs <- sig
+		return OK()
+	})}
+	for _, id := range order {
+		gate := make(chan struct{})
+		h.gates[id] = gate
+		agents = append(agents, NewAgentFunc(id, func(ctx context.Context, sig *Signal) AgentResult {
+			n := h.running.Add(1)
+			for {
+				peak := h.peak.Load()
+				if n <= peak || h.peak.CompareAndSwap(peak, n) {
+					break
+				}
+			}
+			h.started <- id
+			<-gate
+			h.running.Add(-1)
+			defer func() { h.done <- id }()
+			if id == "failing" {
+				return Err(errors.New("worker failed"))
+			}
+			return OK(sig.Derive("done", id))
+		}))
+	}
+	route := func(sig *Signal) []string {
+		switch sig.Type {
+		case "job":
+			return order
+		case "done":
+			return []string{"sink"}
+		}
+		return nil
+	}
+
+	config := DefaultConfig()
+	config.WorkerCount = 1
+	config.Fanout = fanout
+	h.engine = newTestEngine(t, config, route, agents...)
+	h.engine.OnSignalProcessed(func(sig *Signal, result AgentResult) {
+		if sig.Type == "job" {
+			h.mu.Lock()
+			h.processed = append(h.processed, sig.Destination)
+			h.mu.Unlock()
+		}
+	})
+	h.engine.OnError(func(sig *Signal, err error) {
+		h.mu.Lock()
+		h.errs = append(h.errs, sig.Destination+": "+err.Error())
+		h.mu.Unlock()
+	})
+	// Registered after the engine's Stop, so it runs first and unblocks
+	// workers a failed test left waiting
+	t.Cleanup(func() {
+		for _, gate := range h.gates {
+			select {
+			case <-gate:
+			default:
+				close(gate)
+			}
+		}
+	})
+	startEngine(t, h.engine)
+	h.engine.Submit(NewSignal("job", nil))
+	return h
+}
+
+// await receives the next worker ID from ch.
+func (h *fanoutHarness) await(t *testing.T, ch chan string) string {
+	t.Helper()
+	select {
+	case id := <-ch:
+		return id
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for a worker")
+		return ""
+	}
+}
+
+// release lets a worker return and waits until it has.
+func (h *fanoutHarness) release(t *testing.T, id string) {
+	t.Helper()
+	close(h.gates[id])
+	if got := h.await(t, h.done); got != id {
+		t.Fatalf("Worker %q returned, want %q", got, id)
+	}
+}
+
+// wait waits for n outputs.
+func (h *fanoutHarness) wait(t *testing.T, n int) {
+	t.Helper()
+	for range n {
+		select {
+		case <-h.outputs:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for fanout outputs")
+		}
+	}
+}
+
+func (h *fanoutHarness) order() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.processed...)
+}
+
+func TestFanoutRunsDestinationsInParallel(t *testing.T) {
+	h := newFanoutHarness(t, &FanoutConfig{}, []string{"a", "b", "c"})
+
+	// Every call starts while the others are still blocked
+	for range 3 {
+		h.await(t, h.started)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		h.release(t, id)
+	}
+	h.wait(t, 3)
+	if peak := h.peak.Load(); peak != 3 {
+		t.Errorf("Expected 3 concurrent calls, got %d", peak)
+	}
+}
+
+func TestFanoutSequentialByDefault(t *testing.T) {
+	h := newFanoutHarness(t, nil, []string{"a", "b"})
+
+	for _, want := range []string{"a", "b"} {
+		if got := h.await(t, h.started); got != want {
+			t.Fatalf("Started %q, want %q", got, want)
+		}
+		h.release(t, want)
+	}
+	h.wait(t, 2)
+	if peak := h.peak.Load(); peak != 1 {
+		t.Errorf("Without Fanout calls should not overlap, got %d", peak)
+	}
+}
+
+func TestFanoutMaxConcurrency(t *testing.T) {
+	h := newFanoutHarness(t, &FanoutConfig{MaxConcurrency: 2}, []string{"a", "b", "c", "d"})
+
+	// Each release makes room for exactly one more call
+	running := []string{h.await(t, h.started), h.await(t, h.started)}
+	for started := 2; len(running) > 0; {
+		h.release(t, running[0])
+		running = running[1:]
+		if started < 4 {
+			running = append(running, h.await(t, h.started))
+			started++
+		}
+	}
+	h.wait(t, 4)
+	if peak := h.peak.Load(); peak != 2 {
+		t.Errorf("Expected at most 2 concurrent calls, got %d", peak)
+	}
+}
+
+func TestFanoutCompletionOrder(t *testing.T) {
+	route := []string{"slow", "medium", "fast"}
+	completion := []string{"fast", "medium", "slow"}
+
+	ordered := newFanoutHarness(t, &FanoutConfig{Ordered: true}, route)
+	for range route {
+		ordered.await(t, ordered.started)
+	}
+	for _, id := range completion {
+		ordered.release(t, id)
+	}
+	ordered.wait(t, 3)
+	if got := ordered.order(); !reflect.DeepEqual(got, route) {
+		t.Errorf("Ordered results should follow the route, got %v", got)
+	}
+
+	unordered := newFanoutHarness(t, &FanoutConfig{}, route)
+	for range route {
+		unordered.await(t, unordered.started)
+	}
+	for i, id := range completion {
+		unordered.release(t, id)
+		waitFor(t, func() bool { return len(unordered.order()) == i+1 })
+	}
+	unordered.wait(t, 3)
+	if got := unordered.order(); !reflect.DeepEqual(got, completion) {
+		t.Errorf("Unordered results should follow completion, got %v", got)
+	}
+}
+
+func TestFanoutPerDestinationErrors(t *testing.T) {
+	h := newFanoutHarness(t, &FanoutConfig{Ordered: true}, []string{"a", "failing", "b"})
+	for range 3 {
+		h.await(t, h.started)
+	}
+	for _, id := range []string{"failing", "b", "a"} {
+		h.release(t, id)
+	}
+
+	h.wait(t, 2) // Outputs of a and b are still submitted
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !reflect.DeepEqual(h.errs, []string{"failing: worker failed"}) {
+		t.Errorf("Expected one error for the failing destination, got %v", h.errs)
+	}
+	if !reflect.DeepEqual(h.processed, []string{"a", "failing", "b"}) {
+		t.Errorf("Every destination should be reported, got %v", h.processed)
+	}
+}
@@ -0,0 +1,355 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// =============================================================================
+// FSM: Per-Key State Machines
+// =============================================================================
+
+// SignalStateTransition is the type of the event signal an FSMAgent emits
+// after each transition when FSMConfig.EmitEvents is set.
+const SignalStateTransition SignalType = "state_transition"
+
+// Metadata keys understood by FSMAgent.
+const (
+	// MetaFSMKey is the default correlation key selecting the state machine
+	// instance (e.g. a session ID).
+	MetaFSMKey = "fsm_key"
+	// MetaFSMState is set to the new state on signals emitted by a transition.
+	MetaFSMState = "fsm_state"
+)
+
+// ErrInvalidTransition is returned (wrapped) when a signal is not accepted
+// in the current state, either because no transition is declared for it or
+// because every matching guard rejected it.
+var ErrInvalidTransition = errors.New("invalid transition")
+
+// State is a named state of an FSMAgent.
+type State string
+
+// Transition moves a key from one of the From states to To when a signal of
+// type On arrives and Guard (if set) accepts it. When several transitions
+// match, they are tried in declaration order and the first accepted wins.
+type Transition struct {
+	From []State    // States the transition applies in; empty means any state
+	On   SignalType // Triggering signal type
+	To   State      // Target state
+
+	// Guard vetoes the transition by returning false.
+	Guard func(ctx context.Context, signal *Signal) bool
+
+	// Action runs before the state changes, and its output signals are
+	// emitted. An error leaves the state unchanged.
+	Action func(ctx context.Context, signal *Signal, event TransitionEvent) AgentResult
+}
+
+// TransitionEvent describes a state change of one key.
+// It is the payload of SignalStateTransition signals.
+type TransitionEvent struct {
+	Key      string
+	From     State
+	To       State
+	Trigger  SignalType // Type of the triggering signal
+	SignalID string     // ID of the triggering signal
+}
+
+// StateStore persists the current state of each key.
+// Implementations must be safe for concurrent use.
+type StateStore interface {
+	// Get returns the state of a key; ok is false if the key has none.
+	Get(ctx context.Context, key string) (state State, ok bool, err error)
+	// Set stores the state of a key.
+	Set(ctx context.Context, key string, state State) error
+	// Delete forgets a key.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryStateStore is the default in-memory StateStore.
+type MemoryStateStore struct {
+	mu     sync.RWMutex
+	states map[string]State
+}
+
+// NewMemoryStateStore creates an empty in-memory state store.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{states: make(map[string]State)}
+}
+
+// Get returns the state of a key.
+func (s *MemoryStateStore) Get(ctx context.Context, key string) (State, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.states[key]
+	return state, ok, nil
+}
+
+// Set stores the state of a key.
+func (s *MemoryStateStore) Set(ctx context.Context, key string, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = state
+	return nil
+}
+
+// Delete forgets a key.
+func (s *MemoryStateStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+// Len returns the number of keys with a stored state.
+func (s *MemoryStateStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.states)
+}
+
+// FSMConfig configures an FSMAgent.
+type FSMConfig struct {
+	// Initial is the state of keys seen for the first time. Required.
+	Initial State
+
+	// States optionally declares every valid state; when set, transitions
+	// referring to undeclared states are rejected by NewFSMAgent.
+	States []State
+
+	// Final states end a key's machine: its state is deleted from the store,
+	// so the next signal for the key starts again from Initial.
+	Final []State
+
+	// Transitions lists the allowed state changes.
+	Transitions []Transition
+
+	// Key extracts the state machine key from a signal.
+	// Defaults to the MetaFSMKey metadata value.
+	Key func(signal *Signal) string
+
+	// Store persists states. Defaults to a new MemoryStateStore.
+	Store StateStore
+
+	// EmitEvents emits a SignalStateTransition signal after each transition.
+	EmitEvents bool
+
+	// OnTransition, if set, is called after each transition.
+	OnTransition func(event TransitionEvent)
+}
+
+// FSMAgent is an agent that runs one state machine per key. Each signal is
+// looked up against the transitions of its key's current state; accepted
+// signals run the transition's action and move the key to the target state,
+// others fail with an error wrapping ErrInvalidTransition.
+// Signals for the same key are processed one at a time.
+type FSMAgent struct {
+	id     string
+	config FSMConfig
+	final  map[State]bool
+
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock serializes transitions of one key.
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewFSMAgent creates a state machine agent, validating its configuration.
+func NewFSMAgent(id string, config FSMConfig) (*FSMAgent, error) {
+	if config.Initial == "" {
+		return nil, fmt.Errorf("fsm '%s': no initial state", id)
+	}
+	if config.Key == nil {
+		config.Key = JoinByMetadata(MetaFSMKey)
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStateStore()
+	}
+
+	declared := make(map[State]bool, len(config.States))
+	for _, s := range config.States {
+		declared[s] = true
+	}
+	checkState := func(s State) error {
+		if len(declared) > 0 && !declared[s] {
+			return fmt.Errorf("fsm '%s': undeclared state '%s'", id, s)
+		}
+		return nil
+	}
+	if err := checkState(config.Initial); err != nil {
+		return nil, err
+	}
+	final := make(map[State]bool, len(config.Final))
+	for _, s := range config.Final {
+		if err := checkState(s); err != nil {
+			return nil, err
+		}
+		final[s] = true
+	}
+	for i, t := range config.Transitions {
+		if t.On == "" || t.To == "" {
+			return nil, fmt.Errorf("fsm '%s': transition %d needs a signal type and a target state", id, i)
+		}
+		for _, s := range append([]State{t.To}, t.From...) {
+			if err := checkState(s); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &FSMAgent{
+		id:     id,
+		config: config,
+		final:  final,
+		locks:  make(map[string]*keyLock),
+	}, nil
+}
+
+// ID returns the agent's unique identifier.
+func (f *FSMAgent) ID() string {
+	return f.id
+}
+
+// State returns the current state of a key (Initial for unknown keys).
+func (f *FSMAgent) State(ctx context.Context, key string) (State, error) {
+	state, ok, err := f.config.Store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return f.config.Initial, nil
+	}
+	return state, nil
+}
+
+// Reset returns a key to the initial state.
+func (f *FSMAgent) Reset(ctx context.Context, key string) error {
+	unlock := f.lock(key)
+	defer unlock()
+	return f.config.Store.Delete(ctx, key)
+}
+
+// Process applies the first accepted transition for the signal's type in the
+// key's current state.
+func (f *FSMAgent) Process(ctx context.Context, signal *Signal) AgentResult {
+	key := f.config.Key(signal)
+	if key == "" {
+		return Err(fmt.Errorf("fsm '%s': no key in signal %s", f.id, truncateID(signal.ID)))
+	}
+
+	unlock := f.lock(key)
+	defer unlock()
+
+	current, err := f.State(ctx, key)
+	if err != nil {
+		return Err(fmt.Errorf("fsm '%s': load state of '%s': %w", f.id, key, err))
+	}
+
+	t, err := f.match(ctx, key, current, signal)
+	if err != nil {
+		return Err(err)
+	}
+
+	event := TransitionEvent{
+		Key:      key,
+		From:     current,
+		To:       t.To,
+		Trigger:  signal.Type,
+		SignalID: signal.ID,
+	}
+
+	var outputs []*Signal
+	if t.Action != nil {
+		result := t.Action(ctx, signal, event)
+		if result.Error != nil {
+			return result
+		}
+		outputs = result.Signals
+	}
+
+	if f.final[t.To] {
+		err = f.config.Store.Delete(ctx, key)
+	} else {
+		err = f.config.Store.Set(ctx, key, t.To)
+	}
+	if err != nil {
+		return Err(fmt.Errorf("fsm '%s': store state of '%s': %w", f.id, key, err))
+	}
+
+	for i, out := range outputs {
+		outputs[i] = out.WithMetadata(MetaFSMState, string(t.To))
+	}
+	if f.config.EmitEvents {
+		outputs = append(outputs, FactoryFrom(ctx).Derive(signal, SignalStateTransition, &event).
+			WithMetadata(MetaFSMKey, key).
+			WithMetadata(MetaFSMState, string(t.To)))
+	}
+	if f.config.OnTransition != nil {
+		f.config.OnTransition(event)
+	}
+	return OK(outputs...)
+}
+
+// match returns the first transition accepting the signal in state current.
+func (f *FSMAgent) match(ctx context.Context, key string, current State, signal *Signal) (*Transition, error) {
+	declared := false
+	for i := range f.config.Transitions {
+		t := &f.config.Transitions[i]
+		if t.On != signal.Type || !t.appliesIn(current) {
+			continue
+		}
+		declared = true
+		if t.Guard == nil || t.Guard(ctx, signal) {
+			return t, nil
+		}
+	}
+	if declared {
+		return nil, fmt.Errorf("fsm '%s': guard rejected signal '%s' in state '%s' (key '%s'): %w",
+			f.id, signal.Type, current, key, ErrInvalidTransition)
+	}
+	return nil, fmt.Errorf("fsm '%s': signal '%s' not allowed in state '%s' (key '%s'): %w",
+		f.id, signal.Type, current, key, ErrInvalidTransition)
+}
+
+// appliesIn reports whether the transition can fire in the given state.
+func (t *Transition) appliesIn(state State) bool {
+	if len(t.From) == 0 {
+		return true
+	}
+	for _, s := range t.From {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// lock acquires the lock of a key and returns its release function.
+func (f *FSMAgent) lock(key string) func() {
+	f.mu.Lock()
+	l, ok := f.locks[key]
+	if !ok {
+		l = &keyLock{}
+		f.locks[key] = l
+	}
+	l.refs++
+	f.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		f.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(f.locks, key)
+		}
+		f.mu.Unlock()
+	}
+}
@@ -0,0 +1,231 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+const (
+	stateClarifying State = "awaiting_clarification"
+	stateDrafting   State = "drafting"
+	stateReviewing  State = "reviewing"
+	stateDone       State = "done"
+)
+
+func newReviewFSM(t *testing.T, config FSMConfig) *FSMAgent {
+	t.Helper()
+	config.Initial = stateClarifying
+	config.States = []State{stateClarifying, stateDrafting, stateReviewing, stateDone}
+	if config.Transitions == nil {
+		config.Transitions = []Transition{
+			{From: []State{stateClarifying}, On: "answer", To: stateDrafting},
+			{From: []State{stateDrafting}, On: "draft", To: stateReviewing},
+			{From: []State{stateReviewing}, On: "verdict", To: stateDone,
+				Guard: func(ctx context.Context, sig *Signal) bool { return sig.Payload == "approve" }},
+			{From: []State{stateReviewing}, On: "verdict", To: stateDrafting},
+		}
+	}
+	fsm, err := NewFSMAgent("session", config)
+	if err != nil {
+		t.Fatalf("NewFSMAgent() error = %v", err)
+	}
+	return fsm
+}
+
+func sessionSignal(typ SignalType, session string, payload any) *Signal {
+	return NewSignal(typ, payload).WithMetadata(MetaFSMKey, session)
+}
+
+func TestFSMAgentTransitionsPerKey(t *testing.T) {
+	fsm := newReviewFSM(t, FSMConfig{})
+	ctx := context.Background()
+
+	for _, sig := range []*Signal{
+		sessionSignal("answer", "s1", nil),
+		sessionSignal("draft", "s1", nil),
+		sessionSignal("answer", "s2", nil),
+	} {
+		if result := fsm.Process(ctx, sig); result.Error != nil {
+			t.Fatalf("Process(%s) error = %v", sig.Type, result.Error)
+		}
+	}
+
+	if state, _ := fsm.State(ctx, "s1"); state != stateReviewing {
+		t.Errorf("State(s1) = %s, want %s", state, stateReviewing)
+	}
+	if state, _ := fsm.State(ctx, "s2"); state != stateDrafting {
+		t.Errorf("State(s2) = %s, want %s", state, stateDrafting)
+	}
+	if state, _ := fsm.State(ctx, "s3"); state != stateClarifying {
+		t.Errorf("State(s3) = %s, want initial state", state)
+	}
+}
+
+func TestFSMAgentRejectsInvalidSignal(t *testing.T) {
+	fsm := newReviewFSM(t, FSMConfig{})
+	ctx := context.Background()
+
+	result := fsm.Process(ctx, sessionSignal("draft", "s1", nil))
+	if !errors.Is(result.Error, ErrInvalidTransition) {
+		t.Fatalf("Process() error = %v, want ErrInvalidTransition", result.Error)
+	}
+	if state, _ := fsm.State(ctx, "s1"); state != stateClarifying {
+		t.Errorf("State = %s, want unchanged", state)
+	}
+
+	if result := fsm.Process(ctx, NewSignal("answer", nil)); result.Error == nil {
+		t.Error("Process() without key should fail")
+	}
+}
+
+func TestFSMAgentGuards(t *testing.T) {
+	fsm := newReviewFSM(t, FSMConfig{})
+	ctx := context.Background()
+	fsm.Process(ctx, sessionSignal("answer", "s1", nil))
+	fsm.Process(ctx, sessionSignal("draft", "s1", nil))
+
+	// Rejected verdict falls through to the next transition
+	fsm.Process(ctx, sessionSignal("verdict", "s1", "revise"))
+	if state, _ := fsm.State(ctx, "s1"); state != stateDrafting {
+		t.Fatalf("State = %s, want %s", state, stateDrafting)
+	}
+
+	fsm.Process(ctx, sessionSignal("draft", "s1", nil))
+	fsm.Process(ctx, sessionSignal("verdict", "s1", "approve"))
+	if state, _ := fsm.State(ctx, "s1"); state != stateDone {
+		t.Errorf("State = %s, want %s", state, stateDone)
+	}
+}
+
+func TestFSMAgentGuardRejection(t *testing.T) {
+	fsm := newReviewFSM(t, FSMConfig{Transitions: []Transition{
+		{On: "answer", To: stateDrafting,
+			Guard: func(ctx context.Context, sig *Signal) bool { return sig.Payload != nil }},
+	}})
+
+	result := fsm.Process(context.Background(), sessionSignal("answer", "s1", nil))
+	if !errors.Is(result.Error, ErrInvalidTransition) {
+		t.Errorf("Process() error = %v, want ErrInvalidTransition", result.Error)
+	}
+}
+
+func TestFSMAgentActionsAndEvents(t *testing.T) {
+	var events []TransitionEvent
+	fsm := newReviewFSM(t, FSMConfig{
+		EmitEvents:   true,
+		OnTransition: func(e TransitionEvent) { events = append(events, e) },
+		Transitions: []Transition{
+			{From: []State{stateClarifying}, On: "answer", To: stateDrafting,
+				Action: func(ctx context.Context, sig *Signal, e TransitionEvent) AgentResult {
+					return OK(sig.Derive("draft_request", sig.Payload))
+				}},
+			{From: []State{stateDrafting}, On: "draft", To: stateReviewing,
+				Action: func(ctx context.Context, sig *Signal, e TransitionEvent) AgentResult {
+					return Err(errors.New("reviewer unavailable"))
+				}},
+		},
+	})
+	ctx := context.Background()
+
+	result := fsm.Process(ctx, sessionSignal("answer", "s1", "details"))
+	if result.Error != nil || len(result.Signals) != 2 {
+		t.Fatalf("Process() = %+v, want action output and event", result)
+	}
+	if out := result.Signals[0]; out.Type != "draft_request" || out.Metadata[MetaFSMState] != string(stateDrafting) {
+		t.Errorf("Action output = %v (state %q)", out, out.Metadata[MetaFSMState])
+	}
+	event, ok := result.Signals[1].Payload.(*TransitionEvent)
+	if !ok || event.From != stateClarifying || event.To != stateDrafting || event.Key != "s1" {
+		t.Errorf("Event = %+v, want s1 clarifying -> drafting", result.Signals[1].Payload)
+	}
+	if len(events) != 1 || events[0].Trigger != "answer" {
+		t.Errorf("OnTransition events = %+v", events)
+	}
+
+	// A failing action leaves the state unchanged
+	if result := fsm.Process(ctx, sessionSignal("draft", "s1", nil)); result.Error == nil {
+		t.Fatal("Process() should return the action error")
+	}
+	if state, _ := fsm.State(ctx, "s1"); state != stateDrafting {
+		t.Errorf("State = %s, want %s", state, stateDrafting)
+	}
+}
+
+func TestFSMAgentFinalStateForgetsKey(t *testing.T) {
+	store := NewMemoryStateStore()
+	fsm := newReviewFSM(t, FSMConfig{
+		Store: store,
+		Final: []State{stateDone},
+		Transitions: []Transition{
+			{On: "answer", To: stateDrafting},
+			{From: []State{stateDrafting}, On: "cancel", To: stateDone},
+		},
+	})
+	ctx := context.Background()
+
+	fsm.Process(ctx, sessionSignal("answer", "s1", nil))
+	if store.Len() != 1 {
+		t.Fatalf("Store holds %d keys, want 1", store.Len())
+	}
+	fsm.Process(ctx, sessionSignal("cancel", "s1", nil))
+	if store.Len() != 0 {
+		t.Errorf("Store holds %d keys after final state, want 0", store.Len())
+	}
+}
+
+func TestNewFSMAgentValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config FSMConfig
+	}{
+		{"no initial state", FSMConfig{}},
+		{"undeclared target", FSMConfig{
+			Initial:     "a",
+			States:      []State{"a"},
+			Transitions: []Transition{{On: "go", To: "b"}},
+		}},
+		{"missing trigger", FSMConfig{
+			Initial:     "a",
+			Transitions: []Transition{{To: "a"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFSMAgent("fsm", tt.config); err == nil {
+				t.Error("NewFSMAgent() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestFSMAgentSerializesKey(t *testing.T) {
+	fsm, _ := NewFSMAgent("counter", FSMConfig{
+		Initial: "idle",
+		Transitions: []Transition{
+			{From: []State{"idle"}, On: "start", To: "busy"},
+		},
+	})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result := fsm.Process(ctx, sessionSignal("start", "k", nil)); result.Error == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Errorf("Accepted %d concurrent transitions from idle, want 1", accepted)
+	}
+}
@@ -0,0 +1,299 @@
+package signal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// GATEWAY: HTTP/JSON Access to an Engine
+// =============================================================================
+
+// GatewayConfig configures a Gateway.
+type GatewayConfig struct {
+	// Codec decodes request payloads into the Go type registered for the
+	// signal type, and encodes signals in responses. Defaults to a codec
+	// without registered types.
+	Codec *Codec
+
+	// Authenticate is called for every request before it is handled.
+	// A non-nil error rejects the request with 401 Unauthorized.
+	// nil accepts every request.
+	Authenticate func(r *http.Request) error
+
+	// Source is the Source of submitted signals. Defaults to "gateway".
+	Source string
+
+	// SubmitTimeout bounds waiting for room in the engine inbox; a full
+	// inbox then answers 503. Defaults to 5 seconds.
+	SubmitTimeout time.Duration
+
+	// SyncTimeout is the longest a synchronous request waits for a reply,
+	// also the default when the request sets no timeout. Defaults to 30s.
+	SyncTimeout time.Duration
+
+	// StreamBuffer is the number of signals buffered per event stream.
+	// Signals arriving while a slow client's buffer is full are skipped.
+	// Defaults to 64.
+	StreamBuffer int
+
+	// MaxBodyBytes bounds the size of a request body; a larger one answers
+	// 413 Request Entity Too Large. Defaults to 1 MiB.
+	MaxBodyBytes int64
+}
+
+// GatewayRequest is the JSON body of a submitted signal. The payload is
+// decoded with the gateway's Codec.
+type GatewayRequest struct {
+	Type           SignalType        `json:"type"`
+	Payload        json.RawMessage   `json:"payload,omitempty"`
+	Destination    string            `json:"destination,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
+}
+
+// Gateway is an http.Handler that lets HTTP clients drive an Engine:
+//
+//	POST /signals          submit a signal; 202 with {"id": ...}, or 400 with
+//	                       {"error", "fields"} if the payload violates its schema
+//	POST /signals/sync     submit and wait for a reply; 200 with the reply envelope
+//	GET  /signals/stream   Server-Sent Events of signals matching a filter
+//
+// A reply is the first signal descending from the submitted one (directly
+// or through intermediate agents) whose type is the reply_type query
+// parameter; without reply_type, the first direct output is the reply. The
+// timeout query parameter (a Go duration) shortens the wait; on timeout the
+// gateway answers 504.
+//
+// The stream filter uses query parameters: type (repeatable), source,
+// destination, and meta.<key>=<value> for required metadata. Each event
+// is named after the signal type, has the signal ID as its event ID and the
+// envelope JSON as data.
+//
+// Mount the gateway under a prefix with http.StripPrefix.
+type Gateway struct {
+	engine *Engine
+	config GatewayConfig
+	mux    *http.ServeMux
+}
+
+// NewGateway creates a gateway for an engine.
+func NewGateway(engine *Engine, config GatewayConfig) *Gateway {
+	if config.Codec == nil {
+		config.Codec = NewCodec()
+	}
+	if config.Source == "" {
+		config.Source = "gateway"
+	}
+	if config.SubmitTimeout <= 0 {
+		config.SubmitTimeout = 5 * time.Second
+	}
+	if config.SyncTimeout <= 0 {
+		config.SyncTimeout = 30 * time.Second
+	}
+	if config.StreamBuffer <= 0 {
+		config.StreamBuffer = 64
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = 1 << 20
+	}
+	g := &Gateway{engine: engine, config: config, mux: http.NewServeMux()}
+	g.mux.HandleFunc("POST /signals", g.handleSubmit)
+	g.mux.HandleFunc("POST /signals/sync", g.handleSync)
+	g.mux.HandleFunc("GET /signals/stream", g.handleStream)
+	return g
+}
+
+// ServeHTTP authenticates the request and dispatches it.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if g.config.Authenticate != nil {
+		if err := g.config.Authenticate(r); err != nil {
+			writeJSONError(w, http.StatusUnauthorized, err)
+			return
+		}
+	}
+	g.mux.ServeHTTP(w, r)
+}
+
+// handleSubmit submits a signal without waiting.
+func (g *Gateway) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	sig, ok := g.decodeSignal(w, r)
+	if !ok {
+		return
+	}
+	if err := g.engine.SubmitWithTimeout(sig, g.config.SubmitTimeout); err != nil {
+		writeSubmitError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": sig.ID})
+}
+
+// handleSync submits a signal and waits for its reply.
+func (g *Gateway) handleSync(w http.ResponseWriter, r *http.Request) {
+	timeout := g.config.SyncTimeout
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid timeout '%s'", raw))
+			return
+		}
+		timeout = min(d, g.config.SyncTimeout)
+	}
+	replyType := SignalType(r.URL.Query().Get("reply_type"))
+
+	sig, ok := g.decodeSignal(w, r)
+	if !ok {
+		return
+	}
+
+	// Track the submitted signal's descendants until one is the reply.
+	// The tap is attached before submitting so no output is missed.
+	replies := make(chan *Signal, 1)
+	var mu sync.Mutex
+	tree := map[string]bool{sig.ID: true}
+	remove := g.engine.taps.add(func(s *Signal) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !tree[s.ParentID] {
+			return
+		}
+		tree[s.ID] = true
+		if (replyType == "" && s.ParentID == sig.ID) || (replyType != "" && s.Type == replyType) {
+			select {
+			case replies <- s:
+			default:
+			}
+		}
+	})
+	defer remove()
+
+	if err := g.engine.SubmitWithTimeout(sig, g.config.SubmitTimeout); err != nil {
+		writeSubmitError(w, err)
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-replies:
+		env, err := g.config.Codec.Wrap(reply)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, env)
+	case <-timer.C:
+		writeJSONError(w, http.StatusGatewayTimeout, fmt.Errorf("no reply to signal '%s' within %v", sig.ID, timeout))
+	case <-r.Context().Done():
+	}
+}
+
+// handleStream streams matching signals as Server-Sent Events until the
+// client disconnects.
+func (g *Gateway) handleStream(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := Subscription{Metadata: make(map[string]string)}
+	for _, t := range query["type"] {
+		filter.Types = append(filter.Types, SignalType(t))
+	}
+	for key, values := range query {
+		if name, ok := strings.CutPrefix(key, "meta."); ok && len(values) > 0 {
+			filter.Metadata[name] = values[0]
+		}
+	}
+	source, destination := query.Get("source"), query.Get("destination")
+
+	events := make(chan *Signal, g.config.StreamBuffer)
+	remove := g.engine.taps.add(func(s *Signal) {
+		if !filter.Matches(s) || (source != "" && s.Source != source) || (destination != "" && s.Destination != destination) {
+			return
+		}
+		select {
+		case events <- s:
+		default: // Slow client: skip rather than block a worker
+		}
+	})
+	defer remove()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher := http.NewResponseController(w)
+	flusher.Flush()
+
+	for {
+		select {
+		case s := <-events:
+			data, err := g.config.Codec.Encode(s)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", s.ID, s.Type, data)
+			if err := flusher.Flush(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// decodeSignal builds a signal from the request body, answering 400 on
+// malformed input and 413 on a body over MaxBodyBytes.
+func (g *Gateway) decodeSignal(w http.ResponseWriter, r *http.Request) (*Signal, bool) {
+	var req GatewayRequest
+	body := http.MaxBytesReader(w, r.Body, g.config.MaxBodyBytes)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit))
+			return nil, false
+		}
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		return nil, false
+	}
+	if req.Type == "" {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("signal type is required"))
+		return nil, false
+	}
+	payload, err := g.config.Codec.DecodePayload(req.Type, req.Payload)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	sig := g.engine.Factory().New(req.Type, payload)
+	sig.Source = g.config.Source
+	sig.Destination = req.Destination
+	sig.IdempotencyKey = req.IdempotencyKey
+	for k, v := range req.Metadata {
+		sig.Metadata[k] = v
+	}
+	return sig, true
+}
+
+// writeSubmitError answers a rejected submission: 400 with the field
+// errors of an invalid payload, 503 otherwise.
+func writeSubmitError(w http.ResponseWriter, err error) {
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error(), "fields": invalid.Fields})
+		return
+	}
+	writeJSONError(w, http.StatusServiceUnavailable, err)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
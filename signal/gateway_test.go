@@ -0,0 +1,189 @@
+package signal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type gatewayQuestion struct {
+	Text string `json:"text"`
+}
+
+// newGatewayServer runs a coordinator → worker flow behind a gateway that
+// requires a bearer token.
+func newGatewayServer(t *testing.T) (*httptest.Server, chan *Signal) {
+	t.Helper()
+	seen := make(chan *Signal, 8)
+	router := NewRouter()
+	router.Register(NewAgentFunc("coordinator", func(ctx context.Context, sig *Signal) AgentResult {
+		q, ok := sig.Payload.(gatewayQuestion)
+		if !ok {
+			return Err(errors.New("unexpected payload"))
+		}
+		seen <- sig
+		return OK(sig.Derive("task", q.Text).WithDestination("worker"))
+	}))
+	router.Register(NewAgentFunc("worker", func(ctx context.Context, sig *Signal) AgentResult {
+		return OK(sig.Derive("answer", strings.ToUpper(sig.Payload.(string))))
+	}))
+	router.Register(NewAgentFunc("sink", func(ctx context.Context, sig *Signal) AgentResult { return OK() }))
+	router.AddRule(func(sig *Signal) []string {
+		switch sig.Type {
+		case "question":
+			return []string{"coordinator"}
+		case "answer":
+			return []string{"sink"}
+		}
+		return nil
+	})
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+	t.Cleanup(func() { engine.Stop() })
+
+	codec := NewCodec()
+	codec.Register("question", gatewayQuestion{})
+	gateway := NewGateway(engine, GatewayConfig{
+		Codec: codec,
+		Authenticate: func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				return errors.New("invalid token")
+			}
+			return nil
+		},
+	})
+	server := httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+	return server, seen
+}
+
+func gatewayPost(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s error = %v", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestGatewaySubmit(t *testing.T) {
+	server, seen := newGatewayServer(t)
+
+	resp := gatewayPost(t, server.URL+"/signals", `{"type":"question","payload":{"text":"hi"},"metadata":{"user":"u1"}}`)
+	var body map[string]string
+	json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusAccepted || body["id"] == "" {
+		t.Fatalf("Response = %d %v, want 202 with an ID", resp.StatusCode, body)
+	}
+
+	select {
+	case sig := <-seen:
+		if sig.ID != body["id"] || sig.Source != "gateway" || sig.Metadata["user"] != "u1" {
+			t.Errorf("Submitted signal = %+v", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Signal never reached the coordinator")
+	}
+
+	if resp := gatewayPost(t, server.URL+"/signals", `{"type":"question","payload":{"text":1}}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Mistyped payload status = %d, want 400", resp.StatusCode)
+	}
+	if resp := gatewayPost(t, server.URL+"/signals", `{"payload":{}}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Missing type status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestGatewayRejectsLargeBodies(t *testing.T) {
+	server, _ := newGatewayServer(t)
+
+	text := strings.Repeat("x", 2<<20) // Over the 1 MiB default
+	resp := gatewayPost(t, server.URL+"/signals", `{"type":"question","payload":{"text":"`+text+`"}}`)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Status = %d, want 413", resp.StatusCode)
+	}
+}
+
+func TestGatewayAuthentication(t *testing.T) {
+	server, _ := newGatewayServer(t)
+
+	resp, err := http.Post(server.URL+"/signals", "application/json", strings.NewReader(`{"type":"question"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestGatewaySync(t *testing.T) {
+	server, _ := newGatewayServer(t)
+
+	resp := gatewayPost(t, server.URL+"/signals/sync?reply_type=answer", `{"type":"question","payload":{"text":"hi"}}`)
+	var reply Envelope
+	json.NewDecoder(resp.Body).Decode(&reply)
+	if resp.StatusCode != http.StatusOK || reply.Type != "answer" || string(reply.Payload) != `"HI"` {
+		t.Errorf("Reply = %d %+v, want the worker's answer", resp.StatusCode, reply)
+	}
+
+	// Without reply_type, the coordinator's direct output is the reply
+	resp = gatewayPost(t, server.URL+"/signals/sync", `{"type":"question","payload":{"text":"hi"}}`)
+	json.NewDecoder(resp.Body).Decode(&reply)
+	if reply.Type != "task" {
+		t.Errorf("Reply type = %s, want task", reply.Type)
+	}
+
+	resp = gatewayPost(t, server.URL+"/signals/sync?reply_type=never&timeout=20ms", `{"type":"question","payload":{"text":"hi"}}`)
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("Status = %d, want 504", resp.StatusCode)
+	}
+}
+
+func TestGatewayStream(t *testing.T) {
+	server, _ := newGatewayServer(t)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/signals/stream?type=answer&meta.user=u1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", ct)
+	}
+
+	gatewayPost(t, server.URL+"/signals", `{"type":"question","payload":{"text":"other"},"metadata":{"user":"u2"}}`)
+	gatewayPost(t, server.URL+"/signals", `{"type":"question","payload":{"text":"mine"},"metadata":{"user":"u1"}}`)
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	var event []string
+	for len(event) < 3 {
+		select {
+		case line := <-lines:
+			event = append(event, line)
+		case <-time.After(time.Second):
+			t.Fatalf("Stream stalled after %q", event)
+		}
+	}
+	if event[1] != "event: answer" || !strings.Contains(event[2], `"payload":"MINE"`) {
+		t.Errorf("Event = %q, want only u1's answer", event)
+	}
+}
@@ -0,0 +1,43 @@
+package signal
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestEngine registers agents with a new router, adds rule unless nil,
+// and creates an engine that is stopped when the test ends. The engine is
+// not started, so tests can set hooks first (see startEngine).
+func newTestEngine(t *testing.T, config EngineConfig, rule RoutingRule, agents ...Agent) *Engine {
+	t.Helper()
+	router := NewRouter()
+	for _, a := range agents {
+		router.Register(a)
+	}
+	if rule != nil {
+		router.AddRule(rule)
+	}
+	engine := NewEngine(config, router)
+	t.Cleanup(func() { engine.Stop() })
+	return engine
+}
+
+// startEngine starts an engine, failing the test if it cannot.
+func startEngine(t *testing.T, engine *Engine) {
+	t.Helper()
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,255 @@
+package signal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// IN-FLIGHT TRACKING: Per-Agent-Instance Accounting
+// =============================================================================
+
+// agentSlot tracks the in-flight calls of one agent instance.
+// A slot is acquired when a signal is routed to the agent and released when
+// processing completes, so a replaced instance can be drained before Close.
+type agentSlot struct {
+	agent Agent
+
+	mu         sync.Mutex
+	count      int64
+	detached   bool          // No new calls can be acquired
+	drained    chan struct{} // Closed once detached with no calls in flight
+	afterDrain func()        // A Close postponed until drained
+}
+
+func newAgentSlot(agent Agent) *agentSlot {
+	return &agentSlot{agent: agent, drained: make(chan struct{})}
+}
+
+// release marks one routed signal as fully processed by this instance.
+func (s *agentSlot) release() {
+	s.mu.Lock()
+	s.count--
+	var after func()
+	if s.detached && s.count == 0 {
+		close(s.drained)
+		after, s.afterDrain = s.afterDrain, nil
+	}
+	s.mu.Unlock()
+	if after != nil {
+		after()
+	}
+}
+
+// detach marks the slot as detached, so it drains.
+func (s *agentSlot) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detached = true
+	if s.count == 0 {
+		close(s.drained)
+	}
+}
+
+// postpone arranges for f to run when the detached slot drains, unless it
+// already has: then f is not run and postpone reports false.
+func (s *agentSlot) postpone(f func()) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count == 0 {
+		return false
+	}
+	s.afterDrain = f
+	return true
+}
+
+// inflight returns the number of calls in flight.
+func (s *agentSlot) inflight() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// acquireSlots resolves each destination to its current agent instance and
+// marks one call in flight on each. Destinations that are no longer
+// registered yield a nil slot.
+func (e *Engine) acquireSlots(destinations []string) []*agentSlot {
+	e.slotsMu.Lock()
+	defer e.slotsMu.Unlock()
+
+	slots := make([]*agentSlot, len(destinations))
+	for i, id := range destinations {
+		agent, exists := e.router.GetAgent(id)
+		if !exists {
+			continue
+		}
+		slot := e.slots[id]
+		if slot == nil || !sameAgent(slot.agent, agent) {
+			// First use, or the agent was replaced directly on the Router
+			slot = newAgentSlot(agent)
+			e.slots[id] = slot
+		}
+		slot.mu.Lock()
+		slot.count++
+		slot.mu.Unlock()
+		slots[i] = slot
+	}
+	return slots
+}
+
+// detachSlot removes the current slot for an agent ID so no new calls can be
+// acquired on it, optionally installing a slot for a replacement instance.
+// The returned slot may still have calls in flight; it is never nil, since
+// an agent that was never called has an empty one.
+func (e *Engine) detachSlot(agentID string, replacement Agent) *agentSlot {
+	e.slotsMu.Lock()
+	defer e.slotsMu.Unlock()
+
+	old := e.slots[agentID]
+	if old == nil {
+		old = newAgentSlot(nil)
+	}
+	if replacement != nil {
+		e.router.Register(replacement)
+		e.slots[agentID] = newAgentSlot(replacement)
+	} else {
+		e.router.Unregister(agentID)
+		delete(e.slots, agentID)
+	}
+	old.detach()
+	return old
+}
+
+// =============================================================================
+// HOT SWAP: Replace and Remove Agents Without Restarting
+// =============================================================================
+
+// SwapReport describes the outcome of ReplaceAgent or RemoveAgent.
+type SwapReport struct {
+	AgentID   string        // ID of the replaced or removed agent
+	Drained   int64         // In-flight calls the old instance was running when detached
+	DrainTime time.Duration // Time spent waiting for in-flight calls
+	TimedOut  bool          // True if draining gave up before all calls finished
+	CloseErr  error         // Error returned by the old instance's Close, if any
+
+	// ClosePending is set when calls were still in flight: the old instance
+	// is closed once they finish, and a Close error is logged then.
+	ClosePending bool
+}
+
+// ReplaceAgent swaps the registered agent with the same ID for newAgent.
+// New signals are routed to newAgent immediately; signals already routed to
+// the old instance are still processed by it. ReplaceAgent waits for those
+// in-flight calls to finish, up to ProcessTimeout, and closes the old
+// instance once they have (see SwapReport.ClosePending).
+// If the engine is running, newAgent is initialized first; if Init fails,
+// the old instance stays in place and the error is returned.
+func (e *Engine) ReplaceAgent(newAgent Agent) (SwapReport, error) {
+	e.lifecycle.Lock()
+	defer e.lifecycle.Unlock()
+	return e.replaceAgent(newAgent)
+}
+
+// replaceAgent implements ReplaceAgent. Caller must hold e.lifecycle.
+func (e *Engine) replaceAgent(newAgent Agent) (SwapReport, error) {
+	id := newAgent.ID()
+	report := SwapReport{AgentID: id}
+
+	e.mu.Lock()
+	running := e.running
+	e.mu.Unlock()
+
+	if running {
+		if err := e.initAgent(newAgent); err != nil {
+			return report, err
+		}
+	}
+
+	previous, hadPrevious := e.router.GetAgent(id)
+	old := e.detachSlot(id, newAgent)
+
+	e.mu.Lock()
+	_, wasInitialized := e.initialized[id]
+	delete(e.initialized, id)
+	if running {
+		e.initialized[id] = newAgent
+	}
+	e.mu.Unlock()
+
+	if !hadPrevious {
+		return report, nil
+	}
+
+	e.drainSlot(old, &report)
+	if wasInitialized {
+		e.closeDrained(old, previous, &report)
+	}
+	return report, report.CloseErr
+}
+
+// RemoveAgent stops routing new signals to an agent and closes it. Signals
+// already routed to the agent are still processed, and the agent is closed
+// once they finish. With drain set, RemoveAgent waits for them, up to
+// ProcessTimeout; otherwise it returns at once (see SwapReport.ClosePending).
+func (e *Engine) RemoveAgent(agentID string, drain bool) (SwapReport, error) {
+	e.lifecycle.Lock()
+	defer e.lifecycle.Unlock()
+
+	report := SwapReport{AgentID: agentID}
+
+	agent, exists := e.router.GetAgent(agentID)
+	if !exists {
+		return report, fmt.Errorf("agent '%s' not found", agentID)
+	}
+	old := e.detachSlot(agentID, nil)
+
+	e.mu.Lock()
+	_, wasInitialized := e.initialized[agentID]
+	delete(e.initialized, agentID)
+	e.mu.Unlock()
+
+	if drain {
+		e.drainSlot(old, &report)
+	} else {
+		report.Drained = old.inflight()
+	}
+	if wasInitialized {
+		e.closeDrained(old, agent, &report)
+	}
+	return report, report.CloseErr
+}
+
+// drainSlot waits for a detached slot's in-flight calls to finish,
+// bounded by the configured ProcessTimeout.
+func (e *Engine) drainSlot(slot *agentSlot, report *SwapReport) {
+	report.Drained = slot.inflight()
+
+	start := e.config.Clock.Now()
+	expired := make(chan struct{})
+	timer := e.config.Clock.AfterFunc(e.config.ProcessTimeout, func() { close(expired) })
+	defer timer.Stop()
+
+	select {
+	case <-slot.drained:
+	case <-expired:
+		report.TimedOut = true
+	}
+	report.DrainTime = e.config.Clock.Now().Sub(start)
+}
+
+// closeDrained closes a detached agent instance once its slot has drained:
+// now if it has, reporting the error, or else when its last call finishes,
+// logging the error.
+func (e *Engine) closeDrained(slot *agentSlot, agent Agent, report *SwapReport) {
+	postponed := slot.postpone(func() {
+		if err := e.closeAgent(agent); err != nil && e.log != nil {
+			e.log.agentError(agent.ID(), err)
+		}
+	})
+	if postponed {
+		report.ClosePending = true
+		return
+	}
+	report.CloseErr = e.closeAgent(agent)
+}
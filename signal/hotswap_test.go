@@ -0,0 +1,226 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingAgent blocks in Process until release is closed.
+type blockingAgent struct {
+	id        string
+	started   chan struct{}
+	release   chan struct{}
+	processed atomic.Int32
+	closed    atomic.Bool
+	closedMid atomic.Bool // Close was called while a Process call was running
+	running   atomic.Int32
+}
+
+func newBlockingAgent(id string) *blockingAgent {
+	return &blockingAgent{
+		id:      id,
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (a *blockingAgent) ID() string { return a.id }
+
+func (a *blockingAgent) Process(ctx context.Context, sig *Signal) AgentResult {
+	a.running.Add(1)
+	defer a.running.Add(-1)
+	a.started <- struct{}{}
+	<-a.release
+	a.processed.Add(1)
+	return OK()
+}
+
+func (a *blockingAgent) Close(ctx context.Context) error {
+	if a.running.Load() > 0 {
+		a.closedMid.Store(true)
+	}
+	a.closed.Store(true)
+	return nil
+}
+
+func TestEngineReplaceAgentDrainsInFlight(t *testing.T) {
+	old := newBlockingAgent("worker")
+	router := NewRouter()
+	router.Register(old)
+
+	config := DefaultConfig()
+	config.WorkerCount = 2
+	engine := NewEngine(config, router)
+	engine.Start()
+	defer engine.Stop()
+
+	engine.Submit(NewSignal("job", nil).WithDestination("worker"))
+	<-old.started
+
+	var newProcessed atomic.Int32
+	replacement := NewAgentFunc("worker", func(ctx context.Context, sig *Signal) AgentResult {
+		newProcessed.Add(1)
+		return OK()
+	})
+
+	done := make(chan SwapReport, 1)
+	go func() {
+		report, _ := engine.ReplaceAgent(replacement)
+		done <- report
+	}()
+
+	// New signals go to the replacement while the old call is still running
+	waitFor(t, func() bool { agent, _ := router.GetAgent("worker"); return agent == replacement })
+	engine.Submit(NewSignal("job", nil).WithDestination("worker"))
+	waitFor(t, func() bool { return newProcessed.Load() == 1 })
+
+	select {
+	case <-done:
+		t.Fatal("ReplaceAgent returned before the old instance drained")
+	default:
+	}
+
+	close(old.release)
+	report := <-done
+
+	if report.Drained != 1 {
+		t.Errorf("Drained = %d, want 1", report.Drained)
+	}
+	if report.TimedOut {
+		t.Error("Drain should not time out")
+	}
+	if old.processed.Load() != 1 {
+		t.Errorf("Old instance processed = %d, want 1", old.processed.Load())
+	}
+	if !old.closed.Load() || old.closedMid.Load() {
+		t.Error("Old instance should be closed after its in-flight call finished")
+	}
+}
+
+func TestEngineRegisterDrainsReplacedAgent(t *testing.T) {
+	old := newBlockingAgent("worker")
+	router := NewRouter()
+	router.Register(old)
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+	defer engine.Stop()
+
+	engine.Submit(NewSignal("job", nil).WithDestination("worker"))
+	<-old.started
+
+	replacement := newBlockingAgent("worker")
+	done := make(chan error, 1)
+	go func() { done <- engine.Register(replacement) }()
+	waitFor(t, func() bool { agent, _ := router.GetAgent("worker"); return agent == replacement })
+	select {
+	case <-done:
+		t.Fatal("Register returned before the old instance drained")
+	default:
+	}
+
+	close(old.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if !old.closed.Load() || old.closedMid.Load() {
+		t.Error("Old instance should be closed after its in-flight call finished")
+	}
+}
+
+func TestEngineRemoveAgent(t *testing.T) {
+	for _, drain := range []bool{true, false} {
+		t.Run(fmt.Sprintf("drain=%v", drain), func(t *testing.T) {
+			agent := newBlockingAgent("worker")
+			router := NewRouter()
+			router.Register(agent)
+			engine := NewEngine(DefaultConfig(), router)
+			engine.Start()
+			defer engine.Stop()
+
+			engine.Submit(NewSignal("job", nil).WithDestination("worker"))
+			<-agent.started
+
+			done := make(chan SwapReport, 1)
+			go func() {
+				report, _ := engine.RemoveAgent("worker", drain)
+				done <- report
+			}()
+			waitFor(t, func() bool { _, ok := router.GetAgent("worker"); return !ok })
+
+			var report SwapReport
+			if drain {
+				select {
+				case <-done:
+					t.Fatal("RemoveAgent returned before the agent drained")
+				default:
+				}
+				close(agent.release)
+				report = <-done
+			} else {
+				// Returns at once, closing the agent when its call finishes
+				report = <-done
+				if !report.ClosePending || agent.closed.Load() {
+					t.Errorf("Report = %+v, closed = %v; want the Close pending", report, agent.closed.Load())
+				}
+				close(agent.release)
+			}
+
+			if report.Drained != 1 {
+				t.Errorf("Drained = %d, want 1", report.Drained)
+			}
+			waitFor(t, agent.closed.Load)
+			if agent.closedMid.Load() {
+				t.Error("Agent was closed during Process")
+			}
+		})
+	}
+}
+
+func TestEngineClosesTimedOutAgentWhenDrained(t *testing.T) {
+	agent := newBlockingAgent("worker")
+	clock := NewManualClock(time.Unix(0, 0))
+	config := DefaultConfig()
+	config.Clock = clock
+	engine := newTestEngine(t, config, nil, agent)
+	startEngine(t, engine)
+
+	engine.Submit(NewSignal("job", nil).WithDestination("worker"))
+	<-agent.started
+
+	done := make(chan SwapReport, 1)
+	go func() {
+		report, _ := engine.ReplaceAgent(newBlockingAgent("worker"))
+		done <- report
+	}()
+	waitFor(t, func() bool { return clock.Pending() > 0 })
+	clock.Advance(config.ProcessTimeout)
+	report := <-done
+	if !report.TimedOut || !report.ClosePending || report.DrainTime != config.ProcessTimeout {
+		t.Errorf("Report = %+v, want a timed out drain with the Close pending", report)
+	}
+	if agent.closed.Load() {
+		t.Fatal("An agent still processing should not be closed")
+	}
+
+	close(agent.release)
+	waitFor(t, agent.closed.Load)
+	if agent.closedMid.Load() {
+		t.Error("Agent was closed during Process")
+	}
+
+	// Unregister does not wait, and closes once the call finishes too
+	replacement, _ := engine.router.GetAgent("worker")
+	engine.Submit(NewSignal("job", nil).WithDestination("worker"))
+	<-replacement.(*blockingAgent).started
+	if err := engine.Unregister("worker"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+	if replacement.(*blockingAgent).closed.Load() {
+		t.Fatal("Unregister closed an agent still processing")
+	}
+	close(replacement.(*blockingAgent).release)
+	waitFor(t, replacement.(*blockingAgent).closed.Load)
+}
@@ -0,0 +1,110 @@
+package signal
+
+import (
+	"context"
+	"sync"
+)
+
+// =============================================================================
+// QUIESCENCE: Waiting for In-Flight Work
+// =============================================================================
+
+// inflight counts the work the engine owes, per trace and in total. A unit
+// is held for each signal in the inbox, each signal a worker is routing,
+// each call an agent is processing (until its result, deferred or not, has
+// been handled and its outputs submitted), each signal held for a paused
+// agent and each duplicate waiting for its original's results. Outputs are
+// submitted before their parent's unit is released, so a trace never looks
+// idle between a signal and its children.
+type inflight struct {
+	mu     sync.Mutex
+	total  int
+	idle   chan struct{} // Closed while total is 0
+	traces map[string]*traceWork
+}
+
+type traceWork struct {
+	units int
+	done  chan struct{} // Closed when units drops to 0
+}
+
+func newInflight() *inflight {
+	idle := make(chan struct{})
+	close(idle)
+	return &inflight{idle: idle, traces: make(map[string]*traceWork)}
+}
+
+// add acquires a unit of work for a trace.
+func (f *inflight) add(trace string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.total == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.total++
+	w, ok := f.traces[trace]
+	if !ok {
+		w = &traceWork{done: make(chan struct{})}
+		f.traces[trace] = w
+	}
+	w.units++
+}
+
+// done releases a unit of work acquired by add.
+func (f *inflight) done(trace string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if w, ok := f.traces[trace]; ok {
+		if w.units--; w.units == 0 {
+			close(w.done)
+			delete(f.traces, trace)
+		}
+	}
+	if f.total--; f.total == 0 {
+		close(f.idle)
+	}
+}
+
+// counts returns the units in flight in total and per trace.
+func (f *inflight) counts() (total, traces int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.total, len(f.traces)
+}
+
+// WaitIdle blocks until no work is in flight: the inbox is empty, no signal
+// is being routed or processed, every deferred result has been handled, and
+// no signal is held for a paused agent. Outputs count from the moment their
+// agent returns, so WaitIdle does not return between a signal and the
+// signals derived from it. Scheduled submissions count once they fire.
+// It returns ctx.Err() if ctx is done first.
+func (e *Engine) WaitIdle(ctx context.Context) error {
+	e.inflight.mu.Lock()
+	idle := e.inflight.idle
+	e.inflight.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitTrace blocks until no signal of a trace (see TraceID) is in flight,
+// with the semantics of WaitIdle restricted to that causal tree. Call it
+// after submitting the signal that starts the trace; a trace with nothing
+// in flight returns at once. It returns ctx.Err() if ctx is done first.
+func (e *Engine) WaitTrace(ctx context.Context, traceID string) error {
+	e.inflight.mu.Lock()
+	w, ok := e.inflight.traces[traceID]
+	e.inflight.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,229 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newChainEngine routes step1 → step2 → step3. release, when non-nil, gates
+// step1 of signals with the "gated" metadata; hold, when non-nil, gates
+// every step3, which reports on reached when it starts.
+func newChainEngine(t *testing.T, release, hold chan struct{}) (engine *Engine, finished *atomic.Int32, reached chan struct{}) {
+	t.Helper()
+	finished = new(atomic.Int32)
+	reached = make(chan struct{}, 16)
+	route := func(sig *Signal) []string {
+		switch sig.Type {
+		case "step1":
+			return []string{"first"}
+		case "step2":
+			return []string{"second"}
+		case "step3":
+			return []string{"third"}
+		}
+		return nil
+	}
+	engine = newTestEngine(t, DefaultConfig(), route,
+		NewAgentFunc("first", func(ctx context.Context, sig *Signal) AgentResult {
+			if sig.Metadata["gated"] != "" {
+				<-release
+			}
+			return OK(sig.Derive("step2", nil))
+		}),
+		NewAgentFunc("second", func(ctx context.Context, sig *Signal) AgentResult {
+			return OK(sig.Derive("step3", nil))
+		}),
+		NewAgentFunc("third", func(ctx context.Context, sig *Signal) AgentResult {
+			reached <- struct{}{}
+			if hold != nil {
+				<-hold
+			}
+			finished.Add(1)
+			return OK()
+		}),
+	)
+	startEngine(t, engine)
+	return engine, finished, reached
+}
+
+func TestWaitIdleCoversDerivedSignals(t *testing.T) {
+	hold := make(chan struct{})
+	engine, finished, reached := newChainEngine(t, nil, hold)
+
+	for range 3 {
+		engine.Submit(NewSignal("step1", nil))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	idle := make(chan error, 1)
+	go func() { idle <- engine.WaitIdle(ctx) }()
+
+	// Only the derived step3 signals are left, held in the last agent
+	for range 3 {
+		<-reached
+	}
+	select {
+	case err := <-idle:
+		t.Fatalf("WaitIdle returned while step3 was running: %v", err)
+	default:
+	}
+	close(hold)
+	if err := <-idle; err != nil {
+		t.Fatalf("WaitIdle error = %v", err)
+	}
+	if got := finished.Load(); got != 3 {
+		t.Errorf("WaitIdle returned with %d of 3 chains finished", got)
+	}
+	if stats := engine.Stats(); stats.InFlight != 0 || stats.Traces != 0 {
+		t.Errorf("Expected nothing in flight, got %d units in %d traces", stats.InFlight, stats.Traces)
+	}
+}
+
+func TestWaitTraceIsolatesCausalTrees(t *testing.T) {
+	release := make(chan struct{})
+	engine, finished, _ := newChainEngine(t, release, nil)
+
+	gated := NewSignal("step1", nil).WithMetadata("gated", "yes")
+	free := NewSignal("step1", nil)
+	engine.Submit(gated)
+	engine.Submit(free)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := engine.WaitTrace(ctx, TraceID(free)); err != nil {
+		t.Fatalf("WaitTrace error = %v", err)
+	}
+	if got := finished.Load(); got != 1 {
+		t.Errorf("Expected only the free chain finished, got %d", got)
+	}
+	if stats := engine.Stats(); stats.Traces != 1 {
+		t.Errorf("Expected the gated trace in flight, got %d traces", stats.Traces)
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	if err := engine.WaitTrace(short, gated.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitTrace of a blocked trace should time out, got %v", err)
+	}
+	if err := engine.WaitIdle(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitIdle with a blocked trace should time out, got %v", err)
+	}
+
+	close(release)
+	if err := engine.WaitTrace(ctx, gated.ID); err != nil {
+		t.Fatalf("WaitTrace error = %v", err)
+	}
+	if got := finished.Load(); got != 2 {
+		t.Errorf("Expected both chains finished, got %d", got)
+	}
+	if err := engine.WaitTrace(ctx, "unknown"); err != nil {
+		t.Errorf("A trace with nothing in flight should return at once, got %v", err)
+	}
+}
+
+func TestWaitIdleCoversDeferredResults(t *testing.T) {
+	var finished atomic.Int32
+	deferred := make(chan func(), 1) // Completes the deferred call
+	router := NewRouter()
+	router.Register(NewAgentFunc("deferring", func(ctx context.Context, sig *Signal) AgentResult {
+		complete, ok := Defer(ctx)
+		if !ok {
+			return Err(errors.New("not in an engine"))
+		}
+		deferred <- func() { complete(OK(sig.Derive("late", nil))) }
+		return OK()
+	}))
+	router.Register(NewAgentFunc("sink", func(ctx context.Context, sig *Signal) AgentResult {
+		finished.Add(1)
+		return OK()
+	}))
+	router.AddRule(func(sig *Signal) []string {
+		if sig.Type == "late" {
+			return []string{"sink"}
+		}
+		return []string{"deferring"}
+	})
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+	defer engine.Stop()
+
+	engine.Submit(NewSignal("start", nil))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	idle := make(chan error, 1)
+	go func() { idle <- engine.WaitIdle(ctx) }()
+
+	finish := <-deferred
+	select {
+	case err := <-idle:
+		t.Fatalf("WaitIdle returned before the deferred result was completed: %v", err)
+	default:
+	}
+	finish()
+	if err := <-idle; err != nil {
+		t.Fatalf("WaitIdle error = %v", err)
+	}
+	if finished.Load() != 1 {
+		t.Error("WaitIdle returned before the deferred result's output was processed")
+	}
+}
+
+func TestWaitIdleCoversHeldSignals(t *testing.T) {
+	engine, finished, _ := newChainEngine(t, nil, nil)
+	engine.PauseAgent("second")
+
+	sig := NewSignal("step1", nil)
+	engine.Submit(sig)
+	short, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := engine.WaitTrace(short, sig.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("A held signal should keep its trace in flight, got %v", err)
+	}
+
+	engine.ResumeAgent("second")
+	ctx, cancelWait := context.WithTimeout(context.Background(), time.Second)
+	defer cancelWait()
+	if err := engine.WaitIdle(ctx); err != nil {
+		t.Fatalf("WaitIdle error = %v", err)
+	}
+	if finished.Load() != 1 {
+		t.Error("WaitIdle returned before the held signal was processed")
+	}
+}
+
+func TestWaitIdleAfterRejectedSubmission(t *testing.T) {
+	release := make(chan struct{})
+	router := NewRouter()
+	router.Register(NewAgentFunc("blocking", func(ctx context.Context, sig *Signal) AgentResult {
+		<-release
+		return OK()
+	}))
+	router.AddRule(func(sig *Signal) []string { return []string{"blocking"} })
+	engine := NewEngine(EngineConfig{BufferSize: 1, WorkerCount: 1}, router)
+	engine.Start()
+	defer engine.Stop()
+
+	engine.Submit(NewSignal("a", nil)) // Routed and processing: 2 units
+	waitFor(t, func() bool { return engine.Stats().InFlight == 2 })
+	engine.Submit(NewSignal("b", nil)) // Fills the inbox
+
+	if engine.TrySubmit(NewSignal("c", nil)) {
+		t.Fatal("TrySubmit should fail on a full inbox")
+	}
+	if err := engine.SubmitWithTimeout(NewSignal("d", nil), time.Millisecond); err == nil {
+		t.Fatal("SubmitWithTimeout should time out on a full inbox")
+	}
+	if stats := engine.Stats(); stats.InFlight != 3 || stats.Traces != 2 {
+		t.Errorf("Rejected submissions should not count, got %d units in %d traces", stats.InFlight, stats.Traces)
+	}
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := engine.WaitIdle(ctx); err != nil {
+		t.Fatalf("WaitIdle error = %v", err)
+	}
+}
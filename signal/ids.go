@@ -0,0 +1,212 @@
+package signal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// =============================================================================
+// ID GENERATORS: Injectable Signal Identity
+// =============================================================================
+
+// IDGenerator creates unique signal IDs. Implementations must be safe for
+// concurrent use.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDFunc adapts a function to an IDGenerator.
+type IDFunc func() string
+
+// NewID calls f.
+func (f IDFunc) NewID() string {
+	return f()
+}
+
+// DefaultIDGenerator returns the generator used by NewSignal unless the
+// default factory is replaced: "sig-{unix_nano}-{counter}", lock-free.
+func DefaultIDGenerator() IDGenerator {
+	return defaultIDs
+}
+
+var defaultIDs = &counterIDs{}
+
+type counterIDs struct {
+	counter atomic.Uint64
+}
+
+func (g *counterIDs) NewID() string {
+	return fmt.Sprintf("sig-%d-%d", time.Now().UnixNano(), g.counter.Add(1))
+}
+
+// NewUUIDv7Generator returns a generator of RFC 9562 version 7 UUIDs, which
+// sort by creation time (millisecond precision).
+func NewUUIDv7Generator() IDGenerator {
+	return IDFunc(func() string {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return uuid.NewString() // Only if the random source fails
+		}
+		return id.String()
+	})
+}
+
+// NewULIDGenerator returns a generator of ULIDs: 26 Crockford base32
+// characters, a 48-bit millisecond timestamp from clock followed by 80
+// random bits, so IDs sort by creation time. IDs created within the same
+// millisecond are ordered randomly. A nil clock means the system clock.
+func NewULIDGenerator(clock Clock) IDGenerator {
+	if clock == nil {
+		clock = SystemClock()
+	}
+	return IDFunc(func() string {
+		var id [16]byte
+		binary.BigEndian.PutUint64(id[:8], uint64(clock.Now().UnixMilli())<<16)
+		rand.Read(id[6:])
+		return encodeULID(id)
+	})
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodeULID renders 128 bits as 26 base32 characters; the first character
+// carries only the top 3 bits.
+func encodeULID(id [16]byte) string {
+	var out [26]byte
+	for i := range out {
+		var v byte
+		for b := i*5 - 2; b < i*5+3; b++ {
+			v <<= 1
+			if b >= 0 && id[b/8]&(0x80>>(b%8)) != 0 {
+				v |= 1
+			}
+		}
+		out[i] = crockford[v]
+	}
+	return string(out[:])
+}
+
+// SequentialGenerator is a deterministic IDGenerator for tests: prefix-1,
+// prefix-2, and so on.
+type SequentialGenerator struct {
+	prefix  string
+	counter atomic.Uint64
+}
+
+// NewSequentialGenerator creates a sequential generator.
+func NewSequentialGenerator(prefix string) *SequentialGenerator {
+	return &SequentialGenerator{prefix: prefix}
+}
+
+// NewID returns the next ID in sequence.
+func (g *SequentialGenerator) NewID() string {
+	return fmt.Sprintf("%s-%d", g.prefix, g.counter.Add(1))
+}
+
+// Reset restarts the sequence at 1.
+func (g *SequentialGenerator) Reset() {
+	g.counter.Store(0)
+}
+
+// =============================================================================
+// SIGNAL FACTORY: Creating Signals With a Clock and IDs
+// =============================================================================
+
+// SignalFactory creates signals stamped by a Clock and identified by an
+// IDGenerator. NewSignal and Signal.Derive use the default factory; an
+// Engine has its own (see EngineConfig.Clock and EngineConfig.IDs), which
+// agents reach through FactoryFrom.
+type SignalFactory struct {
+	clock Clock
+	ids   IDGenerator
+}
+
+// NewSignalFactory creates a factory. nil arguments mean the system clock
+// and DefaultIDGenerator.
+func NewSignalFactory(clock Clock, ids IDGenerator) *SignalFactory {
+	if clock == nil {
+		clock = SystemClock()
+	}
+	if ids == nil {
+		ids = DefaultIDGenerator()
+	}
+	return &SignalFactory{clock: clock, ids: ids}
+}
+
+// New creates a signal with a fresh ID and the current time.
+func (f *SignalFactory) New(signalType SignalType, payload any) *Signal {
+	return &Signal{
+		ID:        f.ids.NewID(),
+		Type:      signalType,
+		Timestamp: f.clock.Now(),
+		Payload:   payload,
+		Metadata:  make(map[string]string),
+	}
+}
+
+// Derive creates a child of parent, like Signal.Derive.
+func (f *SignalFactory) Derive(parent *Signal, signalType SignalType, payload any) *Signal {
+	child := f.New(signalType, payload)
+	child.ParentID = parent.ID
+	child.Source = parent.Destination // The destination of parent becomes source of child
+	for k, v := range parent.Metadata {
+		if k != MetaIdempotencyKey {
+			child.Metadata[k] = v
+		}
+	}
+	return child
+}
+
+// NewID returns a fresh ID from the factory's generator.
+func (f *SignalFactory) NewID() string {
+	return f.ids.NewID()
+}
+
+// Now returns the current time of the factory's clock.
+func (f *SignalFactory) Now() time.Time {
+	return f.clock.Now()
+}
+
+var defaultFactory atomic.Pointer[SignalFactory]
+
+func init() {
+	defaultFactory.Store(NewSignalFactory(nil, nil))
+}
+
+// DefaultFactory returns the factory used by NewSignal and Signal.Derive.
+func DefaultFactory() *SignalFactory {
+	return defaultFactory.Load()
+}
+
+// SetDefaultFactory replaces the factory used by NewSignal and
+// Signal.Derive and returns the previous one, so tests can restore it.
+// A nil factory restores the system clock and DefaultIDGenerator.
+func SetDefaultFactory(f *SignalFactory) *SignalFactory {
+	if f == nil {
+		f = NewSignalFactory(nil, nil)
+	}
+	return defaultFactory.Swap(f)
+}
+
+type factoryKey struct{}
+
+// WithFactory attaches a factory to a context, as the Engine does for each
+// Process call. Test harnesses use it to hand agents their own factory.
+func WithFactory(ctx context.Context, f *SignalFactory) context.Context {
+	return context.WithValue(ctx, factoryKey{}, f)
+}
+
+// FactoryFrom returns the factory of the Engine running the current
+// Process call, or the default factory outside an Engine.
+func FactoryFrom(ctx context.Context) *SignalFactory {
+	if f, ok := ctx.Value(factoryKey{}).(*SignalFactory); ok {
+		return f
+	}
+	return DefaultFactory()
+}
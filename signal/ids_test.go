@@ -0,0 +1,169 @@
+package signal
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDefaultIDGeneratorIsUniqueUnderConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				id := NewSignal("t", nil).ID
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 4000 {
+		t.Errorf("Generated %d unique IDs, want 4000", len(seen))
+	}
+}
+
+func TestUUIDv7Generator(t *testing.T) {
+	ids := NewUUIDv7Generator()
+	id, err := uuid.Parse(ids.NewID())
+	if err != nil || id.Version() != 7 {
+		t.Errorf("NewID() = %v (err %v), want a version 7 UUID", id, err)
+	}
+}
+
+func TestULIDGenerator(t *testing.T) {
+	clock := NewManualClock(time.UnixMilli(1469918176385)) // Reference ULID timestamp
+	ids := NewULIDGenerator(clock)
+
+	first := ids.NewID()
+	if len(first) != 26 || !strings.HasPrefix(first, "01ARYZ6S41") {
+		t.Errorf("NewID() = %s, want 26 chars starting with the encoded timestamp 01ARYZ6S41", first)
+	}
+	if strings.Trim(first, crockford) != "" {
+		t.Errorf("NewID() = %s, want only Crockford base32 characters", first)
+	}
+
+	var generated []string
+	for i := 0; i < 5; i++ {
+		clock.Advance(time.Millisecond)
+		generated = append(generated, ids.NewID())
+	}
+	if !sort.StringsAreSorted(generated) {
+		t.Errorf("ULIDs %v should sort by creation time", generated)
+	}
+}
+
+func TestSequentialGenerator(t *testing.T) {
+	ids := NewSequentialGenerator("req")
+	if a, b := ids.NewID(), ids.NewID(); a != "req-1" || b != "req-2" {
+		t.Errorf("IDs = %s, %s, want req-1, req-2", a, b)
+	}
+	ids.Reset()
+	if id := ids.NewID(); id != "req-1" {
+		t.Errorf("NewID() after Reset = %s, want req-1", id)
+	}
+}
+
+func TestSetDefaultFactory(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	previous := SetDefaultFactory(NewSignalFactory(clock, NewSequentialGenerator("test")))
+	defer SetDefaultFactory(previous)
+
+	parent := NewSignal("request", nil).WithDestination("worker")
+	child := parent.Derive("result", nil)
+	if parent.ID != "test-1" || child.ID != "test-2" || !child.Timestamp.Equal(clock.Now()) {
+		t.Errorf("Signals = %+v, %+v, want sequential IDs and the manual clock's time", parent, child)
+	}
+	if child.ParentID != "test-1" || child.Source != "worker" {
+		t.Errorf("Derive() lineage = %+v", child)
+	}
+}
+
+func TestEngineFactory(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	ids := NewSequentialGenerator("eng")
+	router := NewRouter()
+	created := make(chan *Signal, 4)
+	router.Register(NewAgentFunc("agent", func(ctx context.Context, sig *Signal) AgentResult {
+		created <- FactoryFrom(ctx).Derive(sig, "out", nil)
+		return OK()
+	}))
+	config := DefaultConfig()
+	config.Clock = clock
+	config.IDs = ids
+	engine := NewEngine(config, router)
+	engine.Start()
+	defer engine.Stop()
+
+	engine.Submit(engine.Factory().New("in", nil).WithDestination("agent"))
+	if out := <-created; out.ID != "eng-2" || out.ParentID != "eng-1" {
+		t.Errorf("Derived = %+v, want IDs from the engine's generator", out)
+	}
+
+	engine.Schedule("@every 1m", NewSignal("tick", nil).WithDestination("agent"))
+	clock.Advance(time.Minute)
+	if out := <-created; out.ParentID != "eng-3" {
+		t.Errorf("Recurring firing ID = %s, want eng-3", out.ParentID)
+	}
+
+	if FactoryFrom(context.Background()) != DefaultFactory() {
+		t.Error("FactoryFrom() outside an engine should return the default factory")
+	}
+}
+
+func TestEngineTimesCallsByItsClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	router := NewRouter()
+	router.Register(NewAgentFunc("agent", func(ctx context.Context, sig *Signal) AgentResult {
+		clock.Advance(3 * time.Second)
+		return OK()
+	}))
+	config := DefaultConfig()
+	config.Clock = clock
+	engine := NewEngine(config, router)
+	calls := make(chan AgentCall, 1)
+	engine.OnAgentCall(func(call AgentCall) { calls <- call })
+	engine.Start()
+	defer engine.Stop()
+
+	engine.Submit(NewSignal("in", nil).WithDestination("agent"))
+	if call := <-calls; call.Duration != 3*time.Second {
+		t.Errorf("Duration = %v, want the 3s the engine's clock advanced", call.Duration)
+	}
+}
+
+func TestBuiltInAgentsUseEngineFactory(t *testing.T) {
+	router := NewRouter()
+	router.Register(NewJoin("join", JoinConfig{Key: func(*Signal) string { return "k" }}))
+	collected := make(chan *Signal, 1)
+	router.Register(NewAgentFunc("sink", func(ctx context.Context, sig *Signal) AgentResult {
+		collected <- sig
+		return OK()
+	}))
+	router.AddRule(func(sig *Signal) []string {
+		if sig.Type == SignalJoinResult {
+			return []string{"sink"}
+		}
+		return []string{"join"}
+	})
+	config := DefaultConfig()
+	config.IDs = NewSequentialGenerator("eng")
+	engine := NewEngine(config, router)
+	engine.Start()
+	defer engine.Stop()
+
+	engine.Submit(engine.Factory().New("part", nil))
+	if out := <-collected; out.ID != "eng-2" {
+		t.Errorf("Join output ID = %s, want eng-2 from the engine's generator", out.ID)
+	}
+}
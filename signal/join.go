@@ -0,0 +1,378 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// JOIN: Scatter-Gather Aggregation
+// =============================================================================
+
+// SignalJoinResult is the default type of the aggregated signal a Join emits.
+const SignalJoinResult SignalType = "join_result"
+
+// Metadata keys understood by Join.
+const (
+	// MetaJoinKey is the default correlation key, and is set on emitted signals.
+	MetaJoinKey = "join_key"
+	// MetaJoinExpected lets a splitter announce the group size in-band,
+	// e.g. sig.WithMetadata(MetaJoinExpected, "3") on every fanout signal.
+	MetaJoinExpected = "join_expected"
+)
+
+// JoinPolicy decides when a join group is complete.
+type JoinPolicy int
+
+const (
+	// JoinAll completes when every expected signal has arrived.
+	JoinAll JoinPolicy = iota
+	// JoinQuorum completes when Quorum signals (k of n) have arrived.
+	JoinQuorum
+	// JoinFirst completes on the first signal; later ones are discarded.
+	JoinFirst
+	// JoinDeadline completes when every expected signal has arrived or when
+	// Deadline elapses after the first one, emitting partial results.
+	JoinDeadline
+)
+
+// JoinConfig configures a Join agent.
+type JoinConfig struct {
+	// Key extracts the correlation key from a signal.
+	// Defaults to the MetaJoinKey metadata value.
+	Key func(signal *Signal) string
+
+	// Expected is the group size used when none is announced, either in-band
+	// (MetaJoinExpected) or through Join.Expect. Defaults to 1.
+	Expected int
+
+	// Policy selects the completion rule. Defaults to JoinAll.
+	Policy JoinPolicy
+
+	// Quorum is the number of signals required by JoinQuorum.
+	// 0 means a simple majority of the expected count.
+	Quorum int
+
+	// Deadline is how long JoinDeadline waits after the first signal.
+	Deadline time.Duration
+
+	// TTL bounds how long an incomplete group is kept before it is discarded.
+	// It also bounds how long completed keys are remembered to discard late
+	// arrivals. Defaults to 5 minutes.
+	TTL time.Duration
+
+	// OutputType is the type of the aggregated signal. Defaults to SignalJoinResult.
+	OutputType SignalType
+
+	// OnExpired, if set, is called with the signals of each discarded group.
+	OnExpired func(key string, signals []*Signal)
+
+	// Emit, if set, receives the aggregated signal of a group completed by
+	// Join.Expect rather than by Process, typically to submit it to the
+	// engine. Without it, such a signal is emitted with the outputs of the
+	// join's next Process call.
+	Emit func(signal *Signal)
+
+	// Clock drives TTL and Deadline. Defaults to the system clock.
+	Clock Clock
+}
+
+// JoinResult is the payload of the aggregated signal.
+type JoinResult struct {
+	Key      string    // Correlation key of the group
+	Signals  []*Signal // Collected signals in arrival order
+	Expected int       // Expected group size
+	Partial  bool      // True when emitted before all expected signals arrived
+}
+
+// JoinByMetadata returns a key extractor reading the given metadata key.
+func JoinByMetadata(key string) func(signal *Signal) string {
+	return func(signal *Signal) string {
+		return signal.Metadata[key]
+	}
+}
+
+// joinGroup holds the signals collected for one correlation key.
+type joinGroup struct {
+	key      string
+	expected int
+	signals  []*Signal
+	created  time.Time
+	complete func(AgentResult) // Deferred emission for JoinDeadline
+	timer    Timer
+	factory  *SignalFactory // Of the engine that delivered the first signal
+}
+
+// Join is an agent that collects signals sharing a correlation key and emits
+// one aggregated signal (with a *JoinResult payload) per group, according to
+// its completion policy. It replaces hand-written collectors in fan-in agents.
+// Join is safe for concurrent use.
+type Join struct {
+	id     string
+	config JoinConfig
+
+	mu     sync.Mutex
+	groups map[string]*joinGroup
+	done   map[string]time.Time // Completed keys, remembered until TTL
+	ready  []*Signal            // Completed by Expect, awaiting the next Process
+}
+
+// NewJoin creates a join agent with the given ID and configuration.
+func NewJoin(id string, config JoinConfig) *Join {
+	if config.Key == nil {
+		config.Key = JoinByMetadata(MetaJoinKey)
+	}
+	if config.Expected <= 0 {
+		config.Expected = 1
+	}
+	if config.TTL <= 0 {
+		config.TTL = 5 * time.Minute
+	}
+	if config.OutputType == "" {
+		config.OutputType = SignalJoinResult
+	}
+	if config.Clock == nil {
+		config.Clock = SystemClock()
+	}
+	return &Join{
+		id:     id,
+		config: config,
+		groups: make(map[string]*joinGroup),
+		done:   make(map[string]time.Time),
+	}
+}
+
+// ID returns the agent's unique identifier.
+func (j *Join) ID() string {
+	return j.id
+}
+
+// Expect announces the group size for a key out-of-band.
+// It may be called before or after the first signal of the group arrives;
+// it is ignored once the group has completed. If the signals already
+// collected complete the group, its aggregated signal is emitted at once:
+// through the deferred call holding a JoinDeadline group open, or else
+// through JoinConfig.Emit.
+func (j *Join) Expect(key string, n int) {
+	now := j.config.Clock.Now()
+	j.mu.Lock()
+	if _, completed := j.done[key]; completed {
+		j.mu.Unlock()
+		return // Late announcement; the group would only leak until TTL
+	}
+	g := j.group(key, now)
+	g.expected = n
+	if len(g.signals) == 0 || !j.isComplete(g) {
+		j.mu.Unlock()
+		return
+	}
+	j.finish(g, now)
+	out := j.aggregate(g, g.signals[len(g.signals)-1], false)
+	switch {
+	case g.complete != nil:
+		j.mu.Unlock()
+		g.complete(OK(out))
+	case j.config.Emit != nil:
+		j.mu.Unlock()
+		j.config.Emit(out)
+	default:
+		j.ready = append(j.ready, out)
+		j.mu.Unlock()
+	}
+}
+
+// Pending returns the number of incomplete groups.
+// Groups completed by Expect and awaiting the next Process are not counted.
+func (j *Join) Pending() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.groups)
+}
+
+// Process adds a signal to its group and emits the aggregated signal once
+// the group is complete. Signals for already completed keys are discarded,
+// as are repeated deliveries of a signal (same ID) already in its group.
+func (j *Join) Process(ctx context.Context, signal *Signal) AgentResult {
+	key := j.config.Key(signal)
+	if key == "" {
+		return Err(fmt.Errorf("join '%s': no correlation key in signal %s", j.id, truncateID(signal.ID)))
+	}
+
+	now := j.config.Clock.Now()
+	j.mu.Lock()
+	outputs, expired := j.sweep(now)
+	outputs = append(j.ready, outputs...)
+	j.ready = nil
+
+	if _, completed := j.done[key]; completed {
+		j.mu.Unlock()
+		j.notifyExpired(expired)
+		return OK(outputs...)
+	}
+
+	g := j.group(key, now)
+	if slices.ContainsFunc(g.signals, func(s *Signal) bool { return s.ID == signal.ID }) {
+		j.mu.Unlock()
+		j.notifyExpired(expired)
+		return OK(outputs...)
+	}
+	if n, err := strconv.Atoi(signal.Metadata[MetaJoinExpected]); err == nil && n > 0 {
+		g.expected = n
+	}
+	if j.config.Policy == JoinDeadline && len(g.signals) == 0 && g.complete == nil {
+		// The first signal holds the group open until the deadline.
+		// Without an engine, the deadline is enforced lazily by sweep.
+		if complete, ok := Defer(ctx); ok {
+			g.complete = complete
+			g.timer = j.config.Clock.AfterFunc(j.config.Deadline, func() { j.expireDeadline(g) })
+		}
+	}
+	if len(g.signals) == 0 {
+		g.created = now // Groups announced by Expect start with their first signal
+		g.factory = FactoryFrom(ctx)
+	}
+	g.signals = append(g.signals, signal)
+
+	if !j.isComplete(g) {
+		j.mu.Unlock()
+		j.notifyExpired(expired)
+		return OK(outputs...)
+	}
+
+	j.finish(g, now)
+	j.mu.Unlock()
+	j.notifyExpired(expired)
+
+	out := j.aggregate(g, signal, false)
+	if g.complete != nil {
+		// Hand the output to the deferred call holding the group open
+		g.complete(OK(out))
+		return OK(outputs...)
+	}
+	return OK(append(outputs, out)...)
+}
+
+// Close emits partial results for groups held open by a deadline,
+// so deferred calls complete before the engine stops.
+func (j *Join) Close(ctx context.Context) error {
+	j.mu.Lock()
+	var held []*joinGroup
+	for _, g := range j.groups {
+		if g.complete != nil {
+			held = append(held, g)
+		}
+	}
+	for _, g := range held {
+		j.finish(g, j.config.Clock.Now())
+	}
+	j.mu.Unlock()
+
+	for _, g := range held {
+		g.complete(OK(j.aggregate(g, g.signals[0], true)))
+	}
+	return nil
+}
+
+// group returns the group for a key, creating it if needed.
+// Caller must hold j.mu.
+func (j *Join) group(key string, now time.Time) *joinGroup {
+	g, exists := j.groups[key]
+	if !exists {
+		g = &joinGroup{key: key, expected: j.config.Expected, created: now}
+		j.groups[key] = g
+	}
+	return g
+}
+
+// isComplete applies the completion policy to a group.
+func (j *Join) isComplete(g *joinGroup) bool {
+	switch j.config.Policy {
+	case JoinFirst:
+		return len(g.signals) >= 1
+	case JoinQuorum:
+		quorum := j.config.Quorum
+		if quorum <= 0 {
+			quorum = g.expected/2 + 1
+		}
+		if quorum > g.expected {
+			quorum = g.expected
+		}
+		return len(g.signals) >= quorum
+	default:
+		return len(g.signals) >= g.expected
+	}
+}
+
+// finish removes a group and remembers its key so late arrivals are discarded.
+// Caller must hold j.mu.
+func (j *Join) finish(g *joinGroup, now time.Time) {
+	delete(j.groups, g.key)
+	j.done[g.key] = now
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+}
+
+// aggregate builds the aggregated signal for a group, derived from trigger.
+func (j *Join) aggregate(g *joinGroup, trigger *Signal, partial bool) *Signal {
+	signals := make([]*Signal, len(g.signals))
+	copy(signals, g.signals)
+	result := &JoinResult{
+		Key:      g.key,
+		Signals:  signals,
+		Expected: g.expected,
+		Partial:  partial,
+	}
+	return g.factory.Derive(trigger, j.config.OutputType, result).WithMetadata(MetaJoinKey, g.key)
+}
+
+// expireDeadline emits the partial result of a deferred group whose deadline passed.
+func (j *Join) expireDeadline(g *joinGroup) {
+	j.mu.Lock()
+	if j.groups[g.key] != g {
+		j.mu.Unlock()
+		return
+	}
+	j.finish(g, j.config.Clock.Now())
+	j.mu.Unlock()
+
+	g.complete(OK(j.aggregate(g, g.signals[0], true)))
+}
+
+// sweep discards groups older than TTL and forgets old completed keys.
+// Deadline groups without a deferred call are emitted lazily here.
+// Caller must hold j.mu.
+func (j *Join) sweep(now time.Time) (outputs []*Signal, expired []*joinGroup) {
+	for key, at := range j.done {
+		if now.Sub(at) >= j.config.TTL {
+			delete(j.done, key)
+		}
+	}
+	for _, g := range j.groups {
+		if j.config.Policy == JoinDeadline && g.complete == nil &&
+			len(g.signals) > 0 && now.Sub(g.created) >= j.config.Deadline {
+			j.finish(g, now)
+			outputs = append(outputs, j.aggregate(g, g.signals[0], true))
+			continue
+		}
+		if g.complete == nil && now.Sub(g.created) >= j.config.TTL {
+			delete(j.groups, g.key)
+			expired = append(expired, g)
+		}
+	}
+	return outputs, expired
+}
+
+// notifyExpired reports discarded groups. Called without holding j.mu.
+func (j *Join) notifyExpired(expired []*joinGroup) {
+	if j.config.OnExpired == nil {
+		return
+	}
+	for _, g := range expired {
+		j.config.OnExpired(g.key, g.signals)
+	}
+}
@@ -0,0 +1,259 @@
+package signal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func joinMember(key string, expected string) *Signal {
+	sig := NewSignal("part", nil).WithMetadata(MetaJoinKey, key)
+	if expected != "" {
+		sig = sig.WithMetadata(MetaJoinExpected, expected)
+	}
+	return sig
+}
+
+func joinResultOf(t *testing.T, result AgentResult) *JoinResult {
+	t.Helper()
+	if result.Error != nil {
+		t.Fatalf("Process() error = %v", result.Error)
+	}
+	if len(result.Signals) != 1 {
+		t.Fatalf("Process() returned %d signals, want 1", len(result.Signals))
+	}
+	jr, ok := result.Signals[0].Payload.(*JoinResult)
+	if !ok {
+		t.Fatalf("Payload = %T, want *JoinResult", result.Signals[0].Payload)
+	}
+	return jr
+}
+
+func TestJoinAllInBandExpected(t *testing.T) {
+	join := NewJoin("join", JoinConfig{})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if result := join.Process(ctx, joinMember("t1", "3")); len(result.Signals) != 0 {
+			t.Fatalf("Group emitted early after %d signals", i+1)
+		}
+	}
+	jr := joinResultOf(t, join.Process(ctx, joinMember("t1", "3")))
+
+	if jr.Key != "t1" || len(jr.Signals) != 3 || jr.Partial {
+		t.Errorf("JoinResult = %+v, want complete group t1 of 3", jr)
+	}
+	if join.Pending() != 0 {
+		t.Errorf("Pending = %d, want 0", join.Pending())
+	}
+}
+
+func TestJoinExpectAnnouncement(t *testing.T) {
+	join := NewJoin("join", JoinConfig{})
+	join.Expect("t1", 2)
+	ctx := context.Background()
+
+	if result := join.Process(ctx, joinMember("t1", "")); len(result.Signals) != 0 {
+		t.Fatal("Group emitted before the announced count")
+	}
+	if jr := joinResultOf(t, join.Process(ctx, joinMember("t1", ""))); len(jr.Signals) != 2 {
+		t.Errorf("Signals = %d, want 2", len(jr.Signals))
+	}
+}
+
+func TestJoinExpectAfterSignals(t *testing.T) {
+	var emitted []*Signal
+	join := NewJoin("join", JoinConfig{Expected: 5, Emit: func(sig *Signal) { emitted = append(emitted, sig) }})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if result := join.Process(ctx, joinMember("t1", "")); len(result.Signals) != 0 {
+			t.Fatalf("Group emitted early after %d signals", i+1)
+		}
+	}
+	join.Expect("t1", 2)
+
+	if len(emitted) != 1 {
+		t.Fatalf("Emitted %d signals, want the aggregate of t1", len(emitted))
+	}
+	jr := joinResultOf(t, OK(emitted...))
+	if jr.Key != "t1" || len(jr.Signals) != 2 || jr.Expected != 2 || jr.Partial {
+		t.Errorf("JoinResult = %+v, want complete group t1 of 2", jr)
+	}
+	if join.Pending() != 0 {
+		t.Errorf("Pending = %d, want 0", join.Pending())
+	}
+}
+
+func TestJoinExpectAfterSignalsWithoutEmit(t *testing.T) {
+	join := NewJoin("join", JoinConfig{Expected: 5})
+	ctx := context.Background()
+	join.Process(ctx, joinMember("t1", ""))
+	join.Expect("t1", 1)
+
+	// The aggregate leaves with the outputs of the next call
+	jr := joinResultOf(t, join.Process(ctx, joinMember("t2", "")))
+	if jr.Key != "t1" || len(jr.Signals) != 1 {
+		t.Errorf("JoinResult = %+v, want complete group t1 of 1", jr)
+	}
+}
+
+func TestJoinIgnoresRepeatedDeliveries(t *testing.T) {
+	join := NewJoin("join", JoinConfig{})
+	ctx := context.Background()
+	first := joinMember("t1", "2")
+
+	join.Process(ctx, first)
+	if result := join.Process(ctx, first); len(result.Signals) != 0 {
+		t.Fatal("A repeated delivery should not complete the group")
+	}
+	if jr := joinResultOf(t, join.Process(ctx, joinMember("t1", "2"))); len(jr.Signals) != 2 || jr.Signals[0] != first {
+		t.Errorf("JoinResult = %+v, want the two distinct signals", jr)
+	}
+}
+
+func TestJoinQuorumAndFirst(t *testing.T) {
+	tests := []struct {
+		name   string
+		config JoinConfig
+		want   int // Signals needed before emission
+	}{
+		{"quorum majority", JoinConfig{Policy: JoinQuorum, Expected: 3}, 2},
+		{"quorum explicit", JoinConfig{Policy: JoinQuorum, Expected: 5, Quorum: 4}, 4},
+		{"first", JoinConfig{Policy: JoinFirst, Expected: 3}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			join := NewJoin("join", tt.config)
+			ctx := context.Background()
+
+			for i := 1; i < tt.want; i++ {
+				if result := join.Process(ctx, joinMember("k", "")); len(result.Signals) != 0 {
+					t.Fatalf("Group emitted after %d signals", i)
+				}
+			}
+			if jr := joinResultOf(t, join.Process(ctx, joinMember("k", ""))); len(jr.Signals) != tt.want {
+				t.Errorf("Signals = %d, want %d", len(jr.Signals), tt.want)
+			}
+
+			// Late arrivals for a completed key are discarded
+			if result := join.Process(ctx, joinMember("k", "")); len(result.Signals) != 0 {
+				t.Error("Late arrival should not emit again")
+			}
+		})
+	}
+}
+
+func TestJoinMissingKey(t *testing.T) {
+	join := NewJoin("join", JoinConfig{})
+	if result := join.Process(context.Background(), NewSignal("part", nil)); result.Error == nil {
+		t.Error("Process() should fail without a correlation key")
+	}
+}
+
+func TestJoinExpiredGroupsAreDiscarded(t *testing.T) {
+	var mu sync.Mutex
+	var expiredKeys []string
+
+	clock := NewManualClock(time.Now())
+	join := NewJoin("join", JoinConfig{
+		Expected: 2,
+		TTL:      10 * time.Millisecond,
+		Clock:    clock,
+		OnExpired: func(key string, signals []*Signal) {
+			mu.Lock()
+			expiredKeys = append(expiredKeys, key)
+			mu.Unlock()
+		},
+	})
+	ctx := context.Background()
+
+	join.Process(ctx, joinMember("stale", ""))
+	clock.Advance(20 * time.Millisecond)
+	join.Process(ctx, joinMember("fresh", ""))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(expiredKeys) != 1 || expiredKeys[0] != "stale" {
+		t.Errorf("Expired = %v, want [stale]", expiredKeys)
+	}
+	if join.Pending() != 1 {
+		t.Errorf("Pending = %d, want 1", join.Pending())
+	}
+}
+
+func TestJoinExpectAfterCompletion(t *testing.T) {
+	join := NewJoin("join", JoinConfig{})
+	joinResultOf(t, join.Process(context.Background(), joinMember("t1", "")))
+
+	join.Expect("t1", 2)
+	if join.Pending() != 0 {
+		t.Errorf("Pending = %d, want 0: a completed key should not reopen", join.Pending())
+	}
+}
+
+func TestJoinDeadlineOnClock(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	join := NewJoin("join", JoinConfig{Policy: JoinDeadline, Expected: 3, Deadline: time.Minute, Clock: clock})
+	ctx := context.Background()
+
+	join.Process(ctx, joinMember("t1", ""))
+	clock.Advance(time.Minute)
+	// Without an engine the deadline is enforced by the next call
+	result := join.Process(ctx, joinMember("t2", ""))
+	if jr := joinResultOf(t, result); jr.Key != "t1" || !jr.Partial || len(jr.Signals) != 1 {
+		t.Errorf("JoinResult = %+v, want partial t1", jr)
+	}
+}
+
+func TestJoinDeadlineEmitsPartialInEngine(t *testing.T) {
+	results := make(chan *JoinResult, 1)
+
+	router := NewRouter()
+	router.Register(NewJoin("join", JoinConfig{
+		Policy:   JoinDeadline,
+		Expected: 3,
+		Deadline: 30 * time.Millisecond,
+	}))
+	router.Register(NewAgentFunc("sink", func(ctx context.Context, sig *Signal) AgentResult {
+		results <- sig.Payload.(*JoinResult)
+		return OK()
+	}))
+	router.AddRule(func(sig *Signal) []string {
+		if sig.Type == SignalJoinResult {
+			return []string{"sink"}
+		}
+		return []string{"join"}
+	})
+
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+	defer engine.Stop()
+
+	engine.Submit(joinMember("t1", ""))
+	engine.Submit(joinMember("t1", ""))
+
+	select {
+	case jr := <-results:
+		if !jr.Partial || len(jr.Signals) != 2 {
+			t.Errorf("JoinResult = %+v, want partial group of 2", jr)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for partial join result")
+	}
+}
+
+func TestCallWaitsForDeferredJoin(t *testing.T) {
+	join := NewJoin("join", JoinConfig{
+		Policy:   JoinDeadline,
+		Expected: 2,
+		Deadline: 20 * time.Millisecond,
+	})
+
+	jr := joinResultOf(t, Call(context.Background(), join, joinMember("t1", "")))
+	if !jr.Partial || len(jr.Signals) != 1 {
+		t.Errorf("JoinResult = %+v, want partial group of 1", jr)
+	}
+}
@@ -0,0 +1,243 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// =============================================================================
+// LIFECYCLE INTERFACES: Optional Agent Capabilities
+// =============================================================================
+
+// Initializer is an optional interface for agents that need to acquire
+// resources (connections, files, caches) before processing signals.
+// The Engine calls Init when it starts, or when the agent is registered
+// through Engine.Register while the engine is running.
+type Initializer interface {
+	Init(ctx context.Context) error
+}
+
+// Closer is an optional interface for agents that need to release or flush
+// resources. The Engine calls Close when it stops, or when the agent is
+// removed through Engine.Unregister. Close is only called on agents that
+// were successfully initialized by the Engine.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// Flusher is an optional interface for agents that buffer signals (such as
+// BatchAgent). The Engine calls Flush when it stops, before its workers
+// exit, so buffered work is processed and its outputs are still routed.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// HealthChecker is an optional interface for agents that can report their
+// own health (e.g. whether a backing service is reachable).
+// A nil error means the agent is healthy.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// HealthReport aggregates the health of every registered agent.
+type HealthReport struct {
+	Running bool             // Whether the engine is running
+	Healthy bool             // True when running and every agent is healthy
+	Agents  map[string]error // Per-agent status; nil means healthy
+}
+
+// Err returns all agent failures joined into one error, or nil if healthy.
+func (r HealthReport) Err() error {
+	var errs []error
+	if !r.Running {
+		errs = append(errs, ErrNotRunning)
+	}
+	for id, err := range r.Agents {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("agent '%s': %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// =============================================================================
+// ENGINE LIFECYCLE INTEGRATION
+// =============================================================================
+
+// Register adds an agent to the engine's router.
+// If the engine is running, the agent is initialized before it becomes
+// routable; if Init fails the agent is not registered.
+// Replacing an agent with the same ID goes through ReplaceAgent: calls in
+// flight on the previous instance finish before it is closed.
+func (e *Engine) Register(agent Agent) error {
+	e.lifecycle.Lock()
+	defer e.lifecycle.Unlock()
+
+	e.mu.Lock()
+	previous, hadPrevious := e.initialized[agent.ID()]
+	e.mu.Unlock()
+
+	if hadPrevious && sameAgent(previous, agent) {
+		// Re-registering the same instance: already initialized
+		e.router.Register(agent)
+		return nil
+	}
+
+	_, err := e.replaceAgent(agent)
+	return err
+}
+
+// Unregister removes an agent from the engine's router and closes it
+// if it was initialized by the engine. Signals already routed to the agent
+// are still processed; Close waits for them (see RemoveAgent), and
+// Unregister does not.
+func (e *Engine) Unregister(agentID string) error {
+	_, err := e.RemoveAgent(agentID, false)
+	return err
+}
+
+// Health checks every registered agent implementing HealthChecker.
+// Agents without a health check are reported healthy.
+func (e *Engine) Health(ctx context.Context) HealthReport {
+	report := HealthReport{
+		Running: e.IsRunning(),
+		Agents:  make(map[string]error),
+	}
+	report.Healthy = report.Running
+
+	for _, id := range e.router.ListAgents() {
+		agent, exists := e.router.GetAgent(id)
+		if !exists {
+			continue
+		}
+		var err error
+		if checker, ok := agent.(HealthChecker); ok {
+			err = checker.Health(ctx)
+		}
+		report.Agents[id] = err
+		if err != nil {
+			report.Healthy = false
+		}
+	}
+	return report
+}
+
+// initAgents initializes every registered agent that has not been
+// initialized yet. On failure, agents initialized by this call are closed
+// again so the engine is left in its previous state.
+func (e *Engine) initAgents() error {
+	var started []string
+	for _, id := range e.router.ListAgents() {
+		agent, exists := e.router.GetAgent(id)
+		if !exists {
+			continue
+		}
+		e.mu.Lock()
+		_, done := e.initialized[id]
+		e.mu.Unlock()
+		if done {
+			continue
+		}
+
+		if err := e.initAgent(agent); err != nil {
+			e.mu.Lock()
+			rollback := make([]Agent, 0, len(started))
+			for _, sid := range started {
+				rollback = append(rollback, e.initialized[sid])
+				delete(e.initialized, sid)
+			}
+			e.mu.Unlock()
+			for _, a := range rollback {
+				_ = e.closeAgent(a)
+			}
+			return err
+		}
+
+		e.mu.Lock()
+		e.initialized[id] = agent
+		e.mu.Unlock()
+		started = append(started, id)
+	}
+	return nil
+}
+
+// flushAgents flushes every registered agent implementing Flusher,
+// bounded by the configured ProcessTimeout.
+func (e *Engine) flushAgents() error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.ProcessTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, id := range e.router.ListAgents() {
+		agent, exists := e.router.GetAgent(id)
+		if !exists {
+			continue
+		}
+		if flusher, ok := agent.(Flusher); ok {
+			if err := flusher.Flush(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("flush agent '%s': %w", id, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeAgents closes every agent initialized by the engine.
+func (e *Engine) closeAgents() error {
+	e.mu.Lock()
+	agents := make([]Agent, 0, len(e.initialized))
+	for id, agent := range e.initialized {
+		agents = append(agents, agent)
+		delete(e.initialized, id)
+	}
+	e.mu.Unlock()
+
+	var errs []error
+	for _, agent := range agents {
+		if err := e.closeAgent(agent); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// initAgent calls Init on an agent if it implements Initializer.
+// Init is bounded by the configured ProcessTimeout.
+func (e *Engine) initAgent(agent Agent) error {
+	init, ok := agent.(Initializer)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.ProcessTimeout)
+	defer cancel()
+	if err := init.Init(ctx); err != nil {
+		return fmt.Errorf("init agent '%s': %w", agent.ID(), err)
+	}
+	return nil
+}
+
+// closeAgent calls Close on an agent if it implements Closer.
+// Close is bounded by the configured ProcessTimeout.
+func (e *Engine) closeAgent(agent Agent) error {
+	closer, ok := agent.(Closer)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.ProcessTimeout)
+	defer cancel()
+	if err := closer.Close(ctx); err != nil {
+		return fmt.Errorf("close agent '%s': %w", agent.ID(), err)
+	}
+	return nil
+}
+
+// sameAgent reports whether a and b are the same agent instance.
+// Agents of non-comparable types are never considered the same.
+func sameAgent(a, b Agent) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
@@ -0,0 +1,133 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// lifecycleAgent records Init/Close calls and reports a configurable health.
+type lifecycleAgent struct {
+	mockAgent
+	initErr   error
+	healthErr error
+	inits     atomic.Int32
+	closes    atomic.Int32
+}
+
+func (a *lifecycleAgent) Init(ctx context.Context) error {
+	a.inits.Add(1)
+	return a.initErr
+}
+
+func (a *lifecycleAgent) Close(ctx context.Context) error {
+	a.closes.Add(1)
+	return nil
+}
+
+func (a *lifecycleAgent) Health(ctx context.Context) error {
+	return a.healthErr
+}
+
+func TestEngineStartStopCallsInitAndClose(t *testing.T) {
+	agent := &lifecycleAgent{mockAgent: mockAgent{id: "res"}}
+	router := NewRouter()
+	router.Register(agent)
+	engine := NewEngine(DefaultConfig(), router)
+
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if agent.inits.Load() != 1 {
+		t.Errorf("Init calls = %d, want 1", agent.inits.Load())
+	}
+
+	if err := engine.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if agent.closes.Load() != 1 {
+		t.Errorf("Close calls = %d, want 1", agent.closes.Load())
+	}
+}
+
+func TestEngineStartFailsWhenInitFails(t *testing.T) {
+	router := NewRouter()
+	router.Register(&lifecycleAgent{mockAgent: mockAgent{id: "bad"}, initErr: errors.New("boom")})
+	engine := NewEngine(DefaultConfig(), router)
+
+	if err := engine.Start(); err == nil {
+		t.Fatal("Start() should fail when an agent fails to initialize")
+	}
+	if engine.IsRunning() {
+		t.Error("Engine should not be running after failed Start")
+	}
+}
+
+func TestEngineRegisterWhileRunning(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), NewRouter())
+	engine.Start()
+	defer engine.Stop()
+
+	first := &lifecycleAgent{mockAgent: mockAgent{id: "worker"}}
+	if err := engine.Register(first); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if first.inits.Load() != 1 {
+		t.Errorf("Init calls = %d, want 1", first.inits.Load())
+	}
+
+	second := &lifecycleAgent{mockAgent: mockAgent{id: "worker"}}
+	if err := engine.Register(second); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if first.closes.Load() != 1 {
+		t.Errorf("Replaced agent Close calls = %d, want 1", first.closes.Load())
+	}
+
+	if err := engine.Unregister("worker"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+	if second.closes.Load() != 1 {
+		t.Errorf("Unregistered agent Close calls = %d, want 1", second.closes.Load())
+	}
+
+	failing := &lifecycleAgent{mockAgent: mockAgent{id: "failing"}, initErr: errors.New("boom")}
+	if err := engine.Register(failing); err == nil {
+		t.Error("Register() should fail when Init fails")
+	}
+	if _, ok := engine.Router().GetAgent("failing"); ok {
+		t.Error("Agent that failed Init should not be registered")
+	}
+}
+
+func TestEngineHealth(t *testing.T) {
+	router := NewRouter()
+	router.Register(&lifecycleAgent{mockAgent: mockAgent{id: "ok"}})
+	router.Register(&mockAgent{id: "plain"})
+	sick := &lifecycleAgent{mockAgent: mockAgent{id: "sick"}, healthErr: errors.New("unreachable")}
+	router.Register(sick)
+
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+	defer engine.Stop()
+
+	report := engine.Health(context.Background())
+	if report.Healthy {
+		t.Error("Report should be unhealthy when an agent is unhealthy")
+	}
+	if report.Agents["ok"] != nil || report.Agents["plain"] != nil {
+		t.Errorf("Healthy agents reported errors: %v", report.Agents)
+	}
+	if report.Agents["sick"] == nil {
+		t.Error("Unhealthy agent should report an error")
+	}
+	if report.Err() == nil {
+		t.Error("Err() should return the aggregated failure")
+	}
+
+	sick.healthErr = nil
+	if report := engine.Health(context.Background()); !report.Healthy {
+		t.Errorf("Report should be healthy, got %v", report.Err())
+	}
+}
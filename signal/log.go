@@ -0,0 +1,237 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+)
+
+// =============================================================================
+// TRACES: Causal Chains of Signals
+// =============================================================================
+
+// MetaTraceID is the metadata key holding a signal's trace ID: the ID of the
+// signal that started its causal chain. The Engine stamps it on agent
+// outputs that lack it, and Derive copies it to children, so every signal
+// descending from one submission shares the trace.
+const MetaTraceID = "trace_id"
+
+// TraceID returns the trace a signal belongs to: its MetaTraceID metadata,
+// or its own ID for a signal that starts a trace.
+func TraceID(signal *Signal) string {
+	if trace := signal.Metadata[MetaTraceID]; trace != "" {
+		return trace
+	}
+	return signal.ID
+}
+
+// =============================================================================
+// LOGGING: Structured Engine Events
+// =============================================================================
+
+// Log attribute keys used by the Engine and by LoggerFrom.
+const (
+	LogKeySignalID   = "signal.id"
+	LogKeySignalType = "signal.type"
+	LogKeyAgent      = "agent"
+	LogKeyTrace      = "trace"
+	LogKeyDuration   = "duration"
+	LogKeyError      = "error"
+)
+
+// LogConfig tunes the events an Engine logs to EngineConfig.Logger:
+//
+//	"signal received"   a worker dequeued a signal            (Received)
+//	"signal processed"  an agent call succeeded               (Processed)
+//	"signal failed"     an agent call returned an error       (Failed)
+//	"signal terminal"   a signal was routed to no agent       (Terminal)
+//	"engine error"      routing, holding or submission failed (Failed)
+//	"policy violation"  an audit-only policy violation        (Warn)
+type LogConfig struct {
+	// Received is the level of "signal received". Defaults to Debug.
+	Received slog.Leveler
+
+	// Processed is the level of "signal processed". Defaults to Debug.
+	Processed slog.Leveler
+
+	// Failed is the level of "signal failed" and "engine error".
+	// Defaults to Error.
+	Failed slog.Leveler
+
+	// Terminal is the level of "signal terminal": a signal no agent is
+	// routed to, usually the final output of a flow. Defaults to Debug.
+	Terminal slog.Leveler
+
+	// Sample logs only every Nth received, processed and terminal event of
+	// a signal type, for high-volume types. Types not listed (or N <= 1) are
+	// logged every time. Failures are never sampled.
+	Sample map[SignalType]int
+}
+
+// engineLog emits an Engine's log events.
+type engineLog struct {
+	logger *slog.Logger
+	config LogConfig
+	counts map[SignalType]*atomic.Uint64 // Per sampled type; fixed after creation
+}
+
+func newEngineLog(logger *slog.Logger, config LogConfig) *engineLog {
+	if config.Received == nil {
+		config.Received = slog.LevelDebug
+	}
+	if config.Processed == nil {
+		config.Processed = slog.LevelDebug
+	}
+	if config.Failed == nil {
+		config.Failed = slog.LevelError
+	}
+	if config.Terminal == nil {
+		config.Terminal = slog.LevelDebug
+	}
+	counts := make(map[SignalType]*atomic.Uint64)
+	for t, n := range config.Sample {
+		if n > 1 {
+			counts[t] = new(atomic.Uint64)
+		}
+	}
+	return &engineLog{logger: logger, config: config, counts: counts}
+}
+
+// sampled reports whether this event of the signal's type is logged.
+func (l *engineLog) sampled(t SignalType) bool {
+	count, ok := l.counts[t]
+	if !ok {
+		return true
+	}
+	return (count.Add(1)-1)%uint64(l.config.Sample[t]) == 0
+}
+
+// received logs a dequeued signal.
+func (l *engineLog) received(signal *Signal) {
+	level := l.config.Received.Level()
+	if !l.logger.Enabled(context.Background(), level) || !l.sampled(signal.Type) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), level, "signal received",
+		append(signalAttrs(signal, ""), slog.String("source", signal.Source))...)
+}
+
+// call logs a completed agent call.
+func (l *engineLog) call(call AgentCall) {
+	attrs := append(signalAttrs(call.Signal, call.Agent), slog.Duration(LogKeyDuration, call.Duration))
+	if call.Result.Error != nil {
+		level := l.config.Failed.Level()
+		l.logger.LogAttrs(context.Background(), level, "signal failed",
+			append(attrs, slog.String(LogKeyError, call.Result.Error.Error()))...)
+		return
+	}
+	level := l.config.Processed.Level()
+	if !l.logger.Enabled(context.Background(), level) || !l.sampled(call.Signal.Type) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), level, "signal processed",
+		append(attrs, slog.Int("outputs", len(call.Result.Signals)))...)
+}
+
+// terminal logs a signal routed to no agent.
+func (l *engineLog) terminal(signal *Signal) {
+	level := l.config.Terminal.Level()
+	if !l.logger.Enabled(context.Background(), level) || !l.sampled(signal.Type) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), level, "signal terminal",
+		append(signalAttrs(signal, ""), slog.String("source", signal.Source))...)
+}
+
+// error logs an engine error concerning a signal and, if known, an agent.
+func (l *engineLog) error(signal *Signal, agent string, err error) {
+	l.logger.LogAttrs(context.Background(), l.config.Failed.Level(), "engine error",
+		append(signalAttrs(signal, agent), slog.String(LogKeyError, err.Error()))...)
+}
+
+// agentError logs an error of an agent outside of a call, such as a Close
+// postponed until its calls finished.
+func (l *engineLog) agentError(agent string, err error) {
+	l.logger.LogAttrs(context.Background(), l.config.Failed.Level(), "agent error",
+		slog.String(LogKeyAgent, agent), slog.String(LogKeyError, err.Error()))
+}
+
+// violation logs a policy violation that was not enforced.
+func (l *engineLog) violation(signal *Signal, agent string, err error) {
+	l.logger.LogAttrs(context.Background(), slog.LevelWarn, "policy violation",
+		append(signalAttrs(signal, agent), slog.String(LogKeyError, err.Error()))...)
+}
+
+// signalAttrs returns the attributes identifying a signal and its agent.
+func signalAttrs(signal *Signal, agent string) []slog.Attr {
+	attrs := make([]slog.Attr, 0, 6)
+	attrs = append(attrs,
+		slog.String(LogKeySignalID, signal.ID),
+		slog.String(LogKeySignalType, string(signal.Type)),
+		slog.String(LogKeyTrace, TraceID(signal)),
+	)
+	if agent != "" {
+		attrs = append(attrs, slog.String(LogKeyAgent, agent))
+	}
+	return attrs
+}
+
+// scopedLogger returns the logger handed to an agent processing signal.
+func (l *engineLog) scopedLogger(signal *Signal, agent string) *slog.Logger {
+	return slog.New(l.logger.Handler().WithAttrs(signalAttrs(signal, agent)))
+}
+
+// reportError logs an engine error and calls the error hook. A signal with
+// no destination is not a failure and is logged as terminal.
+func (e *Engine) reportError(signal *Signal, agent string, err error) {
+	switch {
+	case e.log == nil:
+	case errors.Is(err, ErrNoDestination):
+		e.log.terminal(signal)
+	default:
+		e.log.error(signal, agent, err)
+	}
+	if e.onError != nil {
+		e.onError(signal, err)
+	}
+}
+
+// =============================================================================
+// AGENT LOGGERS
+// =============================================================================
+
+type loggerKey struct{}
+
+// WithLogger attaches a logger to a context, as the Engine does for each
+// Process call when EngineConfig.Logger is set.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFrom returns the logger for the current Process call: the Engine's
+// logger scoped to the signal (signal.id, signal.type, trace and agent), or
+// slog.Default() when the engine has no logger or outside an Engine.
+func LoggerFrom(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// processContext builds the context of one Process call.
+func (e *Engine) processContext(signal *Signal, agent string) (context.Context, context.CancelFunc) {
+	ctx := WithFactory(context.Background(), e.factory)
+	if e.log != nil {
+		ctx = WithLogger(ctx, e.log.scopedLogger(signal, agent))
+	}
+	return context.WithTimeout(ctx, e.config.ProcessTimeout)
+}
+
+// stampTrace sets the trace of an output signal the engine owns (a copy)
+// to that of the signal it was emitted for, unless it already has one.
+func stampTrace(out, parent *Signal) {
+	if out.Metadata[MetaTraceID] == "" {
+		out.Metadata[MetaTraceID] = TraceID(parent)
+	}
+}
@@ -0,0 +1,206 @@
+package signal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logCapture collects JSON log records.
+type logCapture struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (c *logCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Write(p)
+}
+
+// records returns the records with the given message.
+func (c *logCapture) records(t *testing.T, msg string) []map[string]any {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(c.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		if rec["msg"] == msg {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// loggedConfig returns an engine configuration logging JSON at level into
+// the returned capture.
+func loggedConfig(level slog.Level, logging LogConfig) (EngineConfig, *logCapture) {
+	capture := &logCapture{}
+	config := DefaultConfig()
+	config.Logger = slog.New(slog.NewJSONHandler(capture, &slog.HandlerOptions{Level: level}))
+	config.Logging = logging
+	return config, capture
+}
+
+func TestEngineLogsAgentCalls(t *testing.T) {
+	config, capture := loggedConfig(slog.LevelDebug, LogConfig{})
+	route := func(sig *Signal) []string {
+		if sig.Type == "raw" {
+			return []string{"parser"}
+		}
+		return nil
+	}
+	engine := newTestEngine(t, config, route,
+		NewAgentFunc("parser", func(ctx context.Context, sig *Signal) AgentResult {
+			if sig.Payload == "bad" {
+				return Err(errors.New("unparseable"))
+			}
+			return OK(NewSignal("parsed", sig.Payload).WithDestination("store"))
+		}),
+		NewAgentFunc("store", func(ctx context.Context, sig *Signal) AgentResult {
+			return OK()
+		}),
+	)
+	startEngine(t, engine)
+
+	root := NewSignal("raw", "ok")
+	engine.Submit(root)
+	engine.Submit(NewSignal("raw", "bad"))
+	waitFor(t, func() bool { return len(capture.records(t, "signal processed")) == 2 })
+	waitFor(t, func() bool { return len(capture.records(t, "signal failed")) == 1 })
+
+	for _, rec := range capture.records(t, "signal processed") {
+		if rec["level"] != "DEBUG" || rec[LogKeyTrace] != root.ID || rec[LogKeyDuration] == nil {
+			t.Errorf("Unexpected processed record: %v", rec)
+		}
+		if rec[LogKeyAgent] == "store" && rec[LogKeySignalType] != "parsed" {
+			t.Errorf("Output should keep the trace of its root, got %v", rec)
+		}
+	}
+	failed := capture.records(t, "signal failed")[0]
+	if failed["level"] != "ERROR" || failed[LogKeyAgent] != "parser" || failed[LogKeyError] != "unparseable" {
+		t.Errorf("Unexpected failed record: %v", failed)
+	}
+	if received := capture.records(t, "signal received"); len(received) != 3 {
+		t.Errorf("Expected 3 received records, got %d", len(received))
+	}
+}
+
+func TestEngineLogsErrorsWithoutHook(t *testing.T) {
+	config, capture := loggedConfig(slog.LevelInfo, LogConfig{Failed: slog.LevelWarn})
+	config.Schemas = NewSchemaRegistry()
+	config.Schemas.Register("task", Schema{JSON: `{"type":"object","required":["name"]}`})
+	engine := newTestEngine(t, config, nil)
+	startEngine(t, engine)
+
+	sig := NewSignal("task", map[string]any{})
+	engine.TrySubmit(sig)
+	waitFor(t, func() bool { return len(capture.records(t, "engine error")) == 1 })
+
+	rec := capture.records(t, "engine error")[0]
+	if rec["level"] != "WARN" || rec[LogKeySignalID] != sig.ID || !strings.Contains(rec[LogKeyError].(string), "name") {
+		t.Errorf("Unexpected error record: %v", rec)
+	}
+	if received := capture.records(t, "signal received"); len(received) != 0 {
+		t.Errorf("Debug events should be filtered at Info, got %d", len(received))
+	}
+}
+
+func TestEngineLogsTerminalSignals(t *testing.T) {
+	config, capture := loggedConfig(slog.LevelInfo, LogConfig{Terminal: slog.LevelInfo})
+	engine := newTestEngine(t, config, nil)
+	startEngine(t, engine)
+
+	sig := NewSignal("answer", nil)
+	engine.Submit(sig)
+	waitFor(t, func() bool { return len(capture.records(t, "signal terminal")) == 1 })
+
+	rec := capture.records(t, "signal terminal")[0]
+	if rec["level"] != "INFO" || rec[LogKeySignalID] != sig.ID {
+		t.Errorf("Unexpected terminal record: %v", rec)
+	}
+	if errs := capture.records(t, "engine error"); len(errs) != 0 {
+		t.Errorf("A signal without destination is not an engine error, got %v", errs)
+	}
+}
+
+func TestEngineLogSampling(t *testing.T) {
+	config, capture := loggedConfig(slog.LevelDebug, LogConfig{Sample: map[SignalType]int{"tick": 3}})
+	engine := newTestEngine(t, config, func(sig *Signal) []string { return []string{"sink"} },
+		NewAgentFunc("sink", func(ctx context.Context, sig *Signal) AgentResult { return OK() }))
+	startEngine(t, engine)
+	var mu sync.Mutex
+	var calls []AgentCall
+	engine.OnAgentCall(func(call AgentCall) {
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+	})
+	remove := engine.OnAgentCall(func(call AgentCall) { t.Error("A removed hook should not be called") })
+	remove()
+
+	for range 9 {
+		engine.Submit(NewSignal("tick", nil))
+	}
+	engine.Submit(NewSignal("order", nil))
+	engine.Stop()
+
+	if got := len(capture.records(t, "signal received")); got != 4 {
+		t.Errorf("Expected 3 sampled ticks and 1 order received, got %d", got)
+	}
+	if got := len(capture.records(t, "signal processed")); got != 4 {
+		t.Errorf("Expected 3 sampled ticks and 1 order processed, got %d", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 10 || calls[0].Agent != "sink" || calls[0].Signal.Destination != "sink" {
+		t.Errorf("OnAgentCall saw %d calls, want every call regardless of sampling", len(calls))
+	}
+}
+
+func TestLoggerFromIsScopedToSignal(t *testing.T) {
+	config, capture := loggedConfig(slog.LevelInfo, LogConfig{})
+	engine := newTestEngine(t, config, func(sig *Signal) []string { return []string{"worker"} },
+		NewAgentFunc("worker", func(ctx context.Context, sig *Signal) AgentResult {
+			LoggerFrom(ctx).Info("working", "step", 1)
+			return OK()
+		}))
+	startEngine(t, engine)
+
+	sig := NewSignal("job", nil).WithMetadata(MetaTraceID, "trace-7")
+	engine.Submit(sig)
+	waitFor(t, func() bool { return len(capture.records(t, "working")) == 1 })
+
+	rec := capture.records(t, "working")[0]
+	if rec[LogKeySignalID] != sig.ID || rec[LogKeySignalType] != "job" || rec[LogKeyAgent] != "worker" ||
+		rec[LogKeyTrace] != "trace-7" || rec["step"] != float64(1) {
+		t.Errorf("Unexpected agent record: %v", rec)
+	}
+
+	if LoggerFrom(context.Background()) != slog.Default() {
+		t.Error("Outside an engine LoggerFrom should return slog.Default()")
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	root := NewSignal("request", nil)
+	if TraceID(root) != root.ID {
+		t.Errorf("Root signal should start its own trace")
+	}
+	child := root.WithMetadata(MetaTraceID, root.ID).Derive("task", nil)
+	if TraceID(child) != root.ID {
+		t.Errorf("Derive should keep the trace, got %s", TraceID(child))
+	}
+}
@@ -0,0 +1,208 @@
+package signal
+
+import (
+	"fmt"
+	"sort"
+)
+
+// =============================================================================
+// PAUSE/RESUME: Holding Signals During Maintenance
+// =============================================================================
+
+// holdQueue is the holding area of one agent: signals routed to it while it
+// (or the whole engine) is paused, in arrival order.
+type holdQueue struct {
+	items    []heldSignal
+	paused   bool // Paused individually through PauseAgent
+	draining bool // A goroutine is re-dispatching held signals
+}
+
+// heldSignal is a signal waiting in a holding area.
+type heldSignal struct {
+	signal   *Signal   // Destination already set
+	delivery *delivery // Set for delivered signals (see Deliver)
+}
+
+// Pause stops dispatching signals to agents. Signals keep being accepted
+// and routed, but are held per destination agent (up to HoldCapacity each)
+// until Resume. Calls already in flight are not interrupted.
+func (e *Engine) Pause() {
+	e.holdMu.Lock()
+	defer e.holdMu.Unlock()
+	e.enginePaused = true
+}
+
+// Resume restarts dispatching and re-dispatches held signals, in order,
+// for every agent that is not paused individually.
+func (e *Engine) Resume() {
+	e.holdMu.Lock()
+	defer e.holdMu.Unlock()
+	e.enginePaused = false
+	for id := range e.held {
+		e.startDrain(id)
+	}
+}
+
+// PauseAgent stops dispatching signals to one agent; they are held until
+// ResumeAgent. The agent must be registered.
+func (e *Engine) PauseAgent(agentID string) error {
+	if _, exists := e.router.GetAgent(agentID); !exists {
+		return fmt.Errorf("agent '%s' not found", agentID)
+	}
+	e.holdMu.Lock()
+	defer e.holdMu.Unlock()
+	e.holdQueue(agentID).paused = true
+	return nil
+}
+
+// ResumeAgent restarts dispatching to an agent and re-dispatches its held
+// signals, in order. While the engine itself is paused, the held signals
+// stay held until Resume.
+func (e *Engine) ResumeAgent(agentID string) error {
+	e.holdMu.Lock()
+	defer e.holdMu.Unlock()
+	q, ok := e.held[agentID]
+	if !ok || !q.paused {
+		return fmt.Errorf("agent '%s' is not paused", agentID)
+	}
+	q.paused = false
+	e.startDrain(agentID)
+	return nil
+}
+
+// IsPaused reports whether the engine is paused.
+func (e *Engine) IsPaused() bool {
+	e.holdMu.Lock()
+	defer e.holdMu.Unlock()
+	return e.enginePaused
+}
+
+// holdQueue returns the holding area of an agent, creating it if needed.
+// Caller must hold e.holdMu.
+func (e *Engine) holdQueue(agentID string) *holdQueue {
+	q, ok := e.held[agentID]
+	if !ok {
+		q = &holdQueue{}
+		e.held[agentID] = q
+	}
+	return q
+}
+
+// holdPaused holds the signal for every paused destination and returns the
+// destinations to dispatch now. When a holding area is full, the signal is
+// dropped for that destination and reported through the error hook (and to
+// the delivery d, if set).
+func (e *Engine) holdPaused(signal *Signal, destinations []string, d *delivery) []string {
+	e.holdMu.Lock()
+	dispatch := destinations[:0:0]
+	var overflowed []string
+	for _, id := range destinations {
+		q, ok := e.held[id]
+		if !e.enginePaused && (!ok || (!q.paused && !q.draining && len(q.items) == 0)) {
+			dispatch = append(dispatch, id)
+			continue
+		}
+		q = e.holdQueue(id)
+		if len(q.items) >= e.config.HoldCapacity {
+			e.holdOverflow++
+			overflowed = append(overflowed, id)
+			continue
+		}
+		q.items = append(q.items, heldSignal{signal: signal.WithDestination(id), delivery: d})
+		e.inflight.add(TraceID(signal)) // Released once drained
+	}
+	e.holdMu.Unlock()
+
+	for _, id := range overflowed {
+		err := fmt.Errorf("holding area of paused agent '%s' is full (%d signals)", id, e.config.HoldCapacity)
+		e.reportError(signal, id, err)
+		if d != nil {
+			d.reply(Err(err))
+		}
+	}
+	return dispatch
+}
+
+// startDrain starts re-dispatching an agent's held signals unless it is
+// still paused, already draining, or the engine is not running.
+// Caller must hold e.holdMu.
+func (e *Engine) startDrain(agentID string) {
+	q := e.held[agentID]
+	if e.enginePaused || e.drainStopped || q.paused || q.draining {
+		return
+	}
+	if len(q.items) == 0 {
+		delete(e.held, agentID)
+		return
+	}
+	q.draining = true
+	e.drains.Add(1)
+	go e.drainHeld(agentID)
+}
+
+// drainHeld re-dispatches held signals one at a time. Signals arriving for
+// the agent meanwhile are queued behind them, preserving order. Draining
+// stops when the queue is empty or the agent or engine is paused again.
+func (e *Engine) drainHeld(agentID string) {
+	defer e.drains.Done()
+	for {
+		e.holdMu.Lock()
+		q := e.held[agentID]
+		if e.enginePaused || e.drainStopped || q.paused || len(q.items) == 0 {
+			q.draining = false
+			if !q.paused && len(q.items) == 0 {
+				delete(e.held, agentID)
+			}
+			e.holdMu.Unlock()
+			return
+		}
+		held := q.items[0]
+		q.items[0] = heldSignal{}
+		q.items = q.items[1:]
+		e.holdMu.Unlock()
+
+		slots := e.acquireSlots([]string{agentID})
+		e.processInAgent(held.signal, agentID, slots[0], turn{}, held.delivery)
+		e.inflight.done(TraceID(held.signal))
+	}
+}
+
+// resumeDrains restarts draining of held signals after Start.
+func (e *Engine) resumeDrains() {
+	e.holdMu.Lock()
+	defer e.holdMu.Unlock()
+	e.drainStopped = false
+	for id := range e.held {
+		e.startDrain(id)
+	}
+}
+
+// stopDrains halts draining before Stop and waits for the signal being
+// re-dispatched, so its outputs can still be submitted. Undrained signals
+// stay held until the next Start.
+func (e *Engine) stopDrains() {
+	e.holdMu.Lock()
+	e.drainStopped = true
+	e.holdMu.Unlock()
+	e.drains.Wait()
+}
+
+// holdStats fills the pause fields of EngineStats.
+func (e *Engine) holdStats(stats *EngineStats) {
+	e.holdMu.Lock()
+	defer e.holdMu.Unlock()
+	stats.Paused = e.enginePaused
+	stats.HeldOverflow = e.holdOverflow
+	for id, q := range e.held {
+		if q.paused {
+			stats.PausedAgents = append(stats.PausedAgents, id)
+		}
+		if len(q.items) > 0 {
+			if stats.Held == nil {
+				stats.Held = make(map[string]int)
+			}
+			stats.Held[id] = len(q.items)
+		}
+	}
+	sort.Strings(stats.PausedAgents)
+}
@@ -0,0 +1,165 @@
+package signal
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// orderedAgent records the payloads it processes, in order.
+type orderedAgent struct {
+	id   string
+	mu   sync.Mutex
+	seen []any
+}
+
+func (a *orderedAgent) ID() string { return a.id }
+
+func (a *orderedAgent) Process(ctx context.Context, sig *Signal) AgentResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seen = append(a.seen, sig.Payload)
+	return OK()
+}
+
+func (a *orderedAgent) processed() []any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]any(nil), a.seen...)
+}
+
+// waitHeld waits until the engine holds n signals for an agent.
+func waitHeld(t *testing.T, engine *Engine, agentID string, n int) {
+	t.Helper()
+	waitFor(t, func() bool { return engine.Stats().Held[agentID] == n })
+}
+
+func TestPauseAgentHoldsAndResumesInOrder(t *testing.T) {
+	model := &orderedAgent{id: "model"}
+	other := &orderedAgent{id: "other"}
+	config := DefaultConfig()
+	config.WorkerCount = 1
+	engine := newTestEngine(t, config, nil, model, other)
+	startEngine(t, engine)
+
+	if err := engine.PauseAgent("model"); err != nil {
+		t.Fatalf("PauseAgent() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		engine.Submit(NewSignal("prompt", i).WithDestination("model"))
+	}
+	engine.Submit(NewSignal("prompt", "x").WithDestination("other"))
+	waitHeld(t, engine, "model", 3)
+
+	stats := engine.Stats()
+	if len(stats.PausedAgents) != 1 || stats.PausedAgents[0] != "model" || stats.Paused {
+		t.Errorf("Stats = %+v, want only model paused", stats)
+	}
+	waitFor(t, func() bool { return len(other.processed()) == 1 })
+	if len(model.processed()) != 0 {
+		t.Fatal("Paused agent processed signals")
+	}
+
+	if err := engine.ResumeAgent("model"); err != nil {
+		t.Fatalf("ResumeAgent() error = %v", err)
+	}
+	engine.Submit(NewSignal("prompt", 3).WithDestination("model"))
+	waitFor(t, func() bool { return len(model.processed()) == 4 })
+
+	for i, p := range model.processed() {
+		if p != i {
+			t.Errorf("Processed %v, want payloads in submission order", model.processed())
+			break
+		}
+	}
+	if stats := engine.Stats(); len(stats.PausedAgents) != 0 || len(stats.Held) != 0 {
+		t.Errorf("Stats after resume = %+v, want nothing paused or held", stats)
+	}
+}
+
+func TestPauseEngine(t *testing.T) {
+	agent := &orderedAgent{id: "model"}
+	engine := newTestEngine(t, DefaultConfig(), nil, agent)
+	startEngine(t, engine)
+
+	engine.Pause()
+	if !engine.IsPaused() || !engine.Stats().Paused {
+		t.Fatal("Engine should report paused")
+	}
+	engine.Submit(NewSignal("prompt", 1).WithDestination("model"))
+	engine.Submit(NewSignal("prompt", 2).WithDestination("model"))
+	waitHeld(t, engine, "model", 2)
+
+	// Resuming the agent alone does not bypass the engine pause: no drain
+	// starts, so the signals are still held when ResumeAgent returns
+	engine.PauseAgent("model")
+	engine.ResumeAgent("model")
+	if held := engine.Stats().Held["model"]; held != 2 || len(agent.processed()) != 0 {
+		t.Fatalf("Held = %d, processed = %v; want nothing dispatched while the engine is paused", held, agent.processed())
+	}
+
+	engine.Resume()
+	waitFor(t, func() bool { return len(agent.processed()) == 2 })
+}
+
+func TestPauseHoldingAreaIsBounded(t *testing.T) {
+	agent := &orderedAgent{id: "model"}
+	config := DefaultConfig()
+	config.HoldCapacity = 2
+	engine := newTestEngine(t, config, nil, agent)
+	startEngine(t, engine)
+
+	var mu sync.Mutex
+	var errs []error
+	engine.OnError(func(sig *Signal, err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+
+	engine.PauseAgent("model")
+	for i := 0; i < 3; i++ {
+		engine.Submit(NewSignal("prompt", i).WithDestination("model"))
+	}
+	waitFor(t, func() bool { return engine.Stats().HeldOverflow == 1 })
+
+	if held := engine.Stats().Held["model"]; held != 2 {
+		t.Errorf("Held = %d, want 2", held)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "full") {
+		t.Errorf("Errors = %v, want one holding area overflow", errs)
+	}
+}
+
+func TestHeldSignalsSurviveRestart(t *testing.T) {
+	agent := &orderedAgent{id: "model"}
+	engine := newTestEngine(t, DefaultConfig(), nil, agent)
+	startEngine(t, engine)
+
+	engine.PauseAgent("model")
+	engine.Submit(NewSignal("prompt", 1).WithDestination("model"))
+	waitHeld(t, engine, "model", 1)
+
+	engine.Stop()
+	engine.ResumeAgent("model") // Drained once the engine runs again
+	if len(agent.processed()) != 0 {
+		t.Fatal("Held signal dispatched while stopped")
+	}
+	engine.Start()
+	waitFor(t, func() bool { return len(agent.processed()) == 1 })
+}
+
+func TestPauseAgentErrors(t *testing.T) {
+	engine := newTestEngine(t, DefaultConfig(), nil, &orderedAgent{id: "model"})
+	startEngine(t, engine)
+
+	if err := engine.PauseAgent("missing"); err == nil {
+		t.Error("PauseAgent() of unknown agent should fail")
+	}
+	if err := engine.ResumeAgent("model"); err == nil {
+		t.Error("ResumeAgent() of a running agent should fail")
+	}
+}
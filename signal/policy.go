@@ -0,0 +1,182 @@
+package signal
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// =============================================================================
+// POLICY: Access Control for Agent Emissions
+// =============================================================================
+
+// ErrPolicyDenied is reported through the error hook for emissions and
+// routes denied by the engine's Policy; match it with errors.Is.
+var ErrPolicyDenied = errors.New("denied by policy")
+
+// PolicyRule allows one agent to emit signals of some types to some
+// destinations.
+type PolicyRule struct {
+	Source string       `yaml:"source" json:"source"`                   // Emitting agent; "*" matches any agent
+	Types  []SignalType `yaml:"types,omitempty" json:"types,omitempty"` // Empty allows every type
+	To     []string     `yaml:"to,omitempty" json:"to,omitempty"`       // Empty allows every destination
+}
+
+// matches reports whether the rule allows source to emit type t to dest.
+// An empty dest asks only about the type.
+func (r PolicyRule) matches(source string, t SignalType, dest string) bool {
+	if r.Source != "*" && r.Source != source {
+		return false
+	}
+	if len(r.Types) > 0 && !slices.Contains(r.Types, t) {
+		return false
+	}
+	return dest == "" || len(r.To) == 0 || slices.Contains(r.To, dest)
+}
+
+// Policy is an allow-list of what agents may emit, checked by the Engine
+// (see EngineConfig.Policy). A signal emitted by an agent is allowed only if
+// some rule for its source permits its type, and it is delivered only to
+// the destinations some rule permits. Everything else is denied, so an
+// agent without rules may emit nothing.
+//
+// The source of an output is always the agent that returned it. Signals
+// entering from outside carry the identity of their entry point instead of
+// a source of their own: GatewayConfig.Source and ServerConfig.Identity.
+// Signals the application submits keep the Source it sets; those with an
+// empty Source are not checked.
+//
+// The YAML and JSON tags let a policy be embedded in configuration files:
+//
+//	policy:
+//	  rules:
+//	    - source: coordinator
+//	      types: [task_assignment]
+//	      to: [writing, summary]
+type Policy struct {
+	Rules []PolicyRule `yaml:"rules" json:"rules"`
+
+	// AuditOnly reports violations without denying them, to try out a
+	// policy on live traffic. Violations are then logged as "policy
+	// violation" events instead of being reported as errors.
+	AuditOnly bool `yaml:"audit_only,omitempty" json:"audit_only,omitempty"`
+}
+
+// Validate checks that every rule names a source.
+func (p *Policy) Validate() error {
+	for i, r := range p.Rules {
+		if r.Source == "" {
+			return fmt.Errorf("policy rule %d has no source", i)
+		}
+	}
+	return nil
+}
+
+// Allows reports whether source may emit signals of type t to dest.
+// An empty dest asks whether the type may be emitted at all.
+func (p *Policy) Allows(source string, t SignalType, dest string) bool {
+	for _, r := range p.Rules {
+		if r.matches(source, t, dest) {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyStage is where the Engine checks the policy.
+type PolicyStage int
+
+const (
+	// PolicyEmit checks an agent's output before it is submitted: its type,
+	// and its explicit destination if set.
+	PolicyEmit PolicyStage = iota
+	// PolicyRoute checks each destination a signal was routed to.
+	PolicyRoute
+)
+
+// String returns the stage name.
+func (s PolicyStage) String() string {
+	if s == PolicyRoute {
+		return "route"
+	}
+	return "emit"
+}
+
+// PolicyViolation describes a denied (or, in audit-only mode, reported)
+// emission or route.
+type PolicyViolation struct {
+	Stage       PolicyStage
+	Source      string     // Emitting agent
+	Type        SignalType // Signal type
+	Destination string     // Denied destination; empty for a denied type
+	Enforced    bool       // False in audit-only mode
+}
+
+// Err returns the violation as an error wrapping ErrPolicyDenied.
+func (v PolicyViolation) Err() error {
+	if v.Destination == "" {
+		return fmt.Errorf("%w: agent '%s' may not emit signal type '%s'", ErrPolicyDenied, v.Source, v.Type)
+	}
+	return fmt.Errorf("%w: agent '%s' may not send signal type '%s' to '%s'", ErrPolicyDenied, v.Source, v.Type, v.Destination)
+}
+
+// PolicyHook is called for every policy violation.
+type PolicyHook func(signal *Signal, v PolicyViolation)
+
+// OnPolicyViolation sets a hook called for every violation of the policy,
+// enforced or not, for auditing.
+func (e *Engine) OnPolicyViolation(hook PolicyHook) {
+	e.onPolicyViolation = hook
+}
+
+// permitEmit checks an agent's output against the policy and reports
+// whether it may be submitted.
+func (e *Engine) permitEmit(out *Signal, agent string) bool {
+	p := e.config.Policy
+	if p == nil {
+		return true
+	}
+	if !p.Allows(agent, out.Type, "") {
+		return e.violate(out, PolicyViolation{Stage: PolicyEmit, Source: agent, Type: out.Type})
+	}
+	if out.Destination != "" && !p.Allows(agent, out.Type, out.Destination) {
+		return e.violate(out, PolicyViolation{Stage: PolicyEmit, Source: agent, Type: out.Type, Destination: out.Destination})
+	}
+	return true
+}
+
+// permitRoute removes the destinations the policy denies to the signal's
+// source. Signals without a source are not checked.
+func (e *Engine) permitRoute(signal *Signal, destinations []string) []string {
+	p := e.config.Policy
+	if p == nil || signal.Source == "" {
+		return destinations
+	}
+	allowed := make([]string, 0, len(destinations))
+	for _, dest := range destinations {
+		if p.Allows(signal.Source, signal.Type, dest) ||
+			e.violate(signal, PolicyViolation{Stage: PolicyRoute, Source: signal.Source, Type: signal.Type, Destination: dest}) {
+			allowed = append(allowed, dest)
+		}
+	}
+	return allowed
+}
+
+// violate audits a violation and reports whether the signal may proceed,
+// which it may only in audit-only mode.
+func (e *Engine) violate(signal *Signal, v PolicyViolation) bool {
+	v.Enforced = !e.config.Policy.AuditOnly
+	e.policyViolations.Add(1)
+	if e.onPolicyViolation != nil {
+		e.onPolicyViolation(signal, v)
+	}
+	err := fmt.Errorf("%w (id=%s)", v.Err(), truncateID(signal.ID))
+	if v.Enforced {
+		e.reportError(signal, v.Source, err)
+		return false
+	}
+	if e.log != nil {
+		e.log.violation(signal, v.Source, err)
+	}
+	return true
+}
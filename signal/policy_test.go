@@ -0,0 +1,245 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// policyHarness runs a coordinator → worker → output flow in which the
+// worker returns whatever outputs the test sets, and records deliveries,
+// errors and violations.
+type policyHarness struct {
+	engine *Engine
+
+	mu         sync.Mutex
+	outputs    []*Signal // Returned by the worker
+	delivered  []string  // "agent:type"
+	errs       []error
+	violations []PolicyViolation
+}
+
+func newPolicyHarness(t *testing.T, policy *Policy) *policyHarness {
+	t.Helper()
+	h := &policyHarness{}
+	deliver := func(id string, result func(sig *Signal) AgentResult) Agent {
+		return NewAgentFunc(id, func(ctx context.Context, sig *Signal) AgentResult {
+			h.mu.Lock()
+			h.delivered = append(h.delivered, id+":"+string(sig.Type))
+			h.mu.Unlock()
+			return result(sig)
+		})
+	}
+	route := func(sig *Signal) []string {
+		switch sig.Type {
+		case "request":
+			return []string{"coordinator"}
+		case "task":
+			return []string{"worker"}
+		case "result":
+			return []string{"output", "auditor"}
+		}
+		return nil
+	}
+
+	config := DefaultConfig()
+	config.Policy = policy
+	h.engine = newTestEngine(t, config, route,
+		deliver("coordinator", func(sig *Signal) AgentResult { return OK(sig.Derive("task", nil)) }),
+		deliver("worker", func(sig *Signal) AgentResult {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			return OK(h.outputs...)
+		}),
+		deliver("output", func(sig *Signal) AgentResult { return OK() }),
+		deliver("auditor", func(sig *Signal) AgentResult { return OK() }),
+	)
+	h.engine.OnError(func(sig *Signal, err error) {
+		if errors.Is(err, ErrNoDestination) {
+			return
+		}
+		h.mu.Lock()
+		h.errs = append(h.errs, err)
+		h.mu.Unlock()
+	})
+	h.engine.OnPolicyViolation(func(sig *Signal, v PolicyViolation) {
+		h.mu.Lock()
+		h.violations = append(h.violations, v)
+		h.mu.Unlock()
+	})
+	startEngine(t, h.engine)
+	return h
+}
+
+// run submits a request with the worker returning outputs, and waits.
+func (h *policyHarness) run(t *testing.T, outputs ...*Signal) {
+	t.Helper()
+	h.mu.Lock()
+	h.outputs = outputs
+	h.mu.Unlock()
+	h.engine.Submit(NewSignal("request", nil))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := h.engine.WaitIdle(ctx); err != nil {
+		t.Fatalf("WaitIdle error = %v", err)
+	}
+}
+
+func (h *policyHarness) deliveries() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	got := append([]string(nil), h.delivered...)
+	sort.Strings(got)
+	return got
+}
+
+func orchestrationPolicy() *Policy {
+	return &Policy{Rules: []PolicyRule{
+		{Source: "coordinator", Types: []SignalType{"task"}, To: []string{"worker"}},
+		{Source: "worker", Types: []SignalType{"result"}, To: []string{"output"}},
+	}}
+}
+
+func TestPolicyAllowsPermittedFlow(t *testing.T) {
+	h := newPolicyHarness(t, &Policy{Rules: []PolicyRule{
+		{Source: "coordinator", Types: []SignalType{"task"}},
+		{Source: "worker", Types: []SignalType{"result"}, To: []string{"output", "auditor"}},
+	}})
+	h.run(t, NewSignal("result", nil))
+
+	want := []string{"auditor:result", "coordinator:request", "output:result", "worker:task"}
+	if got := h.deliveries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Deliveries = %v, want %v", got, want)
+	}
+	if len(h.errs) != 0 || len(h.violations) != 0 {
+		t.Errorf("Expected no violations, got %v %v", h.errs, h.violations)
+	}
+}
+
+func TestPolicyDeniesEmission(t *testing.T) {
+	h := newPolicyHarness(t, orchestrationPolicy())
+	// The worker tries to assign tasks as if it were the coordinator
+	h.run(t, NewSignal("task", nil), NewSignal("result", nil))
+
+	want := []string{"coordinator:request", "output:result", "worker:task"}
+	if got := h.deliveries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Deliveries = %v, want %v", got, want)
+	}
+	if len(h.errs) != 2 || !errors.Is(h.errs[0], ErrPolicyDenied) {
+		t.Fatalf("Expected denied emission and route, got %v", h.errs)
+	}
+	wantViolations := []PolicyViolation{
+		{Stage: PolicyEmit, Source: "worker", Type: "task", Enforced: true},
+		{Stage: PolicyRoute, Source: "worker", Type: "result", Destination: "auditor", Enforced: true},
+	}
+	if !reflect.DeepEqual(h.violations, wantViolations) {
+		t.Errorf("Violations = %+v, want %+v", h.violations, wantViolations)
+	}
+	if got := h.engine.Stats().Violations; got != 2 {
+		t.Errorf("Stats().Violations = %d, want 2", got)
+	}
+}
+
+func TestPolicyDeniesExplicitDestination(t *testing.T) {
+	h := newPolicyHarness(t, orchestrationPolicy())
+	h.run(t, NewSignal("result", nil).WithDestination("coordinator"))
+
+	if got := h.deliveries(); !reflect.DeepEqual(got, []string{"coordinator:request", "worker:task"}) {
+		t.Errorf("Deliveries = %v", got)
+	}
+	if len(h.violations) != 1 || h.violations[0].Stage != PolicyEmit || h.violations[0].Destination != "coordinator" {
+		t.Errorf("Expected a denied emission to the coordinator, got %+v", h.violations)
+	}
+}
+
+func TestPolicyAuditOnly(t *testing.T) {
+	policy := orchestrationPolicy()
+	policy.AuditOnly = true
+	h := newPolicyHarness(t, policy)
+	h.run(t, NewSignal("result", nil))
+
+	want := []string{"auditor:result", "coordinator:request", "output:result", "worker:task"}
+	if got := h.deliveries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Audit-only should deliver everything, got %v", got)
+	}
+	if len(h.errs) != 0 {
+		t.Errorf("Audit-only violations should not be errors, got %v", h.errs)
+	}
+	if len(h.violations) != 1 || h.violations[0].Enforced {
+		t.Errorf("Expected one unenforced violation, got %+v", h.violations)
+	}
+}
+
+func TestPolicyChecksRemoteCalls(t *testing.T) {
+	policy := orchestrationPolicy()
+	policy.Rules = append(policy.Rules, PolicyRule{Source: "remote", Types: []SignalType{"task"}, To: []string{"worker"}})
+	h := newPolicyHarness(t, policy)
+	h.outputs = []*Signal{NewSignal("task", nil), NewSignal("result", nil)}
+	server := NewServer(h.engine, ServerConfig{})
+	if err := server.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	waitFor(t, func() bool { return server.Addr() != nil })
+	defer server.Close()
+	remote := NewRemoteAgent("worker", fastRemote(server.Addr().String(), TCPTransport()))
+	defer remote.Close(context.Background())
+
+	call := func(typ SignalType) AgentResult {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		sig := NewSignal(typ, nil)
+		sig.Source = "coordinator" // Claimed by the caller, not trusted
+		return remote.Process(ctx, sig)
+	}
+	if result := call("report"); result.Error == nil || !strings.Contains(result.Error.Error(), ErrPolicyDenied.Error()) {
+		t.Errorf("Call with a type remote callers may not send = %+v, want denied", result)
+	}
+	result := call("task")
+	if result.Error != nil || len(result.Signals) != 1 || result.Signals[0].Type != "result" {
+		t.Errorf("Permitted call = %+v, want only the permitted output", result)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	wantViolations := []PolicyViolation{
+		{Stage: PolicyRoute, Source: "remote", Type: "report", Destination: "worker", Enforced: true},
+		{Stage: PolicyEmit, Source: "worker", Type: "task", Enforced: true},
+	}
+	if !reflect.DeepEqual(h.violations, wantViolations) {
+		t.Errorf("Violations = %+v, want %+v", h.violations, wantViolations)
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	policy := &Policy{Rules: []PolicyRule{
+		{Source: "coordinator", Types: []SignalType{"task"}, To: []string{"worker"}},
+		{Source: "*", Types: []SignalType{"log"}},
+	}}
+	tests := []struct {
+		source string
+		typ    SignalType
+		dest   string
+		want   bool
+	}{
+		{"coordinator", "task", "worker", true},
+		{"coordinator", "task", "", true},
+		{"coordinator", "task", "output", false},
+		{"coordinator", "result", "", false},
+		{"worker", "task", "", false},
+		{"worker", "log", "anyone", true},
+	}
+	for _, tt := range tests {
+		if got := policy.Allows(tt.source, tt.typ, tt.dest); got != tt.want {
+			t.Errorf("Allows(%q, %q, %q) = %v, want %v", tt.source, tt.typ, tt.dest, got, tt.want)
+		}
+	}
+
+	if err := (&Policy{Rules: []PolicyRule{{Types: []SignalType{"x"}}}}).Validate(); err == nil {
+		t.Error("Validate should reject a rule without source")
+	}
+}
@@ -0,0 +1,396 @@
+package signal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// RECORDING: Capturing Agent Traffic
+// =============================================================================
+
+// Exchange is one recorded agent call: the signal that entered the agent
+// and the signals (or error) that left it. A recording is a JSONL stream of
+// exchanges.
+type Exchange struct {
+	Agent    string        `json:"agent"`
+	Input    Envelope      `json:"input"`
+	Outputs  []Envelope    `json:"outputs,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// Recorder writes the exchanges of an Engine as JSONL. Attach it with
+// Engine.Record. Writes are serialized; the first write or encoding error
+// stops the recording and is reported by Err.
+type Recorder struct {
+	codec *Codec
+
+	mu    sync.Mutex
+	w     *bufio.Writer
+	enc   *json.Encoder
+	count int
+	err   error
+}
+
+// NewRecorder creates a recorder writing to w. A nil codec uses NewCodec().
+func NewRecorder(w io.Writer, codec *Codec) *Recorder {
+	if codec == nil {
+		codec = NewCodec()
+	}
+	buf := bufio.NewWriter(w)
+	return &Recorder{codec: codec, w: buf, enc: json.NewEncoder(buf)}
+}
+
+// Record attaches a recorder: every agent call completed from now on is
+// written as one exchange, outputs with Source set to the agent. The
+// returned function detaches the recorder and flushes it.
+func (e *Engine) Record(rec *Recorder) (stop func() error) {
+	remove := e.calls.add(rec.record)
+	return func() error {
+		remove()
+		return rec.Flush()
+	}
+}
+
+// Count returns the number of exchanges written.
+func (r *Recorder) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// Flush writes buffered exchanges to the underlying writer.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	return r.err
+}
+
+// Err returns the error that stopped the recording, if any.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// record writes one agent call.
+func (r *Recorder) record(call AgentCall) {
+	ex, err := r.exchange(call)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err == nil {
+		err = r.enc.Encode(ex)
+	}
+	if err != nil {
+		r.err = fmt.Errorf("record call of agent '%s': %w", call.Agent, err)
+		return
+	}
+	r.count++
+}
+
+func (r *Recorder) exchange(call AgentCall) (Exchange, error) {
+	input, err := r.codec.Wrap(call.Signal)
+	if err != nil {
+		return Exchange{}, err
+	}
+	ex := Exchange{Agent: call.Agent, Input: input, Duration: call.Duration}
+	if call.Result.Error != nil {
+		ex.Error = call.Result.Error.Error()
+	}
+	for _, out := range call.Result.Signals {
+		env, err := r.codec.Wrap(out.WithSource(call.Agent))
+		if err != nil {
+			return Exchange{}, err
+		}
+		ex.Outputs = append(ex.Outputs, env)
+	}
+	return ex, nil
+}
+
+// ReadExchanges reads a JSONL recording.
+func ReadExchanges(r io.Reader) ([]Exchange, error) {
+	var exchanges []Exchange
+	dec := json.NewDecoder(r)
+	for {
+		var ex Exchange
+		if err := dec.Decode(&ex); errors.Is(err, io.EOF) {
+			return exchanges, nil
+		} else if err != nil {
+			return exchanges, fmt.Errorf("read exchange %d: %w", len(exchanges)+1, err)
+		}
+		exchanges = append(exchanges, ex)
+	}
+}
+
+// =============================================================================
+// REPLAY: Diffing a New Agent Version Against a Recording
+// =============================================================================
+
+// ReplayConfig configures Replay.
+type ReplayConfig struct {
+	// Agents to replay against, matched to exchanges by ID. Exchanges of
+	// other agents are skipped.
+	Agents []Agent
+
+	// Codec decodes recorded inputs (so agents get their usual payload
+	// types) and encodes replayed outputs. Defaults to NewCodec().
+	Codec *Codec
+
+	// Timeout bounds each replayed call. Defaults to 30 seconds.
+	Timeout time.Duration
+
+	// Ignore lists change paths to leave out of the diff, such as fields
+	// holding generated IDs. Patterns use path.Match syntax and are
+	// matched against paths without their "outputs[i]." prefix, for
+	// example "payload.task_id" or "metadata.*".
+	Ignore []string
+}
+
+// Change is one difference between a recorded and a replayed call.
+// Recorded or Replayed is nil when the value is absent on that side.
+type Change struct {
+	Path     string // "error", "outputs", "outputs[1].destination", "outputs[0].payload.workers[2]"
+	Recorded any
+	Replayed any
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v → %v", c.Path, c.Recorded, c.Replayed)
+}
+
+// ExchangeDiff lists the changes of one replayed exchange.
+type ExchangeDiff struct {
+	Index   int // Position in the recording
+	Agent   string
+	InputID string
+	Changes []Change
+}
+
+// ReplayReport summarizes a replay.
+type ReplayReport struct {
+	Replayed  int            // Exchanges replayed
+	Skipped   int            // Exchanges of agents not under replay
+	Unchanged int            // Replayed exchanges without changes
+	Diffs     []ExchangeDiff // Replayed exchanges with changes, in order
+}
+
+// String renders the report for humans, one change per line.
+func (r ReplayReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d replayed, %d changed, %d skipped\n", r.Replayed, len(r.Diffs), r.Skipped)
+	for _, d := range r.Diffs {
+		fmt.Fprintf(&b, "#%d %s (input %s)\n", d.Index, d.Agent, d.InputID)
+		for _, c := range d.Changes {
+			fmt.Fprintf(&b, "  %s\n", c)
+		}
+	}
+	return b.String()
+}
+
+// Replay feeds each recorded input to the matching agent and diffs the
+// emitted signals against the recorded ones: count, type, destination,
+// metadata, payload fields (compared as JSON) and error. IDs, timestamps
+// and lineage fields always differ between runs and are not compared.
+// Outputs are compared in order.
+func Replay(ctx context.Context, recording io.Reader, config ReplayConfig) (ReplayReport, error) {
+	if config.Codec == nil {
+		config.Codec = NewCodec()
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	agents := make(map[string]Agent, len(config.Agents))
+	for _, a := range config.Agents {
+		agents[a.ID()] = a
+	}
+
+	exchanges, err := ReadExchanges(recording)
+	if err != nil {
+		return ReplayReport{}, err
+	}
+
+	var report ReplayReport
+	for i, ex := range exchanges {
+		agent, ok := agents[ex.Agent]
+		if !ok {
+			report.Skipped++
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		input, err := config.Codec.Unwrap(ex.Input)
+		if err != nil {
+			return report, fmt.Errorf("exchange %d: %w", i+1, err)
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+		result := Call(callCtx, agent, input)
+		cancel()
+
+		changes, err := diffExchange(ex, result, config)
+		if err != nil {
+			return report, fmt.Errorf("exchange %d: %w", i+1, err)
+		}
+		report.Replayed++
+		if len(changes) == 0 {
+			report.Unchanged++
+			continue
+		}
+		report.Diffs = append(report.Diffs, ExchangeDiff{Index: i + 1, Agent: ex.Agent, InputID: ex.Input.ID, Changes: changes})
+	}
+	return report, nil
+}
+
+// diffExchange compares a recorded exchange with a replayed result.
+func diffExchange(ex Exchange, result AgentResult, config ReplayConfig) ([]Change, error) {
+	d := differ{ignore: config.Ignore}
+
+	var replayedErr string
+	if result.Error != nil {
+		replayedErr = result.Error.Error()
+	}
+	if ex.Error != replayedErr {
+		d.add("", "error", optional(ex.Error), optional(replayedErr))
+	}
+
+	outputs := make([]Envelope, len(result.Signals))
+	for i, out := range result.Signals {
+		env, err := config.Codec.Wrap(out.WithSource(ex.Agent))
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = env
+	}
+	if len(ex.Outputs) != len(outputs) {
+		d.add("", "outputs", summarize(ex.Outputs), summarize(outputs))
+	}
+
+	for i := 0; i < min(len(ex.Outputs), len(outputs)); i++ {
+		prefix := fmt.Sprintf("outputs[%d].", i)
+		rec, rep := ex.Outputs[i], outputs[i]
+		if rec.Type != rep.Type {
+			d.add(prefix, "type", rec.Type, rep.Type)
+		}
+		if rec.Destination != rep.Destination {
+			d.add(prefix, "destination", optional(rec.Destination), optional(rep.Destination))
+		}
+		d.values(prefix, "metadata", stringMap(rec.Metadata), stringMap(rep.Metadata))
+
+		var recPayload, repPayload any
+		if err := unmarshalOptional(rec.Payload, &recPayload); err != nil {
+			return nil, err
+		}
+		if err := unmarshalOptional(rep.Payload, &repPayload); err != nil {
+			return nil, err
+		}
+		d.values(prefix, "payload", recPayload, repPayload)
+	}
+	return d.changes, nil
+}
+
+// differ accumulates changes, dropping ignored paths.
+type differ struct {
+	ignore  []string
+	changes []Change
+}
+
+func (d *differ) add(prefix, p string, recorded, replayed any) {
+	for _, pattern := range d.ignore {
+		if ok, _ := path.Match(pattern, p); ok {
+			return
+		}
+	}
+	d.changes = append(d.changes, Change{Path: prefix + p, Recorded: recorded, Replayed: replayed})
+}
+
+// values diffs two generic JSON values field by field.
+func (d *differ) values(prefix, p string, recorded, replayed any) {
+	switch rec := recorded.(type) {
+	case map[string]any:
+		rep, ok := replayed.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(rec)+len(rep))
+		for k := range rec {
+			keys = append(keys, k)
+		}
+		for k := range rep {
+			if _, dup := rec[k]; !dup {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			d.values(prefix, p+"."+k, rec[k], rep[k])
+		}
+		return
+	case []any:
+		rep, ok := replayed.([]any)
+		if !ok || len(rec) != len(rep) {
+			break
+		}
+		for i := range rec {
+			d.values(prefix, fmt.Sprintf("%s[%d]", p, i), rec[i], rep[i])
+		}
+		return
+	}
+	if !reflect.DeepEqual(recorded, replayed) {
+		d.add(prefix, p, recorded, replayed)
+	}
+}
+
+// optional maps an empty string to nil, meaning absent.
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// stringMap converts metadata to a generic JSON object for diffing.
+func stringMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// summarize lists output types and destinations, e.g. "[task→writing task→summary]".
+func summarize(outputs []Envelope) string {
+	parts := make([]string, len(outputs))
+	for i, env := range outputs {
+		parts[i] = string(env.Type)
+		if env.Destination != "" {
+			parts[i] += "→" + env.Destination
+		}
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func unmarshalOptional(raw json.RawMessage, v *any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
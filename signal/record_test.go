@@ -0,0 +1,157 @@
+package signal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type routeDecision struct {
+	Workers []string `json:"workers"`
+	Reason  string   `json:"reason"`
+	TaskID  string   `json:"task_id"`
+}
+
+// newRoutingAgent returns a coordinator that routes by keyword; the
+// version changes which keywords go where.
+func newRoutingAgent(version int) Agent {
+	return NewAgentFunc("coordinator", func(ctx context.Context, sig *Signal) AgentResult {
+		text := sig.Payload.(string)
+		if text == "crash" {
+			return Err(errors.New("cannot route"))
+		}
+		worker := "writing"
+		if strings.Contains(text, "summarize") || (version == 2 && strings.Contains(text, "short")) {
+			worker = "summary"
+		}
+		decision := routeDecision{Workers: []string{worker}, Reason: "keyword", TaskID: sig.ID}
+		return OK(sig.Derive("task", decision).WithDestination(worker).WithMetadata("task_id", sig.ID))
+	})
+}
+
+func recordTraffic(t *testing.T, requests ...string) *bytes.Buffer {
+	t.Helper()
+	router := NewRouter()
+	router.Register(newRoutingAgent(1))
+	router.Register(NewAgentFunc("writing", func(ctx context.Context, sig *Signal) AgentResult { return OK() }))
+	router.Register(NewAgentFunc("summary", func(ctx context.Context, sig *Signal) AgentResult { return OK() }))
+	router.AddRule(func(sig *Signal) []string {
+		if sig.Type == "request" {
+			return []string{"coordinator"}
+		}
+		return nil
+	})
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+
+	var recording bytes.Buffer
+	recorder := NewRecorder(&recording, nil)
+	stop := engine.Record(recorder)
+	for _, r := range requests {
+		engine.Submit(NewSignal("request", r))
+	}
+	want := 2 * len(requests)
+	for _, r := range requests {
+		if r == "crash" {
+			want--
+		}
+	}
+	waitFor(t, func() bool { return recorder.Count() == want })
+	engine.Stop()
+	if err := stop(); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+	return &recording
+}
+
+func TestRecorderWritesExchanges(t *testing.T) {
+	recording := recordTraffic(t, "write a poem", "crash")
+
+	exchanges, err := ReadExchanges(recording)
+	if err != nil {
+		t.Fatalf("ReadExchanges() error = %v", err)
+	}
+	var coordinator []Exchange
+	for _, ex := range exchanges {
+		if ex.Agent == "coordinator" {
+			coordinator = append(coordinator, ex)
+		}
+	}
+	if len(exchanges) != 3 || len(coordinator) != 2 {
+		t.Fatalf("Exchanges = %+v, want 2 coordinator calls and 1 worker call", exchanges)
+	}
+	for _, ex := range coordinator {
+		switch {
+		case ex.Error != "":
+			if ex.Error != "cannot route" || len(ex.Outputs) != 0 {
+				t.Errorf("Failed exchange = %+v", ex)
+			}
+		case len(ex.Outputs) != 1 || ex.Outputs[0].Source != "coordinator" || ex.Outputs[0].Destination != "writing":
+			t.Errorf("Exchange outputs = %+v, want one task for writing", ex.Outputs)
+		case ex.Input.Destination != "coordinator" || ex.Input.Type != "request":
+			t.Errorf("Exchange input = %+v", ex.Input)
+		}
+	}
+}
+
+func TestReplayDiffsNewAgentVersion(t *testing.T) {
+	recording := recordTraffic(t, "summarize this", "keep it short", "write a poem")
+
+	report, err := Replay(context.Background(), recording, ReplayConfig{
+		Agents: []Agent{newRoutingAgent(2)},
+		Ignore: []string{"payload.task_id", "metadata.task_id"},
+	})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if report.Replayed != 3 || report.Skipped != 3 || report.Unchanged != 2 || len(report.Diffs) != 1 {
+		t.Fatalf("Report = %s", report)
+	}
+
+	diff := report.Diffs[0]
+	want := []Change{
+		{Path: "outputs[0].destination", Recorded: "writing", Replayed: "summary"},
+		{Path: "outputs[0].payload.workers[0]", Recorded: "writing", Replayed: "summary"},
+	}
+	if diff.Agent != "coordinator" || len(diff.Changes) != len(want) {
+		t.Fatalf("Diff = %+v, want %v", diff, want)
+	}
+	for i, c := range diff.Changes {
+		if c != want[i] {
+			t.Errorf("Change %d = %v, want %v", i, c, want[i])
+		}
+	}
+	if !strings.Contains(report.String(), "outputs[0].destination: writing → summary") {
+		t.Errorf("String() = %s", report)
+	}
+}
+
+func TestReplayReportsErrorsAndCounts(t *testing.T) {
+	recording := recordTraffic(t, "crash", "write")
+
+	fixed := NewAgentFunc("coordinator", func(ctx context.Context, sig *Signal) AgentResult {
+		return OK(sig.Derive("task", nil), sig.Derive("task", nil))
+	})
+	report, err := Replay(context.Background(), recording, ReplayConfig{Agents: []Agent{fixed}})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if len(report.Diffs) != 2 {
+		t.Fatalf("Report = %s", report)
+	}
+	for _, d := range report.Diffs {
+		paths := make([]string, len(d.Changes))
+		for i, c := range d.Changes {
+			paths[i] = c.Path
+		}
+		joined := strings.Join(paths, " ")
+		if !strings.Contains(joined, "outputs") {
+			t.Errorf("Changes %v should include the output count", joined)
+		}
+		if d.Changes[0].Path == "error" && d.Changes[0].Replayed != nil {
+			t.Errorf("Error change = %v, want the error to disappear", d.Changes[0])
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// REMOTE AGENT: Proxy for an Agent in Another Process
+// =============================================================================
+
+// RemoteConfig configures a RemoteAgent.
+type RemoteConfig struct {
+	// Address of the Server exposing the agent.
+	Address string
+
+	// RemoteID is the agent's ID on the server. Defaults to the local ID.
+	RemoteID string
+
+	// Transport defaults to TCPTransport().
+	Transport Transport
+
+	// Codec encodes signals and decodes outputs. Defaults to a codec
+	// without registered types, so output payloads arrive as generic JSON.
+	Codec *Codec
+
+	// DialTimeout bounds each connection attempt. Defaults to 5 seconds.
+	DialTimeout time.Duration
+
+	// HeartbeatInterval is the time between pings. Defaults to 5 seconds.
+	HeartbeatInterval time.Duration
+
+	// HeartbeatTimeout drops a connection that receives nothing for this
+	// long. Defaults to three heartbeat intervals.
+	HeartbeatTimeout time.Duration
+
+	// ReconnectMin and ReconnectMax bound the exponential backoff between
+	// connection attempts. Default to 100ms and 10 seconds.
+	ReconnectMin time.Duration
+	ReconnectMax time.Duration
+
+	// MaxFrameBytes bounds the size of one incoming frame; a larger one
+	// breaks the connection. Defaults to 16 MiB.
+	MaxFrameBytes int
+}
+
+// remoteReply is the outcome of one call: a result frame or a broken
+// connection.
+type remoteReply struct {
+	frame frame
+	err   error
+}
+
+// RemoteAgent is a local stand-in for an agent exposed by a Server in
+// another process. Registered in a local Router, it forwards each Process
+// call over the transport and returns the remote result; output signals are
+// then routed by the local Engine as if the agent were local.
+//
+// The connection is opened on Init (or the first Process call), kept alive
+// with heartbeats and re-established with backoff when it breaks. Process
+// waits for a connection within its context deadline; calls in flight when
+// a connection breaks fail with ErrConnectionLost.
+type RemoteAgent struct {
+	id     string
+	config RemoteConfig
+
+	mu        sync.Mutex
+	conn      *wireConn
+	connected chan struct{} // Closed while conn is set
+	lastErr   error
+	pending   map[uint64]chan remoteReply
+	seq       uint64
+	stop      chan struct{} // nil until started; closed by Close
+	loop      sync.WaitGroup
+}
+
+// NewRemoteAgent creates a proxy for the agent exposed at config.Address.
+func NewRemoteAgent(id string, config RemoteConfig) *RemoteAgent {
+	if config.RemoteID == "" {
+		config.RemoteID = id
+	}
+	if config.Transport == nil {
+		config.Transport = TCPTransport()
+	}
+	if config.Codec == nil {
+		config.Codec = NewCodec()
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 5 * time.Second
+	}
+	if config.HeartbeatTimeout <= 0 {
+		config.HeartbeatTimeout = 3 * config.HeartbeatInterval
+	}
+	if config.ReconnectMin <= 0 {
+		config.ReconnectMin = 100 * time.Millisecond
+	}
+	if config.ReconnectMax < config.ReconnectMin {
+		config.ReconnectMax = max(10*time.Second, config.ReconnectMin)
+	}
+	if config.MaxFrameBytes <= 0 {
+		config.MaxFrameBytes = defaultMaxFrame
+	}
+	return &RemoteAgent{
+		id:        id,
+		config:    config,
+		connected: make(chan struct{}),
+		pending:   make(map[uint64]chan remoteReply),
+	}
+}
+
+// ID returns the agent's unique identifier.
+func (r *RemoteAgent) ID() string {
+	return r.id
+}
+
+// Init starts connecting in the background. An unreachable server is not
+// an error: Process waits for the connection and Health reports it.
+func (r *RemoteAgent) Init(ctx context.Context) error {
+	r.start()
+	return nil
+}
+
+// Close drops the connection and stops reconnecting. Calls in flight fail
+// with ErrConnectionLost. A later Init or Process connects again.
+func (r *RemoteAgent) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if r.stop == nil {
+		r.mu.Unlock()
+		return nil
+	}
+	close(r.stop)
+	r.stop = nil
+	if r.conn != nil {
+		r.conn.close()
+	}
+	r.mu.Unlock()
+
+	r.loop.Wait()
+	return nil
+}
+
+// Health reports an error while the agent is not connected.
+func (r *RemoteAgent) Health(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn != nil {
+		return nil
+	}
+	if r.lastErr != nil {
+		return fmt.Errorf("not connected to %s: %w", r.config.Address, r.lastErr)
+	}
+	return fmt.Errorf("not connected to %s", r.config.Address)
+}
+
+// Process forwards the signal to the remote agent and returns its result.
+func (r *RemoteAgent) Process(ctx context.Context, signal *Signal) AgentResult {
+	r.start()
+
+	// Addressed to the remote agent, so a signature binds the call to it
+	env, err := r.config.Codec.Wrap(signal.WithDestination(r.config.RemoteID))
+	if err != nil {
+		return Err(err)
+	}
+	conn, err := r.connection(ctx)
+	if err != nil {
+		return Err(fmt.Errorf("remote agent '%s': %w", r.id, err))
+	}
+
+	call := frame{Kind: frameCall, Agent: r.config.RemoteID, Signal: &env}
+	if deadline, ok := ctx.Deadline(); ok {
+		call.Timeout = time.Until(deadline)
+	}
+	replies := make(chan remoteReply, 1)
+	r.mu.Lock()
+	r.seq++
+	call.Seq = r.seq
+	r.pending[call.Seq] = replies
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, call.Seq)
+		r.mu.Unlock()
+	}()
+
+	if err := conn.send(call); err != nil {
+		conn.close() // The read loop fails pending calls and reconnects
+		return Err(fmt.Errorf("remote agent '%s': %w", r.id, err))
+	}
+
+	select {
+	case reply := <-replies:
+		if reply.err != nil {
+			return Err(fmt.Errorf("remote agent '%s': %w", r.id, reply.err))
+		}
+		return r.result(reply.frame)
+	case <-ctx.Done():
+		return Err(fmt.Errorf("remote agent '%s': %w", r.id, ctx.Err()))
+	}
+}
+
+// result converts a result frame to an AgentResult.
+func (r *RemoteAgent) result(f frame) AgentResult {
+	if f.Error != "" {
+		return Err(&RemoteError{Agent: r.config.RemoteID, Message: f.Error})
+	}
+	outputs := make([]*Signal, 0, len(f.Signals))
+	for _, env := range f.Signals {
+		out, err := r.config.Codec.Unwrap(env)
+		if err != nil {
+			return Err(fmt.Errorf("remote agent '%s': %w", r.id, err))
+		}
+		outputs = append(outputs, out)
+	}
+	for i, out := range outputs {
+		if err := r.config.Codec.Accept(out); err != nil {
+			for _, accepted := range outputs[:i] {
+				r.config.Codec.Forget(accepted)
+			}
+			return Err(fmt.Errorf("remote agent '%s': %w", r.id, err))
+		}
+	}
+	return OK(outputs...)
+}
+
+// start launches the connection loop unless it is running.
+func (r *RemoteAgent) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.loop.Add(1)
+	go r.run(r.stop)
+}
+
+// connection waits until the agent is connected.
+func (r *RemoteAgent) connection(ctx context.Context) (*wireConn, error) {
+	for {
+		r.mu.Lock()
+		conn, connected, stop := r.conn, r.connected, r.stop
+		r.mu.Unlock()
+		if conn != nil {
+			return conn, nil
+		}
+		if stop == nil {
+			return nil, errors.New("closed")
+		}
+		select {
+		case <-connected:
+		case <-stop:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("not connected to %s: %w", r.config.Address, ctx.Err())
+		}
+	}
+}
+
+// run connects, serves the connection until it breaks, and reconnects with
+// exponential backoff until stopped.
+func (r *RemoteAgent) run(stop chan struct{}) {
+	defer r.loop.Done()
+	backoff := r.config.ReconnectMin
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), r.config.DialTimeout)
+		go func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		conn, err := r.config.Transport.Dial(ctx, r.config.Address)
+		cancel()
+
+		if err == nil {
+			backoff = r.config.ReconnectMin
+			err = r.serve(newWireConn(conn, r.config.HeartbeatTimeout, r.config.MaxFrameBytes), stop)
+		}
+
+		r.mu.Lock()
+		r.lastErr = err
+		r.mu.Unlock()
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, r.config.ReconnectMax)
+	}
+}
+
+// serve publishes a connection, reads results and sends heartbeats until
+// the connection breaks or the agent is closed.
+func (r *RemoteAgent) serve(conn *wireConn, stop chan struct{}) error {
+	r.mu.Lock()
+	select {
+	case <-stop:
+		r.mu.Unlock()
+		conn.close()
+		return nil
+	default:
+	}
+	r.conn = conn
+	close(r.connected)
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(r.config.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if conn.send(frame{Kind: framePing}) != nil {
+					conn.close()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var err error
+	for {
+		var f frame
+		if f, err = conn.receive(); err != nil {
+			break
+		}
+		if f.Kind != frameResult {
+			continue // Pongs only refresh the read deadline
+		}
+		r.mu.Lock()
+		replies, ok := r.pending[f.Seq]
+		r.mu.Unlock()
+		if ok {
+			replies <- remoteReply{frame: f}
+		}
+	}
+	close(done)
+	conn.close()
+
+	r.mu.Lock()
+	r.conn = nil
+	r.connected = make(chan struct{})
+	for _, replies := range r.pending {
+		select {
+		case replies <- remoteReply{err: ErrConnectionLost}:
+		default:
+		}
+	}
+	r.mu.Unlock()
+	return err
+}
@@ -0,0 +1,231 @@
+package signal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// SCHEDULING: Delayed and Recurring Submission
+// =============================================================================
+
+// MetaScheduleID is set on every signal submitted by a schedule.
+const MetaScheduleID = "schedule_id"
+
+// ScheduleInfo describes a pending schedule.
+type ScheduleInfo struct {
+	ID     string
+	Spec   string     // Recurrence spec; empty for one-shot submissions
+	Type   SignalType // Type of the scheduled signal
+	Next   time.Time  // Next firing time
+	Runs   int        // Number of times the schedule has fired
+	Paused bool       // True while the engine is stopped
+}
+
+// schedule is one delayed or recurring submission.
+type schedule struct {
+	id     string
+	spec   string
+	every  recurrence // nil for one-shot submissions
+	signal *Signal    // Template of the submitted signal
+	next   time.Time
+	runs   int
+	timer  Timer
+}
+
+// scheduler holds an engine's schedules. Timers are armed only while the
+// engine runs; Stop disarms them and Start re-arms them, so schedules
+// survive restarts.
+type scheduler struct {
+	mu      sync.Mutex
+	clock   Clock
+	entries map[string]*schedule
+	armed   bool
+	seq     uint64
+}
+
+func newScheduler(clock Clock) *scheduler {
+	return &scheduler{clock: clock, entries: make(map[string]*schedule)}
+}
+
+// SubmitAt schedules a signal for submission at time t and returns the
+// schedule ID. A time in the past submits on the next clock tick.
+// Signals may be scheduled while the engine is stopped; they are submitted
+// once it starts.
+func (e *Engine) SubmitAt(signal *Signal, t time.Time) (string, error) {
+	return e.addSchedule(&schedule{signal: signal, next: t})
+}
+
+// SubmitAfter schedules a signal for submission after delay d.
+func (e *Engine) SubmitAfter(signal *Signal, d time.Duration) (string, error) {
+	return e.SubmitAt(signal, e.scheduler.clock.Now().Add(d))
+}
+
+// Schedule submits a copy of the signal (with a fresh ID) on a recurring
+// schedule until it is cancelled. spec is a five-field cron expression
+// ("*/15 * * * *"), a descriptor ("@hourly", "@daily", "@weekly",
+// "@monthly", "@yearly") or "@every <duration>". Cron times are evaluated
+// in the location of the clock's Now. Firings missed while the engine is
+// stopped are skipped.
+func (e *Engine) Schedule(spec string, signal *Signal) (string, error) {
+	every, err := parseSchedule(spec)
+	if err != nil {
+		return "", err
+	}
+	next := every.next(e.scheduler.clock.Now())
+	if next.IsZero() {
+		return "", fmt.Errorf("schedule '%s' never fires", spec)
+	}
+	return e.addSchedule(&schedule{spec: spec, every: every, signal: signal, next: next})
+}
+
+// CancelSchedule removes a schedule. It reports whether the schedule existed.
+func (e *Engine) CancelSchedule(id string) bool {
+	sc := e.scheduler
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	s, ok := sc.entries[id]
+	if !ok {
+		return false
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	delete(sc.entries, id)
+	return true
+}
+
+// Schedules lists pending schedules ordered by next firing time.
+func (e *Engine) Schedules() []ScheduleInfo {
+	sc := e.scheduler
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	infos := make([]ScheduleInfo, 0, len(sc.entries))
+	for _, s := range sc.entries {
+		infos = append(infos, ScheduleInfo{
+			ID:     s.id,
+			Spec:   s.spec,
+			Type:   s.signal.Type,
+			Next:   s.next,
+			Runs:   s.runs,
+			Paused: !sc.armed,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Next.Equal(infos[j].Next) {
+			return infos[i].ID < infos[j].ID
+		}
+		return infos[i].Next.Before(infos[j].Next)
+	})
+	return infos
+}
+
+// addSchedule registers a schedule and arms it if the engine is running.
+func (e *Engine) addSchedule(s *schedule) (string, error) {
+	if s.signal == nil {
+		return "", fmt.Errorf("cannot schedule a nil signal")
+	}
+	if err := e.validate(s.signal); err != nil {
+		return "", err
+	}
+	sc := e.scheduler
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.seq++
+	s.id = fmt.Sprintf("sched-%d", sc.seq)
+	sc.entries[s.id] = s
+	if sc.armed {
+		e.armSchedule(s)
+	}
+	return s.id, nil
+}
+
+// armSchedules starts the timers of every schedule. Recurring schedules
+// that fell behind while stopped resume at their next firing after now.
+func (e *Engine) armSchedules() {
+	sc := e.scheduler
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.armed = true
+	now := sc.clock.Now()
+	for id, s := range sc.entries {
+		if s.every != nil && s.next.Before(now) {
+			s.next = s.every.next(now)
+			if s.next.IsZero() {
+				delete(sc.entries, id)
+				continue
+			}
+		}
+		e.armSchedule(s)
+	}
+}
+
+// disarmSchedules stops every timer, keeping the schedules.
+func (e *Engine) disarmSchedules() {
+	sc := e.scheduler
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.armed = false
+	for _, s := range sc.entries {
+		if s.timer != nil {
+			s.timer.Stop()
+			s.timer = nil
+		}
+	}
+}
+
+// armSchedule starts the timer of one schedule. Caller must hold the
+// scheduler lock.
+func (e *Engine) armSchedule(s *schedule) {
+	sc := e.scheduler
+	s.timer = sc.clock.AfterFunc(s.next.Sub(sc.clock.Now()), func() {
+		e.fireSchedule(s)
+	})
+}
+
+// fireSchedule submits a schedule's signal and re-arms recurring schedules.
+// A one-shot submission that fails because the engine stopped is kept for
+// the next Start; one that fails otherwise (e.g. an invalid payload) is
+// dropped, since retrying cannot succeed.
+func (e *Engine) fireSchedule(s *schedule) {
+	sc := e.scheduler
+	sc.mu.Lock()
+	if sc.entries[s.id] != s || !sc.armed {
+		sc.mu.Unlock()
+		return // Cancelled or disarmed after the timer fired
+	}
+	s.timer = nil
+	s.runs++
+
+	sig := s.signal.WithMetadata(MetaScheduleID, s.id)
+	if s.every != nil {
+		sig.ID = e.factory.NewID()
+		sig.Timestamp = e.factory.Now()
+		s.next = s.every.next(s.next)
+		if s.next.IsZero() {
+			delete(sc.entries, s.id)
+		} else {
+			e.armSchedule(s)
+		}
+	} else {
+		delete(sc.entries, s.id)
+	}
+	sc.mu.Unlock()
+
+	if err := e.Submit(sig); err != nil {
+		if s.every == nil && errors.Is(err, ErrNotRunning) {
+			// Keep the one-shot submission for the next Start
+			sc.mu.Lock()
+			s.runs--
+			sc.entries[s.id] = s
+			if sc.armed {
+				e.armSchedule(s)
+			}
+			sc.mu.Unlock()
+		}
+		e.reportError(sig, "", fmt.Errorf("scheduled submission failed: %w", err))
+	}
+}
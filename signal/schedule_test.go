@@ -0,0 +1,235 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var scheduleEpoch = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC) // A Monday
+
+// newScheduledEngine starts an engine on a manual clock whose "sink" agent
+// forwards every received signal to the returned channel.
+func newScheduledEngine(t *testing.T) (*Engine, *ManualClock, chan *Signal) {
+	t.Helper()
+	received := make(chan *Signal, 16)
+	router := NewRouter()
+	router.Register(NewAgentFunc("sink", func(ctx context.Context, sig *Signal) AgentResult {
+		received <- sig
+		return OK()
+	}))
+
+	clock := NewManualClock(scheduleEpoch)
+	config := DefaultConfig()
+	config.Clock = clock
+	engine := NewEngine(config, router)
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { engine.Stop() })
+	return engine, clock, received
+}
+
+func expectSignal(t *testing.T, received <-chan *Signal) *Signal {
+	t.Helper()
+	select {
+	case sig := <-received:
+		return sig
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for a scheduled signal")
+		return nil
+	}
+}
+
+func expectNoSignal(t *testing.T, received <-chan *Signal) {
+	t.Helper()
+	select {
+	case sig := <-received:
+		t.Fatalf("Unexpected signal %v", sig)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestSubmitAfter(t *testing.T) {
+	engine, clock, received := newScheduledEngine(t)
+
+	sig := NewSignal("reminder", "stand-up").WithDestination("sink")
+	id, err := engine.SubmitAfter(sig, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("SubmitAfter() error = %v", err)
+	}
+
+	clock.Advance(9 * time.Minute)
+	expectNoSignal(t, received)
+
+	clock.Advance(time.Minute)
+	got := expectSignal(t, received)
+	if got.ID != sig.ID || got.Metadata[MetaScheduleID] != id {
+		t.Errorf("Submitted %v (schedule %q), want original signal from %s", got, got.Metadata[MetaScheduleID], id)
+	}
+	if n := len(engine.Schedules()); n != 0 {
+		t.Errorf("Schedules() has %d entries after firing, want 0", n)
+	}
+}
+
+func TestSubmitAtAndCancel(t *testing.T) {
+	engine, clock, received := newScheduledEngine(t)
+
+	keep, _ := engine.SubmitAt(NewSignal("keep", nil).WithDestination("sink"), scheduleEpoch.Add(time.Hour))
+	drop, _ := engine.SubmitAt(NewSignal("drop", nil).WithDestination("sink"), scheduleEpoch.Add(30*time.Minute))
+
+	schedules := engine.Schedules()
+	if len(schedules) != 2 || schedules[0].ID != drop || schedules[1].ID != keep {
+		t.Fatalf("Schedules() = %+v, want drop then keep", schedules)
+	}
+	if !engine.CancelSchedule(drop) {
+		t.Fatal("CancelSchedule() = false, want true")
+	}
+	if engine.CancelSchedule(drop) {
+		t.Error("CancelSchedule() of a cancelled schedule = true")
+	}
+
+	clock.Advance(2 * time.Hour)
+	if got := expectSignal(t, received); got.Type != "keep" {
+		t.Errorf("Submitted %s, want keep", got.Type)
+	}
+	expectNoSignal(t, received)
+}
+
+func TestScheduleRecurring(t *testing.T) {
+	engine, clock, received := newScheduledEngine(t)
+
+	id, err := engine.Schedule("*/15 * * * *", NewSignal("poll", nil).WithDestination("sink"))
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 1; i <= 3; i++ {
+		clock.Advance(15 * time.Minute)
+		sig := expectSignal(t, received)
+		if seen[sig.ID] {
+			t.Errorf("Run %d reused signal ID %s", i, sig.ID)
+		}
+		seen[sig.ID] = true
+		if want := scheduleEpoch.Add(time.Duration(i) * 15 * time.Minute); !sig.Timestamp.Equal(want) {
+			t.Errorf("Run %d timestamp = %v, want %v", i, sig.Timestamp, want)
+		}
+	}
+
+	info := engine.Schedules()[0]
+	if info.ID != id || info.Runs != 3 || !info.Next.Equal(scheduleEpoch.Add(time.Hour)) {
+		t.Errorf("ScheduleInfo = %+v, want 3 runs, next at 10:00", info)
+	}
+}
+
+func TestSchedulesSurviveRestart(t *testing.T) {
+	engine, clock, received := newScheduledEngine(t)
+
+	engine.SubmitAfter(NewSignal("once", nil).WithDestination("sink"), time.Minute)
+	engine.Schedule("@every 10m", NewSignal("tick", nil).WithDestination("sink"))
+
+	engine.Stop()
+	if schedules := engine.Schedules(); len(schedules) != 2 || !schedules[0].Paused {
+		t.Fatalf("Schedules() after Stop = %+v, want 2 paused", schedules)
+	}
+	if engine.Stats().Schedules != 2 {
+		t.Errorf("Stats().Schedules = %d, want 2", engine.Stats().Schedules)
+	}
+
+	// Nothing fires while stopped
+	clock.Advance(25 * time.Minute)
+	expectNoSignal(t, received)
+
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// The missed one-shot fires at once; missed recurring runs are skipped
+	clock.Advance(0)
+	if got := expectSignal(t, received); got.Type != "once" {
+		t.Errorf("Submitted %s, want once", got.Type)
+	}
+	expectNoSignal(t, received)
+
+	clock.Advance(10 * time.Minute)
+	if got := expectSignal(t, received); got.Type != "tick" {
+		t.Errorf("Submitted %s, want tick", got.Type)
+	}
+}
+
+func TestScheduleWhileStopped(t *testing.T) {
+	engine, clock, received := newScheduledEngine(t)
+	engine.Stop()
+
+	if _, err := engine.SubmitAfter(NewSignal("later", nil).WithDestination("sink"), time.Minute); err != nil {
+		t.Fatalf("SubmitAfter() while stopped error = %v", err)
+	}
+	engine.Start()
+	clock.Advance(time.Minute)
+	expectSignal(t, received)
+}
+
+func TestScheduleDropsRejectedSubmission(t *testing.T) {
+	registry := NewSchemaRegistry()
+	clock := NewManualClock(scheduleEpoch)
+	config := DefaultConfig()
+	config.Clock = clock
+	config.Schemas = registry
+	engine := NewEngine(config, NewRouter())
+	errs := make(chan error, 4)
+	engine.OnError(func(sig *Signal, err error) { errs <- err })
+	engine.Start()
+	defer engine.Stop()
+
+	if _, err := engine.SubmitAfter(NewSignal("task", map[string]any{}), time.Minute); err != nil {
+		t.Fatalf("SubmitAfter() error = %v", err)
+	}
+	// The payload no longer validates once the schema is registered
+	registry.Register("task", Schema{JSON: taskSchema})
+	clock.Advance(time.Minute)
+
+	var invalid *ValidationError
+	if err := <-errs; !errors.As(err, &invalid) {
+		t.Errorf("Error = %v, want a *ValidationError", err)
+	}
+	if n := len(engine.Schedules()); n != 0 || clock.Pending() != 0 {
+		t.Errorf("Schedules() = %d, pending timers = %d; want the submission dropped", n, clock.Pending())
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	from := scheduleEpoch // Monday 09:00
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", from.Add(15 * time.Minute)},
+		{"30 9 * * *", from.Add(30 * time.Minute)},
+		{"0 8 * * *", from.Add(23 * time.Hour)},
+		{"0 9 * * 1-5", from.Add(24 * time.Hour)},
+		{"0 0 * * 7", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 * *", time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)}, // Friday or the 13th
+		{"@hourly", from.Add(time.Hour)},
+		{"@daily", time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			r, err := parseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("parseSchedule() error = %v", err)
+			}
+			if got := r.next(from); !got.Equal(tt.want) {
+				t.Errorf("next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "* * * *", "61 * * * *", "*/0 * * * *", "5-1 * * * *", "@every -1s", "@sometimes"} {
+		if _, err := parseSchedule(bad); err == nil {
+			t.Errorf("parseSchedule(%q) error = nil, want error", bad)
+		}
+	}
+}
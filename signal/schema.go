@@ -0,0 +1,614 @@
+package signal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// SCHEMAS: Payload Contracts per Signal Type
+// =============================================================================
+
+// Schema describes the payload a signal type must carry. Either check may
+// be used alone; with both, a payload must pass both.
+type Schema struct {
+	// Prototype requires payloads of exactly its Go type, as in
+	// Codec.Register: Task{} requires Task values, &Task{} *Task values.
+	Prototype any
+
+	// JSON is a JSON Schema the payload must satisfy once encoded with
+	// encoding/json. The supported keywords are type, properties,
+	// required, additionalProperties, items, enum, const, minLength,
+	// maxLength, pattern, minimum, maximum, exclusiveMinimum,
+	// exclusiveMaximum, minItems and maxItems; annotations such as title,
+	// description, format and default are ignored. Other keywords are
+	// rejected by Register.
+	JSON string
+
+	// Description documents the signal type in Export.
+	Description string
+}
+
+// FieldError is one validation failure. Path locates the value in the
+// payload, such as "payload", "payload.workers" or "payload.items[2].id".
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (f FieldError) String() string {
+	return f.Path + ": " + f.Message
+}
+
+// ValidationError rejects a signal whose payload violates the schema of its
+// type. Fields lists every violation found, in path order.
+type ValidationError struct {
+	SignalID string
+	Type     SignalType
+	Fields   []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = f.String()
+	}
+	return fmt.Sprintf("invalid payload for signal type '%s' (id=%s): %s",
+		e.Type, truncateID(e.SignalID), strings.Join(fields, "; "))
+}
+
+// SchemaRegistry holds the payload schemas of signal types. Signals of
+// unregistered types are not checked. Set EngineConfig.Schemas to validate
+// every submitted and emitted signal. A SchemaRegistry is safe for
+// concurrent use.
+type SchemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[SignalType]*registeredSchema
+}
+
+type registeredSchema struct {
+	schema Schema
+	goType reflect.Type // nil without a prototype
+	json   *jsonSchema  // nil without a JSON Schema
+}
+
+// NewSchemaRegistry creates an empty registry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: make(map[SignalType]*registeredSchema)}
+}
+
+// Register sets the schema of a signal type, replacing any previous one.
+// It fails if the JSON Schema is malformed or uses unsupported keywords.
+func (r *SchemaRegistry) Register(signalType SignalType, schema Schema) error {
+	reg := &registeredSchema{schema: schema}
+	if schema.Prototype != nil {
+		reg.goType = reflect.TypeOf(schema.Prototype)
+	}
+	if schema.JSON != "" {
+		var doc any
+		if err := json.Unmarshal([]byte(schema.JSON), &doc); err != nil {
+			return fmt.Errorf("schema of signal type '%s': %w", signalType, err)
+		}
+		compiled, err := compileJSONSchema(doc, "#")
+		if err != nil {
+			return fmt.Errorf("schema of signal type '%s': %w", signalType, err)
+		}
+		reg.json = compiled
+	}
+	if reg.goType == nil && reg.json == nil {
+		return fmt.Errorf("schema of signal type '%s' has neither a prototype nor a JSON Schema", signalType)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[signalType] = reg
+	return nil
+}
+
+// Remove deletes the schema of a signal type.
+func (r *SchemaRegistry) Remove(signalType SignalType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.schemas, signalType)
+}
+
+// Types returns the signal types with a schema, sorted.
+func (r *SchemaRegistry) Types() []SignalType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]SignalType, 0, len(r.schemas))
+	for t := range r.schemas {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Validate checks a signal's payload against the schema of its type and
+// returns a *ValidationError listing the violations, or nil.
+func (r *SchemaRegistry) Validate(signal *Signal) error {
+	r.mu.RLock()
+	reg, ok := r.schemas[signal.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	var fields []FieldError
+	if reg.goType != nil {
+		if got := reflect.TypeOf(signal.Payload); got != reg.goType {
+			fields = append(fields, FieldError{Path: "payload",
+				Message: fmt.Sprintf("expected Go type %s, got %s", reg.goType, goTypeName(got))})
+		}
+	}
+	if reg.json != nil && len(fields) == 0 {
+		value, err := encodeGeneric(signal.Payload)
+		if err != nil {
+			fields = append(fields, FieldError{Path: "payload", Message: err.Error()})
+		} else {
+			fields = reg.json.validate(value, "payload", fields)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	return &ValidationError{SignalID: signal.ID, Type: signal.Type, Fields: fields}
+}
+
+// SchemaDoc documents the payload of one signal type.
+type SchemaDoc struct {
+	Type        SignalType      `json:"type"`
+	Description string          `json:"description,omitempty"`
+	GoType      string          `json:"go_type,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+}
+
+// Export documents every registered schema, sorted by signal type. Types
+// registered with only a prototype get a JSON Schema generated from the Go
+// type (json tags name properties; fields without omitempty are required).
+func (r *SchemaRegistry) Export() []SchemaDoc {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	docs := make([]SchemaDoc, 0, len(r.schemas))
+	for t, reg := range r.schemas {
+		doc := SchemaDoc{Type: t, Description: reg.schema.Description}
+		if reg.goType != nil {
+			doc.GoType = reg.goType.String()
+		}
+		if reg.json != nil {
+			doc.Schema = json.RawMessage(reg.schema.JSON)
+		} else {
+			doc.Schema, _ = json.Marshal(schemaOfType(reg.goType, make(map[reflect.Type]bool)))
+		}
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Type < docs[j].Type })
+	return docs
+}
+
+// validate checks a signal against the engine's schemas, if any.
+func (e *Engine) validate(signal *Signal) error {
+	if e.config.Schemas == nil {
+		return nil
+	}
+	return e.config.Schemas.Validate(signal)
+}
+
+func goTypeName(t reflect.Type) string {
+	if t == nil {
+		return "nil"
+	}
+	return t.String()
+}
+
+// encodeGeneric converts a payload to generic JSON values.
+func encodeGeneric(payload any) (any, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload: %w", err)
+	}
+	var value any
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// =============================================================================
+// JSON SCHEMA: The Supported Subset
+// =============================================================================
+
+// jsonSchema is a compiled JSON Schema.
+type jsonSchema struct {
+	types        []string
+	properties   map[string]*jsonSchema
+	required     []string
+	additional   *jsonSchema // Schema of properties not listed
+	noAdditional bool        // additionalProperties: false
+	items        *jsonSchema
+	enum         []any
+	hasConst     bool
+	constant     any
+	pattern      *regexp.Regexp
+
+	minLength, maxLength, minItems, maxItems             *int
+	minimum, maximum, exclusiveMinimum, exclusiveMaximum *float64
+}
+
+// annotationKeywords do not affect validation.
+var annotationKeywords = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "format": true, "readOnly": true, "writeOnly": true, "deprecated": true,
+}
+
+var jsonTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true, "number": true, "integer": true, "string": true,
+}
+
+// compileJSONSchema compiles a decoded schema document; at locates it for
+// error messages.
+func compileJSONSchema(doc any, at string) (*jsonSchema, error) {
+	if b, ok := doc.(bool); ok {
+		if b {
+			return &jsonSchema{}, nil
+		}
+		return &jsonSchema{types: []string{}}, nil // false: nothing is valid
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or boolean", at)
+	}
+
+	s := &jsonSchema{}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, where := obj[k], at+"/"+k
+		var err error
+		switch k {
+		case "type":
+			s.types, err = compileTypes(v, where)
+		case "properties":
+			props, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be an object", where)
+			}
+			s.properties = make(map[string]*jsonSchema, len(props))
+			for name, sub := range props {
+				if s.properties[name], err = compileJSONSchema(sub, where+"/"+name); err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			s.required, err = stringList(v, where)
+		case "additionalProperties":
+			if b, ok := v.(bool); ok && !b {
+				s.noAdditional = true
+			} else {
+				s.additional, err = compileJSONSchema(v, where)
+			}
+		case "items":
+			s.items, err = compileJSONSchema(v, where)
+		case "enum":
+			values, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be an array", where)
+			}
+			s.enum = values
+		case "const":
+			s.hasConst, s.constant = true, v
+		case "pattern":
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be a string", where)
+			}
+			if s.pattern, err = regexp.Compile(str); err != nil {
+				return nil, fmt.Errorf("%s: %w", where, err)
+			}
+		case "minLength":
+			s.minLength, err = count(v, where)
+		case "maxLength":
+			s.maxLength, err = count(v, where)
+		case "minItems":
+			s.minItems, err = count(v, where)
+		case "maxItems":
+			s.maxItems, err = count(v, where)
+		case "minimum":
+			s.minimum, err = number(v, where)
+		case "maximum":
+			s.maximum, err = number(v, where)
+		case "exclusiveMinimum":
+			s.exclusiveMinimum, err = number(v, where)
+		case "exclusiveMaximum":
+			s.exclusiveMaximum, err = number(v, where)
+		default:
+			if !annotationKeywords[k] {
+				return nil, fmt.Errorf("%s: unsupported keyword '%s'", at, k)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func compileTypes(v any, at string) ([]string, error) {
+	var types []string
+	switch t := v.(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		var err error
+		if types, err = stringList(t, at); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%s: must be a string or an array of strings", at)
+	}
+	for _, t := range types {
+		if !jsonTypes[t] {
+			return nil, fmt.Errorf("%s: unknown type '%s'", at, t)
+		}
+	}
+	return types, nil
+}
+
+func stringList(v any, at string) ([]string, error) {
+	values, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: must be an array of strings", at)
+	}
+	out := make([]string, len(values))
+	for i, value := range values {
+		if out[i], ok = value.(string); !ok {
+			return nil, fmt.Errorf("%s: must be an array of strings", at)
+		}
+	}
+	return out, nil
+}
+
+func count(v any, at string) (*int, error) {
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("%s: must be a non-negative integer", at)
+	}
+	n := int(f)
+	return &n, nil
+}
+
+func number(v any, at string) (*float64, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a number", at)
+	}
+	return &f, nil
+}
+
+// jsonTypeOf names the JSON type of a generic value.
+func jsonTypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// validate appends the violations of value, located at path, to fields.
+func (s *jsonSchema) validate(value any, path string, fields []FieldError) []FieldError {
+	fail := func(format string, args ...any) {
+		fields = append(fields, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.types != nil && !s.matchesType(value) {
+		if len(s.types) == 0 {
+			fail("no value is allowed")
+		} else {
+			fail("expected %s, got %s", strings.Join(s.types, " or "), jsonTypeOf(value))
+		}
+		return fields
+	}
+	if s.enum != nil && !containsValue(s.enum, value) {
+		fail("must be one of %s", mustJSON(s.enum))
+	}
+	if s.hasConst && !reflect.DeepEqual(s.constant, value) {
+		fail("must be %s", mustJSON(s.constant))
+	}
+
+	switch v := value.(type) {
+	case string:
+		n := len([]rune(v))
+		if s.minLength != nil && n < *s.minLength {
+			fail("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			fail("must be at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match pattern '%s'", s.pattern)
+		}
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			fail("must be at least %v", *s.minimum)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			fail("must be at most %v", *s.maximum)
+		}
+		if s.exclusiveMinimum != nil && v <= *s.exclusiveMinimum {
+			fail("must be greater than %v", *s.exclusiveMinimum)
+		}
+		if s.exclusiveMaximum != nil && v >= *s.exclusiveMaximum {
+			fail("must be less than %v", *s.exclusiveMaximum)
+		}
+	case []any:
+		if s.minItems != nil && len(v) < *s.minItems {
+			fail("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			fail("must have at most %d items", *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				fields = s.items.validate(item, fmt.Sprintf("%s[%d]", path, i), fields)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				fields = append(fields, FieldError{Path: path + "." + name, Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, listed := s.properties[name]
+			switch {
+			case listed:
+				fields = sub.validate(v[name], path+"."+name, fields)
+			case s.noAdditional:
+				fields = append(fields, FieldError{Path: path + "." + name, Message: "is not allowed"})
+			case s.additional != nil:
+				fields = s.additional.validate(v[name], path+"."+name, fields)
+			}
+		}
+	}
+	return fields
+}
+
+func (s *jsonSchema) matchesType(value any) bool {
+	actual := jsonTypeOf(value)
+	for _, t := range s.types {
+		if t == actual {
+			return true
+		}
+		if t == "integer" && actual == "number" {
+			if f := value.(float64); f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// =============================================================================
+// SCHEMA GENERATION: Documenting Go Payload Types
+// =============================================================================
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// schemaOfType describes the JSON encoding of a Go type. Types already
+// being described (recursive types) are left open.
+func schemaOfType(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string"} // Base64
+		}
+		return map[string]any{"type": "array", "items": schemaOfType(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOfType(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := make(map[string]any)
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() && !(f.Anonymous && f.Type.Kind() == reflect.Struct) {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if name == "" && f.Anonymous {
+				// Fields of untagged embedded structs are promoted
+				embedded := schemaOfType(f.Type, seen)
+				if props, ok := embedded["properties"].(map[string]any); ok {
+					for k, v := range props {
+						properties[k] = v
+					}
+					if req, ok := embedded["required"].([]string); ok {
+						required = append(required, req...)
+					}
+					continue
+				}
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = schemaOfType(f.Type, seen)
+			if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
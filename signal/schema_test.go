@@ -0,0 +1,256 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type schemaTask struct {
+	TaskID  string   `json:"task_id"`
+	Workers []string `json:"workers"`
+	Reason  string   `json:"reason,omitempty"`
+}
+
+const taskSchema = `{
+	"type": "object",
+	"description": "Work assignment",
+	"properties": {
+		"task_id": {"type": "string", "minLength": 1},
+		"workers": {"type": "array", "minItems": 1, "items": {"enum": ["writing", "summary"]}},
+		"reason":  {"type": "string", "maxLength": 10},
+		"priority": {"type": "integer", "minimum": 1, "maximum": 5}
+	},
+	"required": ["task_id", "workers"],
+	"additionalProperties": false
+}`
+
+// fieldPaths returns the paths of a validation error's fields.
+func fieldPaths(t *testing.T, err error) []string {
+	t.Helper()
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected a *ValidationError, got %v", err)
+	}
+	paths := make([]string, len(invalid.Fields))
+	for i, f := range invalid.Fields {
+		paths[i] = f.Path
+	}
+	return paths
+}
+
+func TestSchemaRegistryGoType(t *testing.T) {
+	registry := NewSchemaRegistry()
+	if err := registry.Register("task", Schema{Prototype: schemaTask{}}); err != nil {
+		t.Fatalf("Register error = %v", err)
+	}
+
+	if err := registry.Validate(NewSignal("task", schemaTask{TaskID: "t1"})); err != nil {
+		t.Errorf("Valid payload rejected: %v", err)
+	}
+	if err := registry.Validate(NewSignal("other", "anything")); err != nil {
+		t.Errorf("Unregistered types should not be checked: %v", err)
+	}
+	err := registry.Validate(NewSignal("task", &schemaTask{}))
+	if paths := fieldPaths(t, err); !reflect.DeepEqual(paths, []string{"payload"}) {
+		t.Errorf("Expected a payload error, got %v", paths)
+	}
+	if !strings.Contains(err.Error(), "expected Go type signal.schemaTask, got *signal.schemaTask") {
+		t.Errorf("Unexpected message: %v", err)
+	}
+}
+
+func TestSchemaRegistryJSONSchema(t *testing.T) {
+	registry := NewSchemaRegistry()
+	if err := registry.Register("task", Schema{JSON: taskSchema}); err != nil {
+		t.Fatalf("Register error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		payload any
+		paths   []string
+	}{
+		{"valid struct", schemaTask{TaskID: "t1", Workers: []string{"writing"}}, nil},
+		{"valid map", map[string]any{"task_id": "t1", "workers": []string{"summary"}, "priority": 3}, nil},
+		{"missing fields", map[string]any{"reason": "x"}, []string{"payload.task_id", "payload.workers"}},
+		{"empty values", schemaTask{Workers: []string{}}, []string{"payload.task_id", "payload.workers"}},
+		{"bad items", schemaTask{TaskID: "t1", Workers: []string{"writing", "coding"}}, []string{"payload.workers[1]"}},
+		{"too long", schemaTask{TaskID: "t1", Workers: []string{"writing"}, Reason: "far too long"}, []string{"payload.reason"}},
+		{"not integer", map[string]any{"task_id": "t1", "workers": []string{"writing"}, "priority": 2.5}, []string{"payload.priority"}},
+		{"out of range", map[string]any{"task_id": "t1", "workers": []string{"writing"}, "priority": 9}, []string{"payload.priority"}},
+		{"unknown field", map[string]any{"task_id": "t1", "workers": []string{"writing"}, "extra": true}, []string{"payload.extra"}},
+		{"wrong type", "t1", []string{"payload"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.Validate(NewSignal("task", tt.payload))
+			if tt.paths == nil {
+				if err != nil {
+					t.Errorf("Valid payload rejected: %v", err)
+				}
+				return
+			}
+			if paths := fieldPaths(t, err); !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("Expected fields %v, got %v (%v)", tt.paths, paths, err)
+			}
+		})
+	}
+}
+
+func TestSchemaRegistryRejectsInvalidSchemas(t *testing.T) {
+	registry := NewSchemaRegistry()
+	for _, schema := range []Schema{
+		{},
+		{JSON: `{"type": "object"`},
+		{JSON: `{"$ref": "#/definitions/task"}`},
+		{JSON: `{"type": "text"}`},
+		{JSON: `{"properties": {"n": {"minimum": "one"}}}`},
+		{JSON: `{"pattern": "("}`},
+	} {
+		if err := registry.Register("task", schema); err == nil {
+			t.Errorf("Register(%q) should fail", schema.JSON)
+		}
+	}
+	if len(registry.Types()) != 0 {
+		t.Errorf("Failed registrations should not be kept, got %v", registry.Types())
+	}
+}
+
+func TestEngineValidatesSubmittedAndEmittedSignals(t *testing.T) {
+	registry := NewSchemaRegistry()
+	registry.Register("task", Schema{Prototype: schemaTask{}, JSON: taskSchema})
+
+	var delivered atomic.Int32
+	router := NewRouter()
+	router.Register(NewAgentFunc("coordinator", func(ctx context.Context, sig *Signal) AgentResult {
+		return OK(sig.Derive("task", schemaTask{TaskID: sig.ID}).WithDestination("worker")) // No workers
+	}))
+	router.Register(NewAgentFunc("worker", func(ctx context.Context, sig *Signal) AgentResult {
+		delivered.Add(1)
+		return OK()
+	}))
+	router.AddRule(func(sig *Signal) []string {
+		if sig.Type == "request" {
+			return []string{"coordinator"}
+		}
+		return nil
+	})
+	config := DefaultConfig()
+	config.Schemas = registry
+	engine := NewEngine(config, router)
+	errs := make(chan error, 4)
+	engine.OnError(func(sig *Signal, err error) { errs <- err })
+	engine.Start()
+	defer engine.Stop()
+
+	err := engine.Submit(NewSignal("task", schemaTask{TaskID: "t1"}).WithDestination("worker"))
+	if paths := fieldPaths(t, err); !reflect.DeepEqual(paths, []string{"payload.workers"}) {
+		t.Errorf("Expected a workers error, got %v", paths)
+	}
+	if engine.TrySubmit(NewSignal("task", "t1")) {
+		t.Error("TrySubmit should reject an invalid payload")
+	}
+	if err := <-errs; !strings.Contains(err.Error(), "expected Go type") {
+		t.Errorf("TrySubmit rejection should be reported, got %v", err)
+	}
+	if _, err := engine.SubmitAfter(NewSignal("task", "t1"), 0); err == nil {
+		t.Error("SubmitAfter should reject an invalid payload")
+	}
+
+	engine.Submit(NewSignal("request", nil))
+	err = <-errs
+	if paths := fieldPaths(t, err); !reflect.DeepEqual(paths, []string{"payload.workers"}) {
+		t.Errorf("Expected the emitted signal to be rejected, got %v", err)
+	}
+	if delivered.Load() != 0 {
+		t.Error("Invalid signals should not be delivered")
+	}
+}
+
+type schemaBase struct {
+	ID string `json:"id"`
+}
+
+type schemaReport struct {
+	schemaBase
+	Scores  map[string]float64 `json:"scores,omitempty"`
+	Next    *schemaReport      `json:"next,omitempty"`
+	Ignored string             `json:"-"`
+}
+
+func TestSchemaRegistryExport(t *testing.T) {
+	registry := NewSchemaRegistry()
+	registry.Register("task", Schema{JSON: taskSchema, Description: "Work assignment"})
+	registry.Register("report", Schema{Prototype: &schemaReport{}})
+
+	docs := registry.Export()
+	if len(docs) != 2 || docs[0].Type != "report" || docs[1].Type != "task" {
+		t.Fatalf("Expected report and task docs, got %+v", docs)
+	}
+	if docs[1].Description != "Work assignment" || string(docs[1].Schema) != taskSchema {
+		t.Errorf("JSON Schemas should be exported as registered, got %+v", docs[1])
+	}
+	if docs[0].GoType != "*signal.schemaReport" {
+		t.Errorf("Unexpected Go type %q", docs[0].GoType)
+	}
+
+	var generated map[string]any
+	if err := json.Unmarshal(docs[0].Schema, &generated); err != nil {
+		t.Fatalf("Invalid generated schema: %v", err)
+	}
+	want := map[string]any{
+		"type":     "object",
+		"required": []any{"id"},
+		"properties": map[string]any{
+			"id":     map[string]any{"type": "string"},
+			"scores": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "number"}},
+			"next":   map[string]any{"type": "object"},
+		},
+	}
+	if !reflect.DeepEqual(generated, want) {
+		t.Errorf("Generated schema = %v, want %v", generated, want)
+	}
+
+	// Generated schemas are valid input for Register
+	if err := NewSchemaRegistry().Register("report", Schema{JSON: string(docs[0].Schema)}); err != nil {
+		t.Errorf("Generated schema rejected: %v", err)
+	}
+}
+
+func TestGatewayRejectsInvalidPayload(t *testing.T) {
+	registry := NewSchemaRegistry()
+	registry.Register("task", Schema{JSON: taskSchema})
+	router := NewRouter()
+	router.Register(NewAgentFunc("worker", func(ctx context.Context, sig *Signal) AgentResult { return OK() }))
+	config := DefaultConfig()
+	config.Schemas = registry
+	engine := NewEngine(config, router)
+	engine.Start()
+	defer engine.Stop()
+	server := httptest.NewServer(NewGateway(engine, GatewayConfig{}))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/signals", "application/json",
+		strings.NewReader(`{"type": "task", "destination": "worker", "payload": {"task_id": "t1", "workers": ["coding"]}}`))
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", resp.StatusCode)
+	}
+	var body struct {
+		Fields []FieldError `json:"fields"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if len(body.Fields) != 1 || body.Fields[0].Path != "payload.workers[0]" {
+		t.Errorf("Expected the workers[0] field error, got %+v", body.Fields)
+	}
+}
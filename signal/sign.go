@@ -0,0 +1,331 @@
+package signal
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// SIGNING: HMAC Signatures of Envelopes
+// =============================================================================
+
+// Errors reported by signature verification; match them with errors.Is.
+var (
+	ErrUnsigned         = errors.New("signal is not signed")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrStaleSignal      = errors.New("signal signed outside the accepted window")
+	ErrReplayedSignal   = errors.New("signal already accepted")
+)
+
+// minKeySize is the shortest accepted HMAC secret, in bytes.
+const minKeySize = 16
+
+// Signature authenticates an Envelope. Value is the base64 HMAC-SHA256,
+// under the key KeyID, of SignedAt and the envelope's ID, type, timestamp,
+// routing and lineage fields, idempotency key, metadata and payload JSON.
+// SignedAt is when the envelope was signed, which for a forwarded or
+// scheduled signal can be long after it was created (its Timestamp).
+type Signature struct {
+	KeyID    string    `json:"key_id"`
+	SignedAt time.Time `json:"signed_at"`
+	Value    string    `json:"value"`
+}
+
+// Keyring holds HMAC keys by ID. One of them, the current key, signs; all
+// of them verify. To rotate, Add the new key to every receiver first, then
+// Rotate senders to it, and Retire the old key once no signal signed with
+// it can still arrive. A Keyring is safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	current string
+	clock   Clock // Stamps SignedAt
+}
+
+// NewKeyring creates an empty keyring signing with the system clock's time.
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string][]byte), clock: SystemClock()}
+}
+
+// SetClock sets the clock whose time Sign stamps on signatures.
+func (k *Keyring) SetClock(clock Clock) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.clock = clock
+}
+
+// Add adds a key for verification; the first key added also becomes the
+// signing key. Secrets must be at least 16 bytes.
+func (k *Keyring) Add(id string, secret []byte) error {
+	if id == "" {
+		return errors.New("signing key needs an id")
+	}
+	if len(secret) < minKeySize {
+		return fmt.Errorf("signing key '%s' is shorter than %d bytes", id, minKeySize)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = bytes.Clone(secret)
+	if k.current == "" {
+		k.current = id
+	}
+	return nil
+}
+
+// Rotate makes a key added earlier the signing key.
+func (k *Keyring) Rotate(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("%w '%s'", ErrUnknownKey, id)
+	}
+	k.current = id
+	return nil
+}
+
+// Retire removes a key; signatures made with it no longer verify. The
+// signing key cannot be retired.
+func (k *Keyring) Retire(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if id == k.current {
+		return fmt.Errorf("cannot retire signing key '%s'", id)
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// Current returns the ID of the signing key, or "" for an empty keyring.
+func (k *Keyring) Current() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// Sign sets the envelope's signature with the signing key, signed now.
+func (k *Keyring) Sign(env *Envelope) error {
+	k.mu.RLock()
+	id, secret, clock := k.current, k.keys[k.current], k.clock
+	k.mu.RUnlock()
+	if id == "" {
+		return errors.New("keyring has no signing key")
+	}
+	signature := &Signature{KeyID: id, SignedAt: clock.Now()}
+	mac, err := envelopeMAC(*env, *signature, secret)
+	if err != nil {
+		return err
+	}
+	signature.Value = base64.StdEncoding.EncodeToString(mac)
+	env.Signature = signature
+	return nil
+}
+
+// Check verifies the envelope's signature, without freshness or replay
+// checks (see Verifier).
+func (k *Keyring) Check(env Envelope) error {
+	if env.Signature == nil {
+		return fmt.Errorf("%w (id=%s)", ErrUnsigned, truncateID(env.ID))
+	}
+	k.mu.RLock()
+	secret, ok := k.keys[env.Signature.KeyID]
+	k.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w '%s' (id=%s)", ErrUnknownKey, env.Signature.KeyID, truncateID(env.ID))
+	}
+	got, err := base64.StdEncoding.DecodeString(env.Signature.Value)
+	if err != nil {
+		return fmt.Errorf("%w (id=%s): %v", ErrInvalidSignature, truncateID(env.ID), err)
+	}
+	want, err := envelopeMAC(env, *env.Signature, secret)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(got, want) {
+		return fmt.Errorf("%w (id=%s)", ErrInvalidSignature, truncateID(env.ID))
+	}
+	return nil
+}
+
+// envelopeMAC computes the HMAC of an envelope's signed fields and the
+// signature's key ID and signing time. Each field is length-prefixed and
+// metadata is sorted, so the encoding is unambiguous and independent of map
+// order; the payload is compacted so re-encoding the envelope keeps the
+// signature valid.
+func envelopeMAC(env Envelope, signature Signature, secret []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	field := func(s string) {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		buf.WriteString(s)
+	}
+	instant := func(t time.Time) {
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(t.Unix())))
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(t.Nanosecond())))
+	}
+	field("signal/v2")
+	field(signature.KeyID)
+	instant(signature.SignedAt)
+	field(env.ID)
+	field(string(env.Type))
+	instant(env.Timestamp)
+	field(env.Source)
+	field(env.Destination)
+	field(env.ParentID)
+	field(env.IdempotencyKey)
+
+	keys := make([]string, 0, len(env.Metadata))
+	for key := range env.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf.Write(binary.AppendUvarint(nil, uint64(len(keys))))
+	for _, key := range keys {
+		field(key)
+		field(env.Metadata[key])
+	}
+
+	var payload bytes.Buffer
+	if len(env.Payload) > 0 {
+		if err := json.Compact(&payload, env.Payload); err != nil {
+			return nil, fmt.Errorf("sign payload of signal type '%s': %w", env.Type, err)
+		}
+	}
+	field(payload.String())
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(buf.Bytes())
+	return mac.Sum(nil), nil
+}
+
+// =============================================================================
+// VERIFICATION: Signatures, Freshness and Replays
+// =============================================================================
+
+// VerifierConfig configures a Verifier.
+type VerifierConfig struct {
+	// Keys verifies signatures. Required.
+	Keys *Keyring
+
+	// MaxAge bounds how far a signature's signing time may be from the
+	// verifier's clock, in either direction (allowing for clock skew).
+	// Defaults to 5 minutes.
+	MaxAge time.Duration
+
+	// Clock defaults to the system clock.
+	Clock Clock
+}
+
+// Verifier checks signed envelopes before their signals are submitted:
+// the signature must verify, it must have been signed within MaxAge of now,
+// and the signal must not have been accepted before for the same
+// destination. Receivers Verify an envelope, then Accept its signal when
+// handing it to the engine and Forget it if that fails, so a signal is only
+// remembered once it was taken and a retransmission after a failure is not
+// mistaken for a replay. Accepted signals are remembered for twice MaxAge,
+// after which they would be rejected as stale anyway. A Verifier is safe
+// for concurrent use.
+type Verifier struct {
+	config VerifierConfig
+
+	mu    sync.Mutex
+	seen  map[string]*list.Element
+	order *list.List // Of acceptance, for expiry
+}
+
+type acceptance struct {
+	key     string
+	expires time.Time
+}
+
+// NewVerifier creates a verifier.
+func NewVerifier(config VerifierConfig) *Verifier {
+	if config.MaxAge <= 0 {
+		config.MaxAge = 5 * time.Minute
+	}
+	if config.Clock == nil {
+		config.Clock = SystemClock()
+	}
+	return &Verifier{config: config, seen: make(map[string]*list.Element), order: list.New()}
+}
+
+// Verify checks an envelope's signature, freshness and that its signal was
+// not accepted before. It does not record the signal (see Accept).
+func (v *Verifier) Verify(env Envelope) error {
+	if err := v.config.Keys.Check(env); err != nil {
+		return err
+	}
+	now := v.config.Clock.Now()
+	if age := now.Sub(env.Signature.SignedAt); age > v.config.MaxAge || age < -v.config.MaxAge {
+		return fmt.Errorf("%w (id=%s, age %v)", ErrStaleSignal, truncateID(env.ID), age.Round(time.Millisecond))
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.expire(now)
+	if v.seen[acceptanceKey(env.ID, env.Destination)] != nil {
+		return fmt.Errorf("%w (id=%s)", ErrReplayedSignal, truncateID(env.ID))
+	}
+	return nil
+}
+
+// Accept records a verified signal as accepted, failing with
+// ErrReplayedSignal if it already was, such as by a concurrent duplicate.
+func (v *Verifier) Accept(signal *Signal) error {
+	key := acceptanceKey(signal.ID, signal.Destination)
+	now := v.config.Clock.Now()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.expire(now)
+	if v.seen[key] != nil {
+		return fmt.Errorf("%w (id=%s)", ErrReplayedSignal, truncateID(signal.ID))
+	}
+	v.seen[key] = v.order.PushBack(acceptance{key: key, expires: now.Add(2 * v.config.MaxAge)})
+	return nil
+}
+
+// Forget drops the acceptance of a signal that then failed, so that it is
+// accepted again when retransmitted.
+func (v *Verifier) Forget(signal *Signal) {
+	key := acceptanceKey(signal.ID, signal.Destination)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if e := v.seen[key]; e != nil {
+		v.order.Remove(e)
+		delete(v.seen, key)
+	}
+}
+
+// expire forgets acceptances that have expired. Callers hold v.mu.
+func (v *Verifier) expire(now time.Time) {
+	for e := v.order.Front(); e != nil; e = v.order.Front() {
+		a := e.Value.(acceptance)
+		if a.expires.After(now) {
+			break
+		}
+		delete(v.seen, a.key)
+		v.order.Remove(e)
+	}
+}
+
+// acceptanceKey identifies one delivery of a signal: a signal fanned out to
+// several agents arrives once for each of them.
+func acceptanceKey(id, destination string) string {
+	return id + "\x00" + destination
+}
+
+// Remembered returns the number of accepted signals still remembered.
+func (v *Verifier) Remembered() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.seen)
+}
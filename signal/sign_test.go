@@ -0,0 +1,326 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testKeyring(t *testing.T, ids ...string) *Keyring {
+	t.Helper()
+	keys := NewKeyring()
+	for _, id := range ids {
+		if err := keys.Add(id, []byte("secret-of-"+id+"-0123456789")); err != nil {
+			t.Fatalf("Add(%q) error = %v", id, err)
+		}
+	}
+	return keys
+}
+
+func signedEnvelope(t *testing.T, keys *Keyring, at time.Time) Envelope {
+	t.Helper()
+	codec := NewCodec()
+	codec.SignWith(keys)
+	sig := NewSignal("task", map[string]any{"text": "hello", "n": 1}).
+		WithDestination("worker").
+		WithMetadata("user", "u1")
+	sig.Source = "coordinator"
+	sig.Timestamp = at
+	env, err := codec.Wrap(sig)
+	if err != nil {
+		t.Fatalf("Wrap error = %v", err)
+	}
+	if env.Signature == nil || env.Signature.KeyID != keys.Current() {
+		t.Fatalf("Wrap should sign with the current key, got %+v", env.Signature)
+	}
+	return env
+}
+
+func TestSignatureCoversSignal(t *testing.T) {
+	keys := testKeyring(t, "k1")
+	env := signedEnvelope(t, keys, time.Now())
+	if err := keys.Check(env); err != nil {
+		t.Fatalf("Check of an untouched envelope: %v", err)
+	}
+
+	// Re-encoding with other whitespace keeps the signature valid
+	data, _ := json.MarshalIndent(env, "", "  ")
+	var reencoded Envelope
+	json.Unmarshal(data, &reencoded)
+	reencoded.Payload = json.RawMessage(strings.ReplaceAll(string(reencoded.Payload), ":", ": "))
+	if err := keys.Check(reencoded); err != nil {
+		t.Errorf("Check after re-encoding: %v", err)
+	}
+
+	tamper := map[string]func(e *Envelope){
+		"id":        func(e *Envelope) { e.ID += "x" },
+		"type":      func(e *Envelope) { e.Type = "admin" },
+		"timestamp": func(e *Envelope) { e.Timestamp = e.Timestamp.Add(time.Nanosecond) },
+		"signed at": func(e *Envelope) {
+			e.Signature = &Signature{KeyID: "k1", SignedAt: e.Signature.SignedAt.Add(time.Hour), Value: e.Signature.Value}
+		},
+		"source":      func(e *Envelope) { e.Source = "worker" },
+		"destination": func(e *Envelope) { e.Destination = "output" },
+		"parent":      func(e *Envelope) { e.ParentID = "p" },
+		"metadata":    func(e *Envelope) { e.Metadata = map[string]string{"user": "u2"} },
+		"idempotency": func(e *Envelope) { e.IdempotencyKey = "k" },
+		"payload":     func(e *Envelope) { e.Payload = json.RawMessage(`{"n":2,"text":"hello"}`) },
+		"key id":      func(e *Envelope) { e.Signature = &Signature{KeyID: "k1", Value: "AAAA"} },
+	}
+	for name, change := range tamper {
+		tampered := env
+		tampered.Metadata = map[string]string{"user": "u1"}
+		change(&tampered)
+		if err := keys.Check(tampered); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Tampered %s: Check error = %v, want ErrInvalidSignature", name, err)
+		}
+	}
+
+	unsigned := env
+	unsigned.Signature = nil
+	if err := keys.Check(unsigned); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("Unsigned: Check error = %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	sender := testKeyring(t, "2024-01")
+	receiver := testKeyring(t, "2024-01")
+	old := signedEnvelope(t, sender, time.Now())
+
+	// Receivers learn the new key before senders switch to it
+	newSecret := []byte("secret-of-2024-02-0123456789")
+	receiver.Add("2024-02", newSecret)
+	sender.Add("2024-02", newSecret)
+	if err := sender.Rotate("2024-02"); err != nil {
+		t.Fatalf("Rotate error = %v", err)
+	}
+	rotated := signedEnvelope(t, sender, time.Now())
+	if rotated.Signature.KeyID != "2024-02" {
+		t.Fatalf("Expected the new key, got %q", rotated.Signature.KeyID)
+	}
+	for _, env := range []Envelope{old, rotated} {
+		if err := receiver.Check(env); err != nil {
+			t.Errorf("Check(%s) error = %v", env.Signature.KeyID, err)
+		}
+	}
+
+	receiver.Rotate("2024-02")
+	if err := receiver.Retire("2024-01"); err != nil {
+		t.Fatalf("Retire error = %v", err)
+	}
+	if err := receiver.Check(old); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Retired key: Check error = %v, want ErrUnknownKey", err)
+	}
+	if err := receiver.Retire("2024-02"); err == nil {
+		t.Error("The signing key should not be retirable")
+	}
+	if err := receiver.Add("short", []byte("1234")); err == nil {
+		t.Error("Add should reject short secrets")
+	}
+	if err := NewKeyring().Sign(&Envelope{}); err == nil {
+		t.Error("An empty keyring should not sign")
+	}
+}
+
+func TestVerifierFreshnessAndReplay(t *testing.T) {
+	keys := testKeyring(t, "k1")
+	clock := NewManualClock(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	keys.SetClock(clock)
+	verifier := NewVerifier(VerifierConfig{Keys: keys, MaxAge: time.Minute, Clock: clock})
+
+	// Freshness is that of the signature: an old signal signed now is fresh
+	env := signedEnvelope(t, keys, clock.Now().Add(-time.Hour))
+	if !env.Signature.SignedAt.Equal(clock.Now()) {
+		t.Fatalf("SignedAt = %v, want the keyring clock's time", env.Signature.SignedAt)
+	}
+	if err := verifier.Verify(env); err != nil {
+		t.Fatalf("Verify error = %v", err)
+	}
+	sig := &Signal{ID: env.ID, Destination: env.Destination}
+
+	// Verifying does not record the signal; accepting it does
+	if err := verifier.Verify(env); err != nil {
+		t.Errorf("Second Verify before Accept: %v", err)
+	}
+	if err := verifier.Accept(sig); err != nil {
+		t.Fatalf("Accept error = %v", err)
+	}
+	if err := verifier.Verify(env); !errors.Is(err, ErrReplayedSignal) {
+		t.Errorf("Verify after Accept: error = %v, want ErrReplayedSignal", err)
+	}
+	if err := verifier.Accept(sig); !errors.Is(err, ErrReplayedSignal) {
+		t.Errorf("Second Accept: error = %v, want ErrReplayedSignal", err)
+	}
+
+	// A signal that failed after being accepted can be retransmitted
+	verifier.Forget(sig)
+	if err := verifier.Verify(env); err != nil {
+		t.Errorf("Verify after Forget: %v", err)
+	}
+	verifier.Accept(sig)
+
+	// The same signal for another destination is a separate delivery
+	other := env
+	other.Destination = "auditor"
+	keys.Sign(&other)
+	if err := verifier.Verify(other); err != nil {
+		t.Errorf("Verify for another destination: %v", err)
+	}
+
+	for name, signedAt := range map[string]time.Time{
+		"old":    clock.Now().Add(-2 * time.Minute),
+		"future": clock.Now().Add(2 * time.Minute),
+	} {
+		signer := testKeyring(t, "k1")
+		signer.SetClock(NewManualClock(signedAt))
+		if err := verifier.Verify(signedEnvelope(t, signer, clock.Now())); !errors.Is(err, ErrStaleSignal) {
+			t.Errorf("Signed %s: Verify error = %v, want ErrStaleSignal", name, err)
+		}
+	}
+
+	// Accepted signals are forgotten once they would be stale anyway
+	clock.Advance(2*time.Minute + time.Second)
+	verifier.Accept(&Signal{ID: "fresh"})
+	if got := verifier.Remembered(); got != 1 {
+		t.Errorf("Remembered() = %d, want 1 after expiry", got)
+	}
+	if err := verifier.Verify(env); !errors.Is(err, ErrStaleSignal) {
+		t.Errorf("Replay after expiry: Verify error = %v, want ErrStaleSignal", err)
+	}
+}
+
+func TestCodecVerifiesBeforeDecode(t *testing.T) {
+	keys := testKeyring(t, "k1")
+	sender := NewCodec()
+	sender.SignWith(keys)
+	receiver := NewCodec()
+	receiver.VerifyWith(NewVerifier(VerifierConfig{Keys: keys}))
+
+	data, err := sender.Encode(NewSignal("task", "hello"))
+	if err != nil {
+		t.Fatalf("Encode error = %v", err)
+	}
+	sig, err := receiver.Decode(data)
+	if err != nil || sig.Payload != "hello" {
+		t.Fatalf("Decode = %v, %v", sig, err)
+	}
+	if _, err := receiver.Decode(data); err != nil {
+		t.Errorf("Decoding twice before Accept: %v", err)
+	}
+	if err := receiver.Accept(sig); err != nil {
+		t.Fatalf("Accept error = %v", err)
+	}
+	if _, err := receiver.Decode(data); !errors.Is(err, ErrReplayedSignal) {
+		t.Errorf("Decoding an accepted signal: error = %v, want ErrReplayedSignal", err)
+	}
+	tampered := strings.Replace(string(data), `"hello"`, `"HELLO"`, 1)
+	if _, err := receiver.Decode([]byte(tampered)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Tampered payload: error = %v, want ErrInvalidSignature", err)
+	}
+	plain, _ := NewCodec().Encode(NewSignal("task", "hello"))
+	if _, err := receiver.Decode(plain); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("Unsigned signal: error = %v, want ErrUnsigned", err)
+	}
+	if err := NewCodec().Accept(sig); err != nil {
+		t.Errorf("Accept without a verifier: %v", err)
+	}
+}
+
+func TestRemoteAgentWithSignedSignals(t *testing.T) {
+	keys := testKeyring(t, "k1")
+	serverCodec := NewCodec()
+	serverCodec.SignWith(keys)
+	serverCodec.VerifyWith(NewVerifier(VerifierConfig{Keys: keys}))
+
+	var failures atomic.Int32
+	engine := newTestEngine(t, DefaultConfig(), nil, NewAgentFunc("echo", func(ctx context.Context, sig *Signal) AgentResult {
+		if sig.Payload == "fail once" && failures.Add(1) == 1 {
+			return Err(errors.New("unavailable"))
+		}
+		return OK(sig.Derive("echoed", sig.Payload))
+	}))
+	startEngine(t, engine)
+	server := NewServer(engine, ServerConfig{Codec: serverCodec})
+	if err := server.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	waitFor(t, func() bool { return server.Addr() != nil })
+	defer server.Close()
+
+	send := func(codec *Codec, sig *Signal) AgentResult {
+		config := fastRemote(server.Addr().String(), TCPTransport())
+		config.Codec = codec
+		remote := NewRemoteAgent("echo", config)
+		defer remote.Close(context.Background())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		return remote.Process(ctx, sig)
+	}
+	call := func(codec *Codec) AgentResult {
+		return send(codec, NewSignal("ping", "hi").WithDestination("echo"))
+	}
+
+	signing := NewCodec()
+	signing.SignWith(keys)
+	signing.VerifyWith(NewVerifier(VerifierConfig{Keys: keys}))
+	if result := call(signing); result.Error != nil || len(result.Signals) != 1 || result.Signals[0].Payload != "hi" {
+		t.Errorf("Signed call = %+v", result)
+	}
+
+	// A call that failed can be retransmitted; one that succeeded cannot
+	retried := NewSignal("ping", "fail once").WithDestination("echo")
+	if result := send(signing, retried); result.Error == nil {
+		t.Fatal("Expected the first call to fail")
+	}
+	if result := send(signing, retried); result.Error != nil {
+		t.Errorf("Retransmission after a failure: %v", result.Error)
+	}
+	if result := send(signing, retried); !strings.Contains(fmt.Sprint(result.Error), ErrReplayedSignal.Error()) {
+		t.Errorf("Replay of an accepted call: error = %v, want a replay error", result.Error)
+	}
+
+	forger := NewCodec()
+	forger.SignWith(testKeyring(t, "k1-forged"))
+	if result := call(forger); result.Error == nil || !strings.Contains(result.Error.Error(), "unknown signing key") {
+		t.Errorf("Call signed with an unknown key should fail, got %+v", result)
+	}
+}
+
+func TestServerRejectsRetargetedCalls(t *testing.T) {
+	keys := testKeyring(t, "k1")
+	codec := NewCodec()
+	codec.SignWith(keys)
+	codec.VerifyWith(NewVerifier(VerifierConfig{Keys: keys}))
+	called := make(chan string, 2)
+	agent := func(id string) Agent {
+		return NewAgentFunc(id, func(ctx context.Context, sig *Signal) AgentResult {
+			called <- id
+			return OK()
+		})
+	}
+	engine := newTestEngine(t, DefaultConfig(), nil, agent("reader"), agent("admin"))
+	startEngine(t, engine)
+	server := NewServer(engine, ServerConfig{Codec: codec, Agents: []string{"reader", "admin"}})
+
+	env, err := codec.Wrap(NewSignal("query", "q").WithDestination("reader"))
+	if err != nil {
+		t.Fatalf("Wrap error = %v", err)
+	}
+	// A captured call sent to another exposed agent does not verify
+	retargeted := server.call(context.Background(), frame{Kind: frameCall, Agent: "admin", Signal: &env})
+	if !strings.Contains(retargeted.Error, ErrInvalidSignature.Error()) {
+		t.Errorf("Retargeted call error = %q, want an invalid signature", retargeted.Error)
+	}
+	if reply := server.call(context.Background(), frame{Kind: frameCall, Agent: "reader", Signal: &env}); reply.Error != "" {
+		t.Fatalf("Call error = %q", reply.Error)
+	}
+	if got := <-called; got != "reader" || len(called) != 0 {
+		t.Errorf("Called %q, want only the signed target", got)
+	}
+}
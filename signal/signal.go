@@ -39,18 +39,17 @@ type Signal struct {
 	// Lineage (for debugging and tracing complex workflows)
 	ParentID string            // ID of the signal that caused this one
 	Metadata map[string]string // Additional context without struct changes
+
+	// IdempotencyKey identifies a logical request across retries (see dedup.go).
+	// When empty, the MetaIdempotencyKey metadata value is used.
+	IdempotencyKey string
 }
 
 // NewSignal creates a new signal with a unique ID and timestamp.
 // This is the primary constructor for creating signals.
+// IDs and timestamps come from the default SignalFactory (see ids.go).
 func NewSignal(signalType SignalType, payload any) *Signal {
-	return &Signal{
-		ID:        generateID(),
-		Type:      signalType,
-		Timestamp: time.Now(),
-		Payload:   payload,
-		Metadata:  make(map[string]string),
-	}
+	return DefaultFactory().New(signalType, payload)
 }
 
 // WithDestination returns a new signal with the destination set.
@@ -92,15 +91,11 @@ func (s *Signal) WithMetadata(key, value string) *Signal {
 // Derive creates a child signal with lineage tracking.
 // The new signal has its own ID but maintains a reference to its parent,
 // enabling tracing of signal chains through complex workflows.
+// The idempotency key is not inherited: it identifies the parent request.
+// Derive uses the default factory, not the Engine's EngineConfig.Clock and
+// IDs; agents that need those derive with FactoryFrom(ctx).Derive.
 func (s *Signal) Derive(signalType SignalType, payload any) *Signal {
-	child := NewSignal(signalType, payload)
-	child.ParentID = s.ID
-	child.Source = s.Destination // The destination of parent becomes source of child
-	// Copy metadata from parent
-	for k, v := range s.Metadata {
-		child.Metadata[k] = v
-	}
-	return child
+	return DefaultFactory().Derive(s, signalType, payload)
 }
 
 // String returns a human-readable representation of the signal.
@@ -175,38 +170,107 @@ func (a *AgentFunc) Process(ctx context.Context, signal *Signal) AgentResult {
 // Multiple agents can be returned for fanout patterns.
 type RoutingRule func(signal *Signal) []string
 
+// Subscription declares a set of signals an agent wants to receive.
+// A signal matches when its type is listed in Types (or Types is empty)
+// and every key in Metadata is present on the signal with the same value.
+type Subscription struct {
+	Types    []SignalType      // Accepted signal types; empty accepts any type
+	Metadata map[string]string // Required metadata values; empty requires none
+}
+
+// Matches reports whether the signal satisfies this subscription.
+func (s Subscription) Matches(signal *Signal) bool {
+	if len(s.Types) > 0 {
+		found := false
+		for _, t := range s.Types {
+			if t == signal.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for k, v := range s.Metadata {
+		if got, ok := signal.Metadata[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Subscriber is an optional interface for agents that declare their own inputs.
+// When a Subscriber is registered, the router wires its subscriptions
+// automatically, so adding a new agent needs no routing rule changes.
+type Subscriber interface {
+	// Subscriptions returns the signals this agent consumes.
+	// It is read once, when the agent is registered.
+	Subscriptions() []Subscription
+}
+
 // Router manages agent registration and signal routing.
 // It implements a priority-based routing strategy:
 // 1. Explicit destination (signal.Destination)
 // 2. Rules evaluated in order
+// 3. Subscriptions declared by Subscriber agents
 // This separation of routing from agents enables loose coupling.
 type Router struct {
-	mu     sync.RWMutex
-	agents map[string]Agent
-	rules  []RoutingRule
+	mu            sync.RWMutex
+	agents        map[string]Agent
+	rules         []RoutingRule
+	subscriptions map[string][]Subscription
+	subscribers   []string // Subscriber IDs in registration order
 }
 
 // NewRouter creates a new router with empty agent registry.
 func NewRouter() *Router {
 	return &Router{
-		agents: make(map[string]Agent),
-		rules:  make([]RoutingRule, 0),
+		agents:        make(map[string]Agent),
+		rules:         make([]RoutingRule, 0),
+		subscriptions: make(map[string][]Subscription),
 	}
 }
 
 // Register adds an agent to the router.
 // If an agent with the same ID exists, it will be replaced.
+// If the agent implements Subscriber, its subscriptions replace any
+// previously declared for the same ID.
 func (r *Router) Register(agent Agent) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.agents[agent.ID()] = agent
+	id := agent.ID()
+	r.agents[id] = agent
+	r.removeSubscriptions(id)
+	if sub, ok := agent.(Subscriber); ok {
+		if subs := sub.Subscriptions(); len(subs) > 0 {
+			r.subscriptions[id] = subs
+			r.subscribers = append(r.subscribers, id)
+		}
+	}
 }
 
-// Unregister removes an agent from the router by ID.
+// Unregister removes an agent and its subscriptions from the router by ID.
 func (r *Router) Unregister(agentID string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	delete(r.agents, agentID)
+	r.removeSubscriptions(agentID)
+}
+
+// removeSubscriptions drops the subscriptions of an agent.
+// Caller must hold the write lock.
+func (r *Router) removeSubscriptions(agentID string) {
+	if _, exists := r.subscriptions[agentID]; !exists {
+		return
+	}
+	delete(r.subscriptions, agentID)
+	for i, id := range r.subscribers {
+		if id == agentID {
+			r.subscribers = append(r.subscribers[:i], r.subscribers[i+1:]...)
+			break
+		}
+	}
 }
 
 // AddRule adds a routing rule. Rules are evaluated in the order they are added.
@@ -220,6 +284,9 @@ func (r *Router) AddRule(rule RoutingRule) {
 // Route determines where a signal should go based on:
 // 1. Explicit destination in signal.Destination
 // 2. Routing rules evaluated in order
+// 3. Every subscriber whose subscriptions match, in registration order
+// Explicit rules take precedence over subscriptions: subscriptions are only
+// consulted when no rule yields a registered destination.
 // Returns nil if no valid destination is found.
 func (r *Router) Route(signal *Signal) []string {
 	r.mu.RLock()
@@ -249,7 +316,17 @@ func (r *Router) Route(signal *Signal) []string {
 		}
 	}
 
-	return nil
+	// Priority 3: Declared subscriptions (fanout to every match)
+	var subscribed []string
+	for _, id := range r.subscribers {
+		for _, sub := range r.subscriptions[id] {
+			if sub.Matches(signal) {
+				subscribed = append(subscribed, id)
+				break
+			}
+		}
+	}
+	return subscribed
 }
 
 // GetAgent returns an agent by ID.
@@ -282,20 +359,6 @@ func (r *Router) AgentCount() int {
 // UTILITY FUNCTIONS
 // =============================================================================
 
-var (
-	idCounter uint64
-	idMu      sync.Mutex
-)
-
-// generateID creates a unique signal ID.
-// Format: sig-{timestamp_nano}-{counter}
-func generateID() string {
-	idMu.Lock()
-	defer idMu.Unlock()
-	idCounter++
-	return fmt.Sprintf("sig-%d-%d", time.Now().UnixNano(), idCounter)
-}
-
 // truncateID shortens an ID for display purposes.
 func truncateID(id string) string {
 	if len(id) <= 20 {
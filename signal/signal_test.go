@@ -190,6 +190,75 @@ func TestRouterListAgents(t *testing.T) {
 	}
 }
 
+type subscribingAgent struct {
+	mockAgent
+	subs []Subscription
+}
+
+func (s *subscribingAgent) Subscriptions() []Subscription { return s.subs }
+
+func TestRouterRouteBySubscription(t *testing.T) {
+	router := NewRouter()
+	router.Register(&subscribingAgent{
+		mockAgent: mockAgent{id: "audit"},
+		subs:      []Subscription{{Types: []SignalType{"order"}}},
+	})
+	router.Register(&subscribingAgent{
+		mockAgent: mockAgent{id: "vip"},
+		subs: []Subscription{{
+			Types:    []SignalType{"order"},
+			Metadata: map[string]string{"tier": "gold"},
+		}},
+	})
+
+	destinations := router.Route(NewSignal("order", nil))
+	if len(destinations) != 1 || destinations[0] != "audit" {
+		t.Errorf("Destinations = %v, want [audit]", destinations)
+	}
+
+	destinations = router.Route(NewSignal("order", nil).WithMetadata("tier", "gold"))
+	if len(destinations) != 2 || destinations[0] != "audit" || destinations[1] != "vip" {
+		t.Errorf("Destinations = %v, want [audit vip]", destinations)
+	}
+
+	if destinations := router.Route(NewSignal("refund", nil)); destinations != nil {
+		t.Errorf("Destinations = %v, want nil", destinations)
+	}
+}
+
+func TestRouterRulesTakePrecedenceOverSubscriptions(t *testing.T) {
+	router := NewRouter()
+	router.Register(&mockAgent{id: "handler"})
+	router.Register(&subscribingAgent{
+		mockAgent: mockAgent{id: "subscriber"},
+		subs:      []Subscription{{Types: []SignalType{"special"}}},
+	})
+	router.AddRule(func(sig *Signal) []string {
+		if sig.Type == "special" {
+			return []string{"handler"}
+		}
+		return nil
+	})
+
+	destinations := router.Route(NewSignal("special", nil))
+	if len(destinations) != 1 || destinations[0] != "handler" {
+		t.Errorf("Destinations = %v, want [handler]", destinations)
+	}
+}
+
+func TestRouterUnregisterRemovesSubscriptions(t *testing.T) {
+	router := NewRouter()
+	router.Register(&subscribingAgent{
+		mockAgent: mockAgent{id: "audit"},
+		subs:      []Subscription{{Types: []SignalType{"order"}}},
+	})
+	router.Unregister("audit")
+
+	if destinations := router.Route(NewSignal("order", nil)); destinations != nil {
+		t.Errorf("Destinations = %v, want nil", destinations)
+	}
+}
+
 // =============================================================================
 // ENGINE TESTS
 // =============================================================================
@@ -0,0 +1,133 @@
+package signaltest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// =============================================================================
+// ASSERTIONS
+// =============================================================================
+
+// Want describes an expected signal. Zero fields are not checked.
+type Want struct {
+	Type        signal.SignalType
+	Source      string
+	Destination string
+	ParentID    string
+
+	// Payload is compared with reflect.DeepEqual.
+	Payload any
+
+	// Metadata lists required metadata values; other keys are ignored.
+	Metadata map[string]string
+
+	// Check runs any further assertion on the signal.
+	Check func(sig *signal.Signal) error
+}
+
+// Match returns an error describing the first field of sig that differs
+// from the expectation, or nil.
+func (w Want) Match(sig *signal.Signal) error {
+	if sig == nil {
+		return fmt.Errorf("signal is nil")
+	}
+	if w.Type != "" && sig.Type != w.Type {
+		return fmt.Errorf("type = %s, want %s", sig.Type, w.Type)
+	}
+	if w.Source != "" && sig.Source != w.Source {
+		return fmt.Errorf("source = %q, want %q", sig.Source, w.Source)
+	}
+	if w.Destination != "" && sig.Destination != w.Destination {
+		return fmt.Errorf("destination = %q, want %q", sig.Destination, w.Destination)
+	}
+	if w.ParentID != "" && sig.ParentID != w.ParentID {
+		return fmt.Errorf("parent = %q, want %q", sig.ParentID, w.ParentID)
+	}
+	if w.Payload != nil && !reflect.DeepEqual(sig.Payload, w.Payload) {
+		return fmt.Errorf("payload = %#v, want %#v", sig.Payload, w.Payload)
+	}
+	for k, v := range w.Metadata {
+		if got, ok := sig.Metadata[k]; !ok || got != v {
+			return fmt.Errorf("metadata %s = %q, want %q", k, got, v)
+		}
+	}
+	if w.Check != nil {
+		return w.Check(sig)
+	}
+	return nil
+}
+
+// AssertSignals checks that got matches want one to one, in order.
+func AssertSignals(t testing.TB, got []*signal.Signal, want ...Want) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d signals, want %d:\n%s", len(got), len(want), describe(got))
+	}
+	for i := range want {
+		if err := want[i].Match(got[i]); err != nil {
+			t.Errorf("signal %d (%s): %v", i, got[i], err)
+		}
+	}
+}
+
+// AssertTypes checks the types of got, in order.
+func AssertTypes(t testing.TB, got []*signal.Signal, types ...signal.SignalType) {
+	t.Helper()
+	want := make([]Want, len(types))
+	for i, typ := range types {
+		want[i] = Want{Type: typ}
+	}
+	AssertSignals(t, got, want...)
+}
+
+// AssertContains checks that at least one signal in got matches want, and
+// returns the first match.
+func AssertContains(t testing.TB, got []*signal.Signal, want Want) *signal.Signal {
+	t.Helper()
+	for _, sig := range got {
+		if want.Match(sig) == nil {
+			return sig
+		}
+	}
+	t.Fatalf("no signal matches %+v among:\n%s", want, describe(got))
+	return nil
+}
+
+// AssertLineage checks the types along a lineage chain (see
+// Engine.Lineage), from the root to the last signal.
+func AssertLineage(t testing.TB, chain []*signal.Signal, types ...signal.SignalType) {
+	t.Helper()
+	got := make([]string, len(chain))
+	for i, sig := range chain {
+		got[i] = string(sig.Type)
+	}
+	want := make([]string, len(types))
+	for i, typ := range types {
+		want[i] = string(typ)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lineage = %s, want %s", strings.Join(got, " → "), strings.Join(want, " → "))
+	}
+}
+
+// AssertNoFailures checks that no agent returned an error.
+func AssertNoFailures(t testing.TB, e *Engine) {
+	t.Helper()
+	for _, f := range e.Failures() {
+		t.Errorf("agent '%s' failed on %s: %v", f.Agent, f.Signal, f.Err)
+	}
+}
+
+// describe lists signals one per line for failure messages.
+func describe(signals []*signal.Signal) string {
+	var b strings.Builder
+	for i, sig := range signals {
+		fmt.Fprintf(&b, "  %d: %s\n", i, sig)
+	}
+	return b.String()
+}
@@ -0,0 +1,286 @@
+// Package signaltest provides a deterministic engine, a fake clock and
+// assertions for testing agents and agent flows without goroutines, sleeps
+// or channels.
+package signaltest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// =============================================================================
+// FAKE CLOCK
+// =============================================================================
+
+// Epoch is the start time of clocks created by NewClock.
+var Epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// NewClock returns a manual clock starting at Epoch. Time only moves when
+// the test advances it, and due timers fire synchronously during Advance.
+func NewClock() *signal.ManualClock {
+	return signal.NewManualClock(Epoch)
+}
+
+// =============================================================================
+// ENGINE: Synchronous, Single-Threaded Processing
+// =============================================================================
+
+// Config configures a test Engine.
+type Config struct {
+	// Clock is advanced by Engine.Advance. Defaults to NewClock().
+	// Pass the same clock to agents that take one.
+	Clock *signal.ManualClock
+
+	// ProcessTimeout bounds each Process call. A deferred result is not
+	// waited for: it stays pending until the agent completes it. Defaults to
+	// 5 seconds.
+	ProcessTimeout time.Duration
+
+	// MaxSteps bounds the signals processed by one Run, catching agents
+	// that emit signals forever. Defaults to 10000.
+	MaxSteps int
+
+	// IDs identifies signals created through signal.FactoryFrom in agents.
+	// Defaults to a sequential generator ("sig-1", "sig-2", ...).
+	IDs signal.IDGenerator
+}
+
+// Delivery is one Process call made by the engine.
+type Delivery struct {
+	Agent  string             // Destination agent ID
+	Signal *signal.Signal     // Signal as delivered (Destination set)
+	Result signal.AgentResult // What the agent returned
+}
+
+// Failure is an error returned by an agent.
+type Failure struct {
+	Agent  string
+	Signal *signal.Signal
+	Err    error
+}
+
+// Engine processes signals one at a time on the calling goroutine, in
+// submission order, routing them with a signal.Router exactly like
+// signal.Engine. Agent outputs are appended to the queue, so a flow runs
+// breadth-first and always in the same order. Every delivery, output,
+// unroutable signal and error is recorded for assertions.
+//
+// Deferred results (see signal.Defer) are not waited for. The call stays
+// pending, counted by Deferred, until the agent completes it, typically
+// when Advance fires its timer or a later Step delivers the signal it was
+// waiting for. The delivery and its outputs are then recorded as if
+// Process had returned the result. Results completed from an agent's own
+// goroutine are picked up by the next Step or Run.
+type Engine struct {
+	router  *signal.Router
+	config  Config
+	factory *signal.SignalFactory
+
+	mu        sync.Mutex
+	completed []Delivery // Deferred results completed but not yet recorded
+	deferred  int        // Deferred results not yet completed
+
+	queue      []*signal.Signal
+	seen       map[string]*signal.Signal // Every signal by ID, for lineage
+	deliveries []Delivery
+	emitted    []*signal.Signal
+	terminal   []*signal.Signal
+	failures   []Failure
+}
+
+// NewEngine creates a test engine over a router.
+func NewEngine(router *signal.Router, config Config) *Engine {
+	if config.Clock == nil {
+		config.Clock = NewClock()
+	}
+	if config.ProcessTimeout <= 0 {
+		config.ProcessTimeout = 5 * time.Second
+	}
+	if config.MaxSteps <= 0 {
+		config.MaxSteps = 10000
+	}
+	if config.IDs == nil {
+		config.IDs = signal.NewSequentialGenerator("sig")
+	}
+	return &Engine{
+		router:  router,
+		config:  config,
+		factory: signal.NewSignalFactory(config.Clock, config.IDs),
+		seen:    make(map[string]*signal.Signal),
+	}
+}
+
+// Factory returns the factory agents get through signal.FactoryFrom: it
+// stamps signals with the engine's clock and IDs.
+func (e *Engine) Factory() *signal.SignalFactory {
+	return e.factory
+}
+
+// Clock returns the engine's clock.
+func (e *Engine) Clock() *signal.ManualClock {
+	return e.config.Clock
+}
+
+// Submit queues signals for processing. Nothing runs until Step or Run.
+func (e *Engine) Submit(signals ...*signal.Signal) {
+	for _, sig := range signals {
+		e.enqueue(sig)
+	}
+}
+
+// Run processes queued signals, and the signals they cause, until the queue
+// is empty. It fails if MaxSteps signals are processed without quiescing.
+func (e *Engine) Run() error {
+	e.drain()
+	for steps := 0; len(e.queue) > 0; steps++ {
+		if steps == e.config.MaxSteps {
+			return fmt.Errorf("not quiescent after %d signals (%d queued)", steps, len(e.queue))
+		}
+		e.Step()
+	}
+	return nil
+}
+
+// Advance moves the clock forward, firing due timers, and runs until
+// quiescent.
+func (e *Engine) Advance(d time.Duration) error {
+	e.config.Clock.Advance(d)
+	return e.Run()
+}
+
+// Step processes the next queued signal in every destination it routes to.
+// It returns false if the queue was empty.
+func (e *Engine) Step() bool {
+	e.drain()
+	if len(e.queue) == 0 {
+		return false
+	}
+	sig := e.queue[0]
+	e.queue[0] = nil
+	e.queue = e.queue[1:]
+
+	destinations := e.router.Route(sig)
+	if len(destinations) == 0 {
+		e.terminal = append(e.terminal, sig)
+		return true
+	}
+	for _, dest := range destinations {
+		agent, ok := e.router.GetAgent(dest)
+		if !ok {
+			continue
+		}
+		delivered := sig.WithDestination(dest)
+		ctx, cancel := context.WithTimeout(signal.WithFactory(context.Background(), e.factory), e.config.ProcessTimeout)
+		e.mu.Lock()
+		e.deferred++
+		e.mu.Unlock()
+		signal.CallDeferred(ctx, agent, delivered, func(result signal.AgentResult) {
+			e.mu.Lock()
+			e.deferred--
+			e.completed = append(e.completed, Delivery{Agent: dest, Signal: delivered, Result: result})
+			e.mu.Unlock()
+		})
+		cancel()
+		e.drain()
+	}
+	return true
+}
+
+// drain records the completed Process calls, queueing their outputs.
+func (e *Engine) drain() {
+	e.mu.Lock()
+	completed := e.completed
+	e.completed = nil
+	e.mu.Unlock()
+
+	for _, delivery := range completed {
+		e.deliveries = append(e.deliveries, delivery)
+		if delivery.Result.Error != nil {
+			e.failures = append(e.failures, Failure{Agent: delivery.Agent, Signal: delivery.Signal, Err: delivery.Result.Error})
+			continue
+		}
+		for _, out := range delivery.Result.Signals {
+			out = out.WithSource(delivery.Agent)
+			if out.Metadata[signal.MetaTraceID] == "" {
+				out.Metadata[signal.MetaTraceID] = signal.TraceID(delivery.Signal)
+			}
+			e.emitted = append(e.emitted, out)
+			e.enqueue(out)
+		}
+	}
+}
+
+func (e *Engine) enqueue(sig *signal.Signal) {
+	e.seen[sig.ID] = sig
+	e.queue = append(e.queue, sig)
+}
+
+// Pending returns the number of queued signals.
+func (e *Engine) Pending() int {
+	return len(e.queue)
+}
+
+// Deferred returns the number of Process calls whose deferred result has
+// not been completed yet.
+func (e *Engine) Deferred() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.deferred
+}
+
+// Deliveries returns every Process call, in the order their results were
+// recorded.
+func (e *Engine) Deliveries() []Delivery {
+	return append([]Delivery(nil), e.deliveries...)
+}
+
+// Emitted returns the signals emitted by agents, in emission order, with
+// Source set to the emitting agent. Submitted signals are not included.
+func (e *Engine) Emitted() []*signal.Signal {
+	return append([]*signal.Signal(nil), e.emitted...)
+}
+
+// EmittedBy returns the signals emitted by one agent, in order.
+func (e *Engine) EmittedBy(agentID string) []*signal.Signal {
+	var out []*signal.Signal
+	for _, sig := range e.emitted {
+		if sig.Source == agentID {
+			out = append(out, sig)
+		}
+	}
+	return out
+}
+
+// Terminal returns the signals no agent was routed to, such as final
+// responses leaving the flow, in order.
+func (e *Engine) Terminal() []*signal.Signal {
+	return append([]*signal.Signal(nil), e.terminal...)
+}
+
+// Failures returns the errors returned by agents, in order.
+func (e *Engine) Failures() []Failure {
+	return append([]Failure(nil), e.failures...)
+}
+
+// Lineage returns the chain of signals that led to sig, from the submitted
+// root to sig itself, following ParentID through every signal the engine
+// has seen.
+func (e *Engine) Lineage(sig *signal.Signal) []*signal.Signal {
+	chain := []*signal.Signal{sig}
+	for sig.ParentID != "" && len(chain) <= len(e.seen) {
+		parent, ok := e.seen[sig.ParentID]
+		if !ok {
+			break
+		}
+		chain = append(chain, parent)
+		sig = parent
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
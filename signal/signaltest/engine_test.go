@@ -0,0 +1,175 @@
+package signaltest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// newFanoutRouter routes "request" to a splitter that fans out to two
+// uppercasing workers whose results are terminal.
+func newFanoutRouter() *signal.Router {
+	router := signal.NewRouter()
+	router.Register(signal.NewAgentFunc("splitter", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+		words := strings.Fields(sig.Payload.(string))
+		return signal.OK(
+			sig.Derive("word", words[0]).WithDestination("upper"),
+			sig.Derive("word", words[1]).WithDestination("upper"),
+		)
+	}))
+	router.Register(signal.NewAgentFunc("upper", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+		if sig.Payload == "bad" {
+			return signal.Err(errors.New("cannot uppercase"))
+		}
+		return signal.OK(sig.Derive("result", strings.ToUpper(sig.Payload.(string))))
+	}))
+	router.AddRule(func(sig *signal.Signal) []string {
+		if sig.Type == "request" {
+			return []string{"splitter"}
+		}
+		return nil
+	})
+	return router
+}
+
+func TestEngineRunsUntilQuiescent(t *testing.T) {
+	engine := NewEngine(newFanoutRouter(), Config{})
+	request := signal.NewSignal("request", "hello world")
+	engine.Submit(request)
+
+	if err := engine.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	AssertNoFailures(t, engine)
+	AssertSignals(t, engine.Emitted(),
+		Want{Type: "word", Source: "splitter", Destination: "upper", ParentID: request.ID, Payload: "hello"},
+		Want{Type: "word", Source: "splitter", Payload: "world"},
+		Want{Type: "result", Source: "upper", Payload: "HELLO"},
+		Want{Type: "result", Source: "upper", Payload: "WORLD"},
+	)
+	AssertTypes(t, engine.Terminal(), "result", "result")
+
+	final := engine.Terminal()[1]
+	AssertLineage(t, engine.Lineage(final), "request", "word", "result")
+	if engine.Lineage(final)[0] != request {
+		t.Error("Lineage should start at the submitted signal")
+	}
+	if len(engine.Deliveries()) != 3 || engine.Pending() != 0 {
+		t.Errorf("Deliveries = %d, pending = %d", len(engine.Deliveries()), engine.Pending())
+	}
+}
+
+func TestEngineRecordsFailures(t *testing.T) {
+	engine := NewEngine(newFanoutRouter(), Config{})
+	engine.Submit(signal.NewSignal("request", "bad good"))
+	engine.Run()
+
+	failures := engine.Failures()
+	if len(failures) != 1 || failures[0].Agent != "upper" || failures[0].Signal.Payload != "bad" {
+		t.Fatalf("Failures = %+v, want the bad word", failures)
+	}
+	AssertContains(t, engine.EmittedBy("upper"), Want{Payload: "GOOD"})
+}
+
+func TestEngineStep(t *testing.T) {
+	engine := NewEngine(newFanoutRouter(), Config{})
+	engine.Submit(signal.NewSignal("request", "a b"))
+
+	if !engine.Step() || engine.Pending() != 2 {
+		t.Fatalf("After one step, pending = %d, want 2 words", engine.Pending())
+	}
+	engine.Run()
+	if engine.Step() {
+		t.Error("Step() on an empty queue should return false")
+	}
+}
+
+func TestEngineDetectsEndlessFlows(t *testing.T) {
+	router := signal.NewRouter()
+	router.Register(signal.NewAgentFunc("echo", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+		return signal.OK(sig.Derive("ping", nil).WithDestination("echo"))
+	}))
+	engine := NewEngine(router, Config{MaxSteps: 50})
+	engine.Submit(signal.NewSignal("ping", nil).WithDestination("echo"))
+
+	if err := engine.Run(); err == nil {
+		t.Fatal("Run() should fail for a flow that never quiesces")
+	}
+}
+
+func TestEngineKeepsDeferredResultsPending(t *testing.T) {
+	clock := NewClock()
+	router := signal.NewRouter()
+	router.Register(signal.NewBatchAgent("batcher", func(ctx context.Context, signals []*signal.Signal) signal.AgentResult {
+		var out []*signal.Signal
+		for _, sig := range signals {
+			out = append(out, sig.Derive("done", len(signals)))
+		}
+		return signal.OK(out...)
+	}, signal.BatchConfig{MaxSize: 10, MaxWait: time.Minute, Clock: clock}))
+	engine := NewEngine(router, Config{Clock: clock})
+	engine.Submit(
+		signal.NewSignal("item", nil).WithDestination("batcher"),
+		signal.NewSignal("item", nil).WithDestination("batcher"),
+	)
+
+	if err := engine.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if engine.Deferred() != 2 || len(engine.Deliveries()) != 0 || len(engine.Emitted()) != 0 {
+		t.Fatalf("Before MaxWait: deferred = %d, deliveries = %d, emitted = %d, want 2 pending calls",
+			engine.Deferred(), len(engine.Deliveries()), len(engine.Emitted()))
+	}
+
+	if err := engine.Advance(time.Minute); err != nil {
+		t.Fatalf("Advance() error = %v", err)
+	}
+	if engine.Deferred() != 0 || len(engine.Deliveries()) != 2 {
+		t.Fatalf("After MaxWait: deferred = %d, deliveries = %d, want 2 completed calls",
+			engine.Deferred(), len(engine.Deliveries()))
+	}
+	AssertNoFailures(t, engine)
+	AssertSignals(t, engine.Emitted(),
+		Want{Type: "done", Source: "batcher", Payload: 2},
+		Want{Type: "done", Source: "batcher", Payload: 2},
+	)
+}
+
+func TestEngineAdvance(t *testing.T) {
+	engine := NewEngine(newFanoutRouter(), Config{})
+	engine.Clock().AfterFunc(time.Minute, func() {
+		engine.Submit(signal.NewSignal("request", "late arrival"))
+	})
+
+	engine.Advance(59 * time.Second)
+	if len(engine.Emitted()) != 0 {
+		t.Fatal("Timer fired early")
+	}
+	engine.Advance(time.Second)
+	AssertTypes(t, engine.Terminal(), "result", "result")
+	if got := engine.Clock().Now(); !got.Equal(Epoch.Add(time.Minute)) {
+		t.Errorf("Now() = %v, want a minute past Epoch", got)
+	}
+}
+
+func TestWantMatch(t *testing.T) {
+	sig := signal.NewSignal("result", map[string]int{"n": 1}).WithMetadata("k", "v")
+
+	if err := (Want{Type: "result", Payload: map[string]int{"n": 1}, Metadata: map[string]string{"k": "v"}}).Match(sig); err != nil {
+		t.Errorf("Match() error = %v", err)
+	}
+	for _, want := range []Want{
+		{Type: "other"},
+		{Payload: map[string]int{"n": 2}},
+		{Metadata: map[string]string{"k": "x"}},
+		{Check: func(*signal.Signal) error { return errors.New("custom") }},
+	} {
+		if want.Match(sig) == nil {
+			t.Errorf("Match(%+v) should fail", want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package signal
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// TAPS: Internal Event Observers
+// =============================================================================
+
+// tapSet holds internal observers of engine events. Unlike the single-slot
+// hooks, any number of taps can be attached and detached at runtime (the
+// gateway attaches one per waiting request and stream, a recording one per
+// Recorder). Observers run on worker goroutines and must not block.
+type tapSet[T any] struct {
+	mu       sync.Mutex
+	next     int
+	taps     map[int]func(T)
+	snapshot atomic.Pointer[[]func(T)] // Read without locking
+}
+
+// AgentCall is a completed agent call, observed through OnAgentCall.
+type AgentCall struct {
+	Agent    string
+	Signal   *Signal // As delivered, Destination set
+	Result   AgentResult
+	Duration time.Duration // Until the result was returned or completed, by the engine's clock
+}
+
+// AgentCallHook is called after every agent call.
+type AgentCallHook func(call AgentCall)
+
+// OnAgentCall adds a hook called after every agent call completes, failed
+// or not, including deferred results, delivered signals and calls that are
+// not logged because of sampling. Any number of hooks can be added; the
+// returned function removes this one. Hooks run on worker goroutines and
+// must not block.
+func (e *Engine) OnAgentCall(hook AgentCallHook) (remove func()) {
+	return e.calls.add(hook)
+}
+
+// add attaches an observer and returns a function that detaches it.
+func (t *tapSet[T]) add(fn func(T)) (remove func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.taps == nil {
+		t.taps = make(map[int]func(T))
+	}
+	id := t.next
+	t.next++
+	t.taps[id] = fn
+	t.publish()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.taps, id)
+		t.publish()
+	}
+}
+
+// publish rebuilds the snapshot. Caller must hold t.mu.
+func (t *tapSet[T]) publish() {
+	fns := make([]func(T), 0, len(t.taps))
+	for _, fn := range t.taps {
+		fns = append(fns, fn)
+	}
+	t.snapshot.Store(&fns)
+}
+
+// notify calls every attached observer.
+func (t *tapSet[T]) notify(event T) {
+	fns := t.snapshot.Load()
+	if fns == nil {
+		return
+	}
+	for _, fn := range *fns {
+		fn(event)
+	}
+}
@@ -0,0 +1,412 @@
+package signal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// TRANSPORT: Connecting Engines Across Processes
+// =============================================================================
+
+// Transport opens stream connections between processes. Signals travel over
+// them as codec envelopes in newline-delimited JSON frames.
+type Transport interface {
+	Listen(address string) (net.Listener, error)
+	Dial(ctx context.Context, address string) (net.Conn, error)
+}
+
+// netTransport is a Transport over a net package network.
+type netTransport struct {
+	network string
+}
+
+// TCPTransport returns a transport over TCP; addresses are "host:port".
+func TCPTransport() Transport {
+	return netTransport{network: "tcp"}
+}
+
+// UnixTransport returns a transport over Unix domain sockets; addresses are
+// socket file paths.
+func UnixTransport() Transport {
+	return netTransport{network: "unix"}
+}
+
+func (t netTransport) Listen(address string) (net.Listener, error) {
+	return net.Listen(t.network, address)
+}
+
+func (t netTransport) Dial(ctx context.Context, address string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, t.network, address)
+}
+
+// ErrConnectionLost is returned for remote calls whose connection broke
+// before the result arrived.
+var ErrConnectionLost = errors.New("connection lost")
+
+// ErrTooManyCalls is reported for remote calls refused because their
+// connection already runs ServerConfig.MaxCalls calls.
+var ErrTooManyCalls = errors.New("too many concurrent calls")
+
+// RemoteError is an error returned by an agent in another process.
+type RemoteError struct {
+	Agent   string // Remote agent ID
+	Message string // Error text reported by the remote process
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("remote agent '%s': %s", e.Agent, e.Message)
+}
+
+// frameKind identifies a wire message.
+type frameKind string
+
+const (
+	frameCall   frameKind = "call"   // Process a signal in a remote agent
+	frameResult frameKind = "result" // Result of a call, matched by Seq
+	framePing   frameKind = "ping"   // Heartbeat request
+	framePong   frameKind = "pong"   // Heartbeat reply
+)
+
+// frame is one wire message.
+type frame struct {
+	Kind    frameKind     `json:"kind"`
+	Seq     uint64        `json:"seq,omitempty"`
+	Agent   string        `json:"agent,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty"` // Caller's remaining deadline
+	Signal  *Envelope     `json:"signal,omitempty"`
+	Signals []Envelope    `json:"signals,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// defaultMaxFrame is the default bound on the size of one received frame.
+const defaultMaxFrame = 16 << 20
+
+// ErrFrameTooLarge is returned when a peer sends a frame over the
+// connection's size limit; the connection is then closed.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// wireConn frames messages on a connection. Sends are serialized; every
+// read and write is bounded by a deadline so a silent peer is detected, and
+// every received frame by a size limit so a peer cannot exhaust memory.
+type wireConn struct {
+	conn    net.Conn
+	frames  *bufio.Scanner
+	timeout time.Duration
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newWireConn(conn net.Conn, timeout time.Duration, maxFrame int) *wireConn {
+	frames := bufio.NewScanner(conn)
+	frames.Buffer(make([]byte, 0, min(64*1024, maxFrame)), maxFrame)
+	return &wireConn{
+		conn:    conn,
+		frames:  frames,
+		enc:     json.NewEncoder(conn),
+		timeout: timeout,
+	}
+}
+
+// send writes a frame.
+func (w *wireConn) send(f frame) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	return w.enc.Encode(f)
+}
+
+// receive reads the next frame, failing if none arrives within the timeout
+// or if it exceeds the size limit.
+func (w *wireConn) receive() (frame, error) {
+	var f frame
+	w.conn.SetReadDeadline(time.Now().Add(w.timeout))
+	if !w.frames.Scan() {
+		switch err := w.frames.Err(); {
+		case errors.Is(err, bufio.ErrTooLong):
+			return f, ErrFrameTooLarge
+		case err != nil:
+			return f, err
+		}
+		return f, io.EOF
+	}
+	err := json.Unmarshal(w.frames.Bytes(), &f)
+	return f, err
+}
+
+func (w *wireConn) close() error {
+	return w.conn.Close()
+}
+
+// =============================================================================
+// SERVER: Exposing an Engine's Agents
+// =============================================================================
+
+// ServerConfig configures a Server.
+type ServerConfig struct {
+	// Transport defaults to TCPTransport().
+	Transport Transport
+
+	// Codec decodes incoming signals and encodes outputs. Defaults to a
+	// codec without registered types, so payloads arrive as generic JSON.
+	Codec *Codec
+
+	// Agents lists the agent IDs remote callers may use. Empty exposes
+	// every agent registered with the engine.
+	Agents []string
+
+	// IdleTimeout closes connections that send nothing (not even a
+	// heartbeat) for this long. Defaults to 15 seconds.
+	IdleTimeout time.Duration
+
+	// MaxFrameBytes bounds the size of one incoming frame; a connection
+	// sending a larger one is closed. Defaults to 16 MiB.
+	MaxFrameBytes int
+
+	// MaxCalls bounds the calls running at once for one connection; further
+	// calls fail with ErrTooManyCalls until one returns. Defaults to 64.
+	MaxCalls int
+
+	// Identity is the Source of every incoming signal, replacing the one
+	// sent by the caller, so the engine's Policy decides what remote
+	// callers may reach (e.g. a rule for "remote"). Defaults to "remote".
+	Identity string
+}
+
+// Server exposes the agents of a local Engine to RemoteAgent proxies in
+// other processes. Each call is delivered through the running Engine (see
+// Engine.Deliver): validated, held while the agent is paused, observed by
+// hooks and loggers and pinned against hot swaps, bounded by the caller's
+// deadline and the engine's ProcessTimeout. Outputs are returned to the
+// caller, whose engine routes them; they are not submitted locally.
+type Server struct {
+	engine  *Engine
+	config  ServerConfig
+	exposed map[string]bool
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[*wireConn]context.CancelFunc
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer creates a server for an engine's agents.
+func NewServer(engine *Engine, config ServerConfig) *Server {
+	if config.Transport == nil {
+		config.Transport = TCPTransport()
+	}
+	if config.Codec == nil {
+		config.Codec = NewCodec()
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = 15 * time.Second
+	}
+	if config.MaxFrameBytes <= 0 {
+		config.MaxFrameBytes = defaultMaxFrame
+	}
+	if config.MaxCalls <= 0 {
+		config.MaxCalls = 64
+	}
+	if config.Identity == "" {
+		config.Identity = "remote"
+	}
+	var exposed map[string]bool
+	if len(config.Agents) > 0 {
+		exposed = make(map[string]bool, len(config.Agents))
+		for _, id := range config.Agents {
+			exposed[id] = true
+		}
+	}
+	return &Server{
+		engine:  engine,
+		config:  config,
+		exposed: exposed,
+		conns:   make(map[*wireConn]context.CancelFunc),
+	}
+}
+
+// Listen starts accepting connections on address in the background.
+func (s *Server) Listen(address string) error {
+	listener, err := s.config.Transport.Listen(address)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", address, err)
+	}
+	go s.Serve(listener)
+	return nil
+}
+
+// Serve accepts connections on listener until Close. It always returns a
+// non-nil error; after Close, the error is net.ErrClosed.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return net.ErrClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		s.serveConn(conn)
+	}
+}
+
+// Addr returns the listening address, or nil before Listen or Serve.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close stops accepting connections, closes the open ones (cancelling
+// their calls) and waits for the calls to return.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for w, cancel := range s.conns {
+		cancel()
+		w.close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// serveConn reads frames from a connection until it fails or goes idle.
+func (s *Server) serveConn(conn net.Conn) {
+	w := newWireConn(conn, s.config.IdleTimeout, s.config.MaxFrameBytes)
+	calls := make(chan struct{}, s.config.MaxCalls) // Slots of running calls
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		cancel()
+		conn.Close()
+		return
+	}
+	s.conns[w] = cancel
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.conns, w)
+			s.mu.Unlock()
+			cancel()
+			w.close()
+		}()
+
+		for {
+			f, err := w.receive()
+			if err != nil {
+				return
+			}
+			switch f.Kind {
+			case framePing:
+				w.send(frame{Kind: framePong})
+			case frameCall:
+				select {
+				case calls <- struct{}{}:
+				default:
+					w.send(frame{Kind: frameResult, Seq: f.Seq, Error: fmt.Sprintf("%v: %d running", ErrTooManyCalls, s.config.MaxCalls)})
+					continue
+				}
+				s.wg.Add(1)
+				go func() {
+					defer s.wg.Done()
+					defer func() { <-calls }()
+					w.send(s.call(ctx, f))
+				}()
+			}
+		}
+	}()
+}
+
+// call runs one remote call and builds its result frame.
+func (s *Server) call(ctx context.Context, f frame) frame {
+	reply := frame{Kind: frameResult, Seq: f.Seq}
+	fail := func(err error) frame {
+		reply.Error = err.Error()
+		return reply
+	}
+
+	if f.Signal == nil {
+		return fail(errors.New("call without a signal"))
+	}
+	if s.exposed != nil && !s.exposed[f.Agent] {
+		return fail(fmt.Errorf("agent '%s' not found", f.Agent))
+	}
+	// The frame's target is not signed: a signed call goes to the agent its
+	// signature covers, or a captured call could be sent to another agent
+	if f.Signal.Signature != nil && f.Signal.Destination != f.Agent {
+		return fail(fmt.Errorf("%w (id=%s): signed for agent '%s', not '%s'",
+			ErrInvalidSignature, truncateID(f.Signal.ID), f.Signal.Destination, f.Agent))
+	}
+	sig, err := s.config.Codec.Unwrap(*f.Signal)
+	if err != nil {
+		return fail(err)
+	}
+	sig.Source = s.config.Identity // The caller's claim is not trusted
+
+	timeout := s.engine.config.ProcessTimeout
+	if f.Timeout > 0 && f.Timeout < timeout {
+		timeout = f.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Deliver through the engine, so the call is validated, held while the
+	// agent is paused, observed and drained by hot swaps like a routed one
+	if err := s.config.Codec.Accept(sig); err != nil {
+		return fail(err)
+	}
+	results := make(chan AgentResult, 1)
+	s.engine.Deliver(ctx, f.Agent, sig.WithDestination(f.Agent), func(result AgentResult) {
+		results <- result
+	})
+	var result AgentResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		s.config.Codec.Forget(sig)
+		return fail(fmt.Errorf("agent '%s': %w", f.Agent, ctx.Err()))
+	}
+	if result.Error != nil {
+		s.config.Codec.Forget(sig) // The caller may retry
+		return fail(result.Error)
+	}
+	for _, out := range result.Signals {
+		env, err := s.config.Codec.Wrap(out)
+		if err != nil {
+			return fail(err)
+		}
+		reply.Signals = append(reply.Signals, env)
+	}
+	return reply
+}
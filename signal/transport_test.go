@@ -0,0 +1,272 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newWorkerServer starts an engine hosting a "summarizer" agent that
+// uppercases string payloads, exposed by a Server on a free address.
+func newWorkerServer(t *testing.T, transport Transport, address string) (*Server, string) {
+	t.Helper()
+	return newWorkerServerWith(t, ServerConfig{Transport: transport}, address)
+}
+
+// newWorkerServerWith is newWorkerServer with server settings; only
+// "summarizer" is exposed.
+func newWorkerServerWith(t *testing.T, config ServerConfig, address string) (*Server, string) {
+	t.Helper()
+	router := NewRouter()
+	router.Register(NewAgentFunc("summarizer", func(ctx context.Context, sig *Signal) AgentResult {
+		text, _ := sig.Payload.(string)
+		switch text {
+		case "fail":
+			return Err(errors.New("model unavailable"))
+		case "slow":
+			<-ctx.Done()
+			return Err(ctx.Err())
+		}
+		return OK(sig.Derive("summary", strings.ToUpper(text)))
+	}))
+	router.Register(NewAgentFunc("private", func(ctx context.Context, sig *Signal) AgentResult {
+		return OK()
+	}))
+	engine := NewEngine(DefaultConfig(), router)
+	engine.Start()
+	t.Cleanup(func() { engine.Stop() }) // Runs after the server is closed
+
+	config.Agents = []string{"summarizer"}
+	server := NewServer(engine, config)
+	if err := server.Listen(address); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	waitFor(t, func() bool { return server.Addr() != nil })
+	t.Cleanup(func() { server.Close() })
+	return server, server.Addr().String()
+}
+
+func fastRemote(address string, transport Transport) RemoteConfig {
+	return RemoteConfig{
+		Address:           address,
+		Transport:         transport,
+		HeartbeatInterval: 20 * time.Millisecond,
+		ReconnectMin:      5 * time.Millisecond,
+		ReconnectMax:      20 * time.Millisecond,
+	}
+}
+
+func TestRemoteAgentAcrossEngines(t *testing.T) {
+	_, address := newWorkerServer(t, TCPTransport(), "127.0.0.1:0")
+
+	summaries := make(chan *Signal, 1)
+	router := NewRouter()
+	router.Register(NewRemoteAgent("summarizer", fastRemote(address, TCPTransport())))
+	router.Register(NewAgentFunc("output", func(ctx context.Context, sig *Signal) AgentResult {
+		summaries <- sig
+		return OK()
+	}))
+	router.AddRule(func(sig *Signal) []string {
+		if sig.Type == "summary" {
+			return []string{"output"}
+		}
+		return []string{"summarizer"}
+	})
+	engine := NewEngine(DefaultConfig(), router)
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer engine.Stop()
+
+	request := NewSignal("text", "hello").WithMetadata("tenant", "acme")
+	engine.Submit(request)
+
+	select {
+	case sig := <-summaries:
+		if sig.Payload != "HELLO" || sig.ParentID != request.ID || sig.Source != "summarizer" || sig.Metadata["tenant"] != "acme" {
+			t.Errorf("Summary = %+v, want uppercased output with lineage", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("No summary routed back to the local engine")
+	}
+	if report := engine.Health(context.Background()); !report.Healthy {
+		t.Errorf("Health() = %v, want connected", report.Err())
+	}
+}
+
+func TestRemoteAgentErrorsAndTimeouts(t *testing.T) {
+	_, address := newWorkerServer(t, TCPTransport(), "127.0.0.1:0")
+	remote := NewRemoteAgent("summarizer", fastRemote(address, TCPTransport()))
+	defer remote.Close(context.Background())
+
+	result := remote.Process(context.Background(), NewSignal("text", "fail"))
+	var remoteErr *RemoteError
+	if !errors.As(result.Error, &remoteErr) || remoteErr.Message != "model unavailable" {
+		t.Errorf("Error = %v, want the remote agent's error", result.Error)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result = remote.Process(ctx, NewSignal("text", "slow"))
+	if result.Error == nil || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Slow call = %v after %v, want a timely deadline error", result.Error, time.Since(start))
+	}
+
+	private := NewRemoteAgent("private", fastRemote(address, TCPTransport()))
+	defer private.Close(context.Background())
+	if result := private.Process(context.Background(), NewSignal("text", "x")); !errors.As(result.Error, &remoteErr) {
+		t.Errorf("Error = %v, want unexposed agent rejected", result.Error)
+	}
+}
+
+func TestServerDeliversThroughEngine(t *testing.T) {
+	server, address := newWorkerServer(t, TCPTransport(), "127.0.0.1:0")
+	engine := server.engine
+	processed := make(chan string, 4)
+	engine.OnSignalProcessed(func(sig *Signal, result AgentResult) { processed <- sig.Destination })
+	remote := NewRemoteAgent("summarizer", fastRemote(address, TCPTransport()))
+	defer remote.Close(context.Background())
+
+	// A call to a paused agent is held until the agent is resumed
+	engine.PauseAgent("summarizer")
+	results := make(chan AgentResult, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		results <- remote.Process(ctx, NewSignal("text", "held"))
+	}()
+	waitFor(t, func() bool { return engine.Stats().Held["summarizer"] == 1 })
+	engine.ResumeAgent("summarizer")
+	if result := <-results; result.Error != nil || len(result.Signals) != 1 || result.Signals[0].Payload != "HELD" {
+		t.Errorf("Held call = %+v", result)
+	}
+	if got := <-processed; got != "summarizer" {
+		t.Errorf("Processed hook saw %q, want the remote call", got)
+	}
+}
+
+func TestRemoteAgentReconnects(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "worker.sock")
+	server, _ := newWorkerServer(t, UnixTransport(), socket)
+	remote := NewRemoteAgent("summarizer", fastRemote(socket, UnixTransport()))
+	defer remote.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if result := remote.Process(ctx, NewSignal("text", "a")); result.Error != nil {
+		t.Fatalf("Process() error = %v", result.Error)
+	}
+
+	server.Close()
+	waitFor(t, func() bool { return remote.Health(ctx) != nil })
+
+	newWorkerServer(t, UnixTransport(), socket)
+	result := remote.Process(ctx, NewSignal("text", "b"))
+	if result.Error != nil || result.Signals[0].Payload != "B" {
+		t.Errorf("Process() after restart = %+v, want reconnected", result)
+	}
+}
+
+func TestRemoteAgentHeartbeatTimeout(t *testing.T) {
+	// A peer that accepts connections but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			go func() {
+				io.Copy(io.Discard, conn) // Until the remote gives up on it
+				conn.Close()
+			}()
+		}
+	}()
+
+	config := fastRemote(listener.Addr().String(), TCPTransport())
+	config.HeartbeatTimeout = 50 * time.Millisecond
+	remote := NewRemoteAgent("silent", config)
+	remote.Init(context.Background())
+	defer remote.Close(context.Background())
+
+	waitFor(t, func() bool { return accepted.Load() >= 2 })
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if result := remote.Process(ctx, NewSignal("text", "x")); result.Error == nil {
+		t.Error("Process() against a silent peer should fail")
+	}
+}
+
+// rawCall dials a server and sends call frames for "summarizer" on one
+// connection, bypassing RemoteAgent.
+func rawCall(t *testing.T, address string, calls ...frame) (net.Conn, *json.Decoder) {
+	t.Helper()
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	enc := json.NewEncoder(conn)
+	for _, f := range calls {
+		if err := enc.Encode(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	return conn, json.NewDecoder(conn)
+}
+
+func TestServerBoundsCallsPerConnection(t *testing.T) {
+	_, address := newWorkerServerWith(t, ServerConfig{MaxCalls: 1}, "127.0.0.1:0")
+	codec := NewCodec()
+	slow, _ := codec.Wrap(NewSignal("text", "slow"))
+	fast, _ := codec.Wrap(NewSignal("text", "fast"))
+
+	// The slow call holds the only slot until its timeout
+	_, dec := rawCall(t, address,
+		frame{Kind: frameCall, Seq: 1, Agent: "summarizer", Timeout: 100 * time.Millisecond, Signal: &slow},
+		frame{Kind: frameCall, Seq: 2, Agent: "summarizer", Signal: &fast},
+	)
+	var first, second frame
+	if err := dec.Decode(&first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Seq != 2 || !strings.Contains(first.Error, ErrTooManyCalls.Error()) {
+		t.Errorf("First reply = %+v, want call 2 refused", first)
+	}
+	if err := dec.Decode(&second); err != nil {
+		t.Fatal(err)
+	}
+	if second.Seq != 1 || second.Error == "" {
+		t.Errorf("Second reply = %+v, want call 1 timed out", second)
+	}
+}
+
+func TestServerClosesConnectionsSendingLargeFrames(t *testing.T) {
+	_, address := newWorkerServerWith(t, ServerConfig{MaxFrameBytes: 1024}, "127.0.0.1:0")
+	codec := NewCodec()
+	big, _ := codec.Wrap(NewSignal("text", strings.Repeat("x", 2048)))
+
+	_, dec := rawCall(t, address, frame{Kind: frameCall, Seq: 1, Agent: "summarizer", Signal: &big})
+	// Closed with the frame unread: EOF or a reset, not a read timeout
+	var reply frame
+	err := dec.Decode(&reply)
+	var netErr net.Error
+	if err == nil || errors.As(err, &netErr) && netErr.Timeout() {
+		t.Errorf("Decode() = %+v, %v, want the connection closed", reply, err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package workflow declares multi-step agent flows as directed acyclic graphs
+// and runs them on a signal.Engine. A Definition lists steps (agent IDs),
+// their dependencies, how each step's input is built from earlier outputs,
+// and optional conditions. Definitions can be written in Go or loaded from YAML.
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+	"gopkg.in/yaml.v3"
+)
+
+// InputWorkflow is the Step.Input value selecting the workflow's own input.
+const InputWorkflow = "$input"
+
+// Definition describes a workflow as a DAG of steps.
+type Definition struct {
+	Name  string `yaml:"name"`
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is one node of the workflow graph, executed by a registered agent.
+type Step struct {
+	// ID uniquely identifies the step within the workflow.
+	ID string `yaml:"id"`
+
+	// Agent is the ID of the registered agent that executes the step.
+	Agent string `yaml:"agent"`
+
+	// DependsOn lists the steps that must finish before this one starts.
+	DependsOn []string `yaml:"depends_on,omitempty"`
+
+	// Type is the signal type sent to the agent. Defaults to SignalStep.
+	Type signal.SignalType `yaml:"type,omitempty"`
+
+	// Input selects the payload passed to the agent: a dependency's step ID
+	// (its first output payload) or InputWorkflow. When empty, a root step
+	// receives the workflow input, a step with one dependency receives that
+	// dependency's output, and a step with several receives a map of
+	// dependency ID to output payload.
+	Input string `yaml:"input,omitempty"`
+
+	// When makes the step conditional. A step whose condition is false is
+	// skipped, and so are steps whose dependencies were all skipped.
+	When *Condition `yaml:"when,omitempty"`
+
+	// Map builds the input payload in Go, overriding Input.
+	Map func(in Inputs) (any, error) `yaml:"-"`
+
+	// Timeout fails the step if its outcome has not arrived in time.
+	// Zero means the step is only bounded by the engine's ProcessTimeout.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// Compensate declares the signal that undoes this step. A workflow with
+	// compensations runs as a saga: when a step fails or times out, no new
+	// steps start, and compensations of completed steps are sent one at a
+	// time in reverse completion order.
+	Compensate *Compensation `yaml:"compensate,omitempty"`
+}
+
+// Compensation is the compensating action of a saga step.
+type Compensation struct {
+	// Agent executes the compensation. Defaults to the step's agent.
+	Agent string `yaml:"agent,omitempty"`
+
+	// Type is the compensating signal's type. Defaults to SignalCompensate.
+	Type signal.SignalType `yaml:"type,omitempty"`
+
+	// Map builds the compensation payload from the step's outcome.
+	// Defaults to the step's first output payload.
+	Map func(outcome *StepOutcome) any `yaml:"-"`
+}
+
+// Condition is a conditional edge evaluated on a dependency's output.
+// All set fields must match. In Go, If may be used instead of (or in
+// addition to) the declarative fields.
+type Condition struct {
+	Step     string            `yaml:"step"`               // Dependency whose output is inspected
+	Type     signal.SignalType `yaml:"type,omitempty"`     // Required output signal type
+	Metadata map[string]string `yaml:"metadata,omitempty"` // Required output metadata values
+
+	If func(in Inputs) bool `yaml:"-"`
+}
+
+// Inputs gives input mappings and conditions access to earlier results.
+type Inputs struct {
+	Workflow any                     // The workflow's input payload
+	Steps    map[string]*StepOutcome // Outcomes of finished steps by ID
+}
+
+// Output returns the first output payload of a completed step, or nil.
+func (in Inputs) Output(stepID string) any {
+	if o, ok := in.Steps[stepID]; ok && len(o.Signals) > 0 {
+		return o.Signals[0].Payload
+	}
+	return nil
+}
+
+// matches evaluates the condition against finished step outcomes.
+func (c *Condition) matches(in Inputs) bool {
+	if c.Step != "" {
+		o, ok := in.Steps[c.Step]
+		if !ok || o.Err != nil || len(o.Signals) == 0 {
+			return false
+		}
+		out := o.Signals[0]
+		if c.Type != "" && out.Type != c.Type {
+			return false
+		}
+		for k, v := range c.Metadata {
+			if out.Metadata[k] != v {
+				return false
+			}
+		}
+	}
+	if c.If != nil && !c.If(in) {
+		return false
+	}
+	return true
+}
+
+// =============================================================================
+// LOADING
+// =============================================================================
+
+// ParseDefinition parses a YAML workflow definition.
+func ParseDefinition(data []byte) (*Definition, error) {
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("parse workflow: %w", err)
+	}
+	return &def, nil
+}
+
+// LoadDefinition reads and parses a YAML workflow definition file.
+func LoadDefinition(path string) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read workflow: %w", err)
+	}
+	return ParseDefinition(data)
+}
+
+// =============================================================================
+// VALIDATION
+// =============================================================================
+
+// Validate checks the definition for structural errors: missing or duplicate
+// step IDs, unknown dependencies, invalid input or condition references, and
+// cycles. If router is non-nil, every step agent must also be registered.
+func (d *Definition) Validate(router *signal.Router) error {
+	if d.Name == "" {
+		return fmt.Errorf("workflow has no name")
+	}
+	if len(d.Steps) == 0 {
+		return fmt.Errorf("workflow '%s' has no steps", d.Name)
+	}
+
+	steps := make(map[string]*Step, len(d.Steps))
+	for i := range d.Steps {
+		s := &d.Steps[i]
+		if s.ID == "" {
+			return fmt.Errorf("workflow '%s': step %d has no id", d.Name, i)
+		}
+		if _, dup := steps[s.ID]; dup {
+			return fmt.Errorf("workflow '%s': duplicate step '%s'", d.Name, s.ID)
+		}
+		if s.Agent == "" {
+			return fmt.Errorf("workflow '%s': step '%s' has no agent", d.Name, s.ID)
+		}
+		if router != nil {
+			if _, ok := router.GetAgent(s.Agent); !ok {
+				return fmt.Errorf("workflow '%s': step '%s' uses unknown agent '%s'", d.Name, s.ID, s.Agent)
+			}
+			if c := s.Compensate; c != nil && c.Agent != "" {
+				if _, ok := router.GetAgent(c.Agent); !ok {
+					return fmt.Errorf("workflow '%s': step '%s' compensates with unknown agent '%s'", d.Name, s.ID, c.Agent)
+				}
+			}
+		}
+		steps[s.ID] = s
+	}
+
+	for _, s := range steps {
+		deps := make(map[string]bool, len(s.DependsOn))
+		for _, dep := range s.DependsOn {
+			if _, ok := steps[dep]; !ok {
+				return fmt.Errorf("workflow '%s': step '%s' depends on unknown step '%s'", d.Name, s.ID, dep)
+			}
+			deps[dep] = true
+		}
+		if s.Input != "" && s.Input != InputWorkflow && !deps[s.Input] {
+			return fmt.Errorf("workflow '%s': step '%s' takes input from '%s', which is not a dependency", d.Name, s.ID, s.Input)
+		}
+		if s.When != nil && s.When.Step != "" && !deps[s.When.Step] {
+			return fmt.Errorf("workflow '%s': step '%s' has a condition on '%s', which is not a dependency", d.Name, s.ID, s.When.Step)
+		}
+	}
+
+	if cycle := d.findCycle(); cycle != nil {
+		return fmt.Errorf("workflow '%s': dependency cycle through %v", d.Name, cycle)
+	}
+	return nil
+}
+
+// IsSaga reports whether any step declares a compensation.
+func (d *Definition) IsSaga() bool {
+	for _, s := range d.Steps {
+		if s.Compensate != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findCycle returns the steps left unordered by a topological sort
+// (those on or behind a cycle), or nil if the graph is acyclic.
+func (d *Definition) findCycle() []string {
+	indegree := make(map[string]int, len(d.Steps))
+	dependents := make(map[string][]string)
+	for _, s := range d.Steps {
+		indegree[s.ID] += 0
+		for _, dep := range s.DependsOn {
+			indegree[s.ID]++
+			dependents[dep] = append(dependents[dep], s.ID)
+		}
+	}
+
+	queue := make([]string, 0, len(d.Steps))
+	for id, n := range indegree {
+		if n == 0 {
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		delete(indegree, id)
+		for _, next := range dependents[id] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if len(indegree) == 0 {
+		return nil
+	}
+	remaining := make([]string, 0, len(indegree))
+	for id := range indegree {
+		remaining = append(remaining, id)
+	}
+	sort.Strings(remaining)
+	return remaining
+}
@@ -0,0 +1,89 @@
+package workflow
+
+import "time"
+
+// =============================================================================
+// SAGA: Compensating Completed Steps
+// =============================================================================
+
+// compensate schedules compensations once an aborted saga has no running
+// steps, and returns the next compensation to dispatch. Compensations run
+// one at a time, in reverse completion order. Timed-out steps that can be
+// compensated are still awaited: one that succeeds late is compensated too.
+// Caller must hold w.mu.
+func (w *Workflow) compensate(r *run) []dispatchable {
+	if r.status.State == RunRunning {
+		for _, st := range r.status.Steps {
+			if st.State == StepRunning {
+				return nil // Wait for in-flight steps before undoing anything
+			}
+		}
+		if len(r.late) > 0 {
+			return nil
+		}
+		r.status.State = RunCompensating
+		for i := len(r.completed) - 1; i >= 0; i-- {
+			if w.steps[r.completed[i]].Compensate != nil {
+				r.compensations = append(r.compensations, r.completed[i])
+			}
+		}
+	}
+
+	if r.compensating != "" || len(r.compensations) == 0 {
+		return nil
+	}
+
+	stepID := r.compensations[0]
+	r.compensations = r.compensations[1:]
+	r.compensating = stepID
+
+	c := w.steps[stepID].Compensate
+	outcome := r.outcomes[stepID]
+	var payload any
+	if c.Map != nil {
+		payload = c.Map(outcome)
+	} else if len(outcome.Signals) > 0 {
+		payload = outcome.Signals[0].Payload
+	}
+
+	return []dispatchable{{
+		stepID:  stepID,
+		agentID: w.compensationAgentID(stepID),
+		typ:     c.Type,
+		input:   payload,
+	}}
+}
+
+// recordCompensation stores the outcome of the in-flight compensation,
+// finished at now.
+func (r *run) recordCompensation(stepID string, outcome *StepOutcome, now time.Time) {
+	if r.compensating != stepID {
+		return
+	}
+	r.compensating = ""
+
+	st := r.status.Steps[stepID]
+	st.Finished = now
+	if outcome.Err != nil {
+		st.State = StepCompensationFailed
+		st.CompensationErr = outcome.Err
+	} else {
+		st.State = StepCompensated
+	}
+	r.status.Steps[stepID] = st
+}
+
+// allCompensated reports whether at least one step was compensated and no
+// compensation failed. A run with nothing to compensate has merely failed.
+func (r *run) allCompensated() bool {
+	compensated := false
+	for _, st := range r.status.Steps {
+		switch st.State {
+		case StepCompensationFailed:
+			return false
+		case StepCompensated:
+			compensated = true
+		}
+	}
+	return compensated
+}
@@ -0,0 +1,330 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// undoLog records the compensations an agent received, in order.
+type undoLog struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *undoLog) add(entry string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func (l *undoLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.entries...)
+}
+
+// bookingAgent emits "<id>:<payload>" for steps and logs compensations.
+func bookingAgent(id string, log *undoLog) signal.Agent {
+	return signal.NewAgentFunc(id, func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+		if sig.Type == SignalCompensate {
+			log.add(id + " undo " + sig.Payload.(string))
+			return signal.OK()
+		}
+		return signal.OK(sig.Derive("booked", id+":"+sig.Payload.(string)))
+	})
+}
+
+func TestSagaCompensatesInReverseOrder(t *testing.T) {
+	log := &undoLog{}
+	engine := newTestEngine(t,
+		bookingAgent("flight", log),
+		bookingAgent("hotel", log),
+		signal.NewAgentFunc("payment", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			return signal.Err(errors.New("card declined"))
+		}),
+	)
+
+	w, err := Compile(&Definition{
+		Name: "trip",
+		Steps: []Step{
+			{ID: "flight", Agent: "flight", Compensate: &Compensation{}},
+			{ID: "hotel", Agent: "hotel", DependsOn: []string{"flight"}, Input: InputWorkflow,
+				Compensate: &Compensation{}},
+			{ID: "pay", Agent: "payment", DependsOn: []string{"hotel"}},
+			{ID: "notify", Agent: "flight", DependsOn: []string{"pay"}},
+		},
+	}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	finished := make(chan Status, 1)
+	w.OnFinish(func(s Status) { finished <- s })
+
+	id, _ := w.Start("hanoi")
+	status := waitRun(t, w, id)
+
+	if status.State != RunCompensated {
+		t.Fatalf("State = %v, want compensated", status.State)
+	}
+	want := []string{"hotel undo hotel:hanoi", "flight undo flight:hanoi"}
+	got := log.get()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Compensations = %v, want %v", got, want)
+	}
+	for _, step := range []string{"flight", "hotel"} {
+		if st := status.Steps[step].State; st != StepCompensated {
+			t.Errorf("%s = %v, want compensated", step, st)
+		}
+	}
+	if st := status.Steps["pay"].State; st != StepFailed {
+		t.Errorf("pay = %v, want failed", st)
+	}
+	if st := status.Steps["notify"].State; st != StepSkipped {
+		t.Errorf("notify = %v, want skipped", st)
+	}
+
+	select {
+	case s := <-finished:
+		if s.InstanceID != id || s.State != RunCompensated {
+			t.Errorf("OnFinish status = %s/%v, want %s/compensated", s.InstanceID, s.State, id)
+		}
+	case <-time.After(time.Second):
+		t.Error("OnFinish was not called")
+	}
+}
+
+func TestSagaCompensationFailure(t *testing.T) {
+	engine := newTestEngine(t,
+		signal.NewAgentFunc("reserve", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			if sig.Type == "release" {
+				return signal.Err(errors.New("inventory offline"))
+			}
+			return signal.OK(sig.Derive("reserved", "sku-1"))
+		}),
+		signal.NewAgentFunc("charge", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			return signal.Err(errors.New("card declined"))
+		}),
+	)
+
+	w, err := Compile(&Definition{
+		Name: "order",
+		Steps: []Step{
+			{ID: "reserve", Agent: "reserve", Compensate: &Compensation{Type: "release"}},
+			{ID: "charge", Agent: "charge", DependsOn: []string{"reserve"}},
+		},
+	}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	id, _ := w.Start(nil)
+	status := waitRun(t, w, id)
+
+	if status.State != RunFailed {
+		t.Errorf("State = %v, want failed", status.State)
+	}
+	if st := status.Steps["reserve"]; st.State != StepCompensationFailed || st.CompensationErr == nil {
+		t.Errorf("reserve = %+v, want compensation failed with error", st)
+	}
+}
+
+func TestSagaWithNothingToCompensateFails(t *testing.T) {
+	log := &undoLog{}
+	engine := newTestEngine(t,
+		bookingAgent("flight", log),
+		signal.NewAgentFunc("reserve", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			return signal.Err(errors.New("sold out"))
+		}),
+	)
+
+	// The only step with a compensation is the one that fails
+	w, err := Compile(&Definition{
+		Name: "order",
+		Steps: []Step{
+			{ID: "flight", Agent: "flight"},
+			{ID: "reserve", Agent: "reserve", DependsOn: []string{"flight"}, Compensate: &Compensation{Type: "release"}},
+		},
+	}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	id, _ := w.Start("paris")
+	if status := waitRun(t, w, id); status.State != RunFailed {
+		t.Errorf("State = %v, want failed", status.State)
+	}
+	if got := log.get(); len(got) != 0 {
+		t.Errorf("Compensations = %v, want none", got)
+	}
+}
+
+func TestSagaStepTimeoutTriggersCompensation(t *testing.T) {
+	log := &undoLog{}
+	release := make(chan struct{})
+	engine := newTestEngine(t,
+		bookingAgent("flight", log),
+		signal.NewAgentFunc("slow", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return signal.OK(sig.Derive("late", nil))
+		}),
+	)
+	t.Cleanup(func() { close(release) }) // Runs before the engine stops
+
+	w, err := Compile(&Definition{
+		Name: "timeout",
+		Steps: []Step{
+			{ID: "flight", Agent: "flight", Compensate: &Compensation{}},
+			{ID: "hotel", Agent: "slow", DependsOn: []string{"flight"}, Timeout: 20 * time.Millisecond},
+		},
+	}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	id, _ := w.Start("paris")
+	status := waitRun(t, w, id)
+
+	if status.State != RunCompensated {
+		t.Fatalf("State = %v, want compensated", status.State)
+	}
+	if st := status.Steps["hotel"]; st.State != StepFailed || st.Err == nil {
+		t.Errorf("hotel = %+v, want failed with timeout", st)
+	}
+	if got := log.get(); len(got) != 1 || got[0] != "flight undo flight:paris" {
+		t.Errorf("Compensations = %v, want flight undone", got)
+	}
+}
+
+func TestSagaCompensatesStepSucceedingAfterTimeout(t *testing.T) {
+	log := &undoLog{}
+	clock := signal.NewManualClock(time.Unix(0, 0))
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	hotel := bookingAgent("hotel", log)
+	router := signal.NewRouter()
+	router.Register(bookingAgent("flight", log))
+	router.Register(signal.NewAgentFunc("slow-hotel", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+		if sig.Type == SignalStep {
+			started <- struct{}{}
+			<-release
+		}
+		return hotel.Process(ctx, sig)
+	}))
+	config := signal.DefaultConfig()
+	config.Clock = clock
+	engine := signal.NewEngine(config, router)
+	engine.Start()
+	t.Cleanup(func() { engine.Stop() })
+	var once sync.Once
+	t.Cleanup(func() { once.Do(func() { close(release) }) }) // Runs before the engine stops
+
+	w, err := Compile(&Definition{
+		Name: "late",
+		Steps: []Step{
+			{ID: "flight", Agent: "flight", Compensate: &Compensation{}},
+			{ID: "hotel", Agent: "slow-hotel", DependsOn: []string{"flight"}, Input: InputWorkflow,
+				Timeout: time.Minute, Compensate: &Compensation{}},
+		},
+	}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	id, _ := w.Start("paris")
+	<-started
+	clock.Advance(time.Minute)
+	waitFor(t, func() bool {
+		status, _ := w.Status(id)
+		return status.Steps["hotel"].State == StepFailed
+	})
+	if status, _ := w.Status(id); status.State != RunRunning || len(log.get()) != 0 {
+		t.Fatalf("State = %v, compensations = %v; want the timed-out step awaited", status.State, log.get())
+	}
+
+	// The booking went through after all, so it is undone with the rest
+	once.Do(func() { close(release) })
+	status := waitRun(t, w, id)
+	if status.State != RunCompensated || status.Steps["hotel"].State != StepCompensated {
+		t.Errorf("State = %v, hotel = %+v; want the late booking compensated", status.State, status.Steps["hotel"])
+	}
+	want := []string{"hotel undo hotel:paris", "flight undo flight:paris"}
+	if got := log.get(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Compensations = %v, want %v", got, want)
+	}
+}
+
+func TestStepTimeoutUsesEngineClock(t *testing.T) {
+	for _, rejected := range []bool{false, true} {
+		t.Run(fmt.Sprintf("timeout rejected=%v", rejected), func(t *testing.T) {
+			clock := signal.NewManualClock(time.Unix(0, 0))
+			started := make(chan struct{}, 1)
+			release := make(chan struct{})
+			router := signal.NewRouter()
+			router.Register(signal.NewAgentFunc("slow", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+				started <- struct{}{}
+				<-release
+				return signal.OK()
+			}))
+			config := signal.DefaultConfig()
+			config.Clock = clock
+			if rejected {
+				// The timeout signal cannot be submitted, as while the engine stops
+				config.Schemas = signal.NewSchemaRegistry()
+				config.Schemas.Register(SignalStepTimeout, signal.Schema{JSON: `{"type": "object"}`})
+			}
+			engine := signal.NewEngine(config, router)
+			engine.Start()
+			defer engine.Stop()
+			defer close(release)
+
+			w, err := Compile(&Definition{
+				Name:  "clocked-timeout",
+				Steps: []Step{{ID: "hotel", Agent: "slow", Timeout: time.Minute}},
+			}, engine)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			id, _ := w.Start("paris")
+			<-started
+			clock.Advance(time.Minute)
+			status := waitRun(t, w, id)
+
+			if st := status.Steps["hotel"]; status.State != RunFailed || st.State != StepFailed || !strings.Contains(fmt.Sprint(st.Err), "timed out") {
+				t.Errorf("Status = %v, hotel = %+v; want failed by the timeout", status.State, st)
+			}
+		})
+	}
+}
+
+func TestParseDefinitionCompensation(t *testing.T) {
+	def, err := ParseDefinition([]byte(`
+name: order
+steps:
+  - id: reserve
+    agent: inventory
+    timeout: 2s
+    compensate:
+      type: release
+`))
+	if err != nil {
+		t.Fatalf("ParseDefinition() error = %v", err)
+	}
+	step := def.Steps[0]
+	if step.Timeout != 2*time.Second || step.Compensate == nil || step.Compensate.Type != "release" {
+		t.Errorf("Step = %+v, want timeout and compensation", step)
+	}
+	if !def.IsSaga() {
+		t.Error("IsSaga() = false, want true")
+	}
+}
@@ -0,0 +1,719 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// Signal types used by the workflow runtime.
+const (
+	// SignalWorkflowStart starts a workflow instance; its payload is the input.
+	SignalWorkflowStart signal.SignalType = "workflow_start"
+	// SignalStep is the default type of the signal sent to a step's agent.
+	SignalStep signal.SignalType = "workflow_step"
+	// SignalStepDone reports a step's outcome back to the workflow coordinator.
+	SignalStepDone signal.SignalType = "workflow_step_done"
+	// SignalStepTimeout tells the coordinator a step exceeded its Timeout.
+	SignalStepTimeout signal.SignalType = "workflow_step_timeout"
+	// SignalCompensate is the default type of a saga compensation signal.
+	SignalCompensate signal.SignalType = "workflow_compensate"
+	// SignalCompensationDone reports a compensation's outcome to the coordinator.
+	SignalCompensationDone signal.SignalType = "workflow_compensation_done"
+)
+
+// Metadata keys set on every signal belonging to a workflow instance.
+const (
+	MetaInstance = "workflow_instance"
+	MetaStep     = "workflow_step"
+)
+
+// =============================================================================
+// STATUS TYPES
+// =============================================================================
+
+// StepState is the lifecycle state of one step in a workflow instance.
+type StepState int
+
+const (
+	StepPending StepState = iota
+	StepRunning
+	StepCompleted
+	StepFailed
+	StepSkipped
+	StepCompensated
+	StepCompensationFailed
+)
+
+// String returns the state name.
+func (s StepState) String() string {
+	switch s {
+	case StepPending:
+		return "pending"
+	case StepRunning:
+		return "running"
+	case StepCompleted:
+		return "completed"
+	case StepFailed:
+		return "failed"
+	case StepSkipped:
+		return "skipped"
+	case StepCompensated:
+		return "compensated"
+	case StepCompensationFailed:
+		return "compensation_failed"
+	default:
+		return fmt.Sprintf("StepState(%d)", int(s))
+	}
+}
+
+// terminal reports whether the step will not change state anymore.
+func (s StepState) terminal() bool {
+	return s != StepPending && s != StepRunning
+}
+
+// RunState is the overall state of a workflow instance.
+type RunState int
+
+const (
+	RunRunning RunState = iota
+	RunCompleted
+	RunFailed
+	// RunCompensating means a saga step failed and compensations are running.
+	RunCompensating
+	// RunCompensated means a saga step failed and every completed step
+	// was successfully compensated. A failed run with nothing to compensate
+	// is RunFailed.
+	RunCompensated
+)
+
+// String returns the state name.
+func (s RunState) String() string {
+	switch s {
+	case RunRunning:
+		return "running"
+	case RunCompleted:
+		return "completed"
+	case RunFailed:
+		return "failed"
+	case RunCompensating:
+		return "compensating"
+	case RunCompensated:
+		return "compensated"
+	default:
+		return fmt.Sprintf("RunState(%d)", int(s))
+	}
+}
+
+// StepOutcome is the result of executing a step's agent.
+type StepOutcome struct {
+	Signals []*signal.Signal // Output signals of the agent
+	Err     error            // Processing error, if any
+}
+
+// StepStatus reports the progress of one step.
+type StepStatus struct {
+	State           StepState
+	Err             error // Step failure
+	CompensationErr error // Compensation failure (sagas)
+	Started         time.Time
+	Finished        time.Time
+}
+
+// Status reports the progress of a workflow instance.
+type Status struct {
+	InstanceID string
+	Workflow   string
+	State      RunState
+	Steps      map[string]StepStatus
+	Started    time.Time
+	Finished   time.Time
+}
+
+// =============================================================================
+// WORKFLOW: Compiled Definition
+// =============================================================================
+
+// run is the mutable state of one workflow instance.
+type run struct {
+	input    any
+	status   Status
+	outcomes map[string]*StepOutcome
+	done     chan struct{}
+	timers   map[string]signal.Timer // Step timeouts
+
+	// Saga state (see saga.go)
+	completed     []string        // Completed steps in completion order
+	aborted       bool            // A step failed; no new steps start
+	compensations []string        // Steps still to compensate, next first
+	compensating  string          // Step whose compensation is in flight
+	late          map[string]bool // Timed-out steps with a compensation, whose outcome is awaited
+	notified      bool            // OnFinish hook has been called
+}
+
+// Workflow is a definition compiled onto an Engine. Compile registers a
+// coordinator agent and one proxy agent per step; the coordinator dispatches
+// ready steps as signals and advances the graph as their outcomes return.
+// All step execution happens on the Engine's workers.
+type Workflow struct {
+	def    *Definition
+	engine *signal.Engine
+	id     string // Coordinator agent ID
+	steps  map[string]*Step
+	saga   bool
+
+	mu       sync.Mutex
+	runs     map[string]*run
+	onFinish func(Status)
+}
+
+// Compile validates a definition against the engine's registered agents and
+// registers the agents that run it. The coordinator's ID is "workflow:<name>";
+// step proxies are "workflow:<name>:<step>", and compensation proxies are
+// "workflow:<name>:<step>:compensate".
+func Compile(def *Definition, engine *signal.Engine) (*Workflow, error) {
+	if err := def.Validate(engine.Router()); err != nil {
+		return nil, err
+	}
+
+	w := &Workflow{
+		def:    def,
+		engine: engine,
+		id:     "workflow:" + def.Name,
+		steps:  make(map[string]*Step, len(def.Steps)),
+		saga:   def.IsSaga(),
+		runs:   make(map[string]*run),
+	}
+	for i := range def.Steps {
+		s := &def.Steps[i]
+		if s.Type == "" {
+			s.Type = SignalStep
+		}
+		if c := s.Compensate; c != nil {
+			if c.Agent == "" {
+				c.Agent = s.Agent
+			}
+			if c.Type == "" {
+				c.Type = SignalCompensate
+			}
+		}
+		w.steps[s.ID] = s
+	}
+
+	if err := engine.Register(w); err != nil {
+		return nil, err
+	}
+	for _, s := range w.steps {
+		proxy := &stepAgent{workflow: w, id: w.stepAgentID(s.ID), agent: s.Agent, doneType: SignalStepDone}
+		if err := engine.Register(proxy); err != nil {
+			return nil, err
+		}
+		if s.Compensate != nil {
+			compensator := &stepAgent{workflow: w, id: w.compensationAgentID(s.ID), agent: s.Compensate.Agent, doneType: SignalCompensationDone}
+			if err := engine.Register(compensator); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return w, nil
+}
+
+// OnFinish sets a hook called with the final status of every instance,
+// once it completes, fails or (for sagas) finishes compensating.
+func (w *Workflow) OnFinish(hook func(Status)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onFinish = hook
+}
+
+// ID returns the coordinator agent's ID.
+func (w *Workflow) ID() string {
+	return w.id
+}
+
+// Start launches a new instance with the given input and returns its ID.
+func (w *Workflow) Start(input any) (string, error) {
+	instanceID := "wf-" + uuid.NewString()
+
+	steps := make(map[string]StepStatus, len(w.steps))
+	for id := range w.steps {
+		steps[id] = StepStatus{State: StepPending}
+	}
+	r := &run{
+		input: input,
+		status: Status{
+			InstanceID: instanceID,
+			Workflow:   w.def.Name,
+			State:      RunRunning,
+			Steps:      steps,
+			Started:    w.engine.Clock().Now(),
+		},
+		outcomes: make(map[string]*StepOutcome),
+		done:     make(chan struct{}),
+		timers:   make(map[string]signal.Timer),
+		late:     make(map[string]bool),
+	}
+
+	w.mu.Lock()
+	w.runs[instanceID] = r
+	w.mu.Unlock()
+
+	start := w.engine.Factory().New(SignalWorkflowStart, input).
+		WithDestination(w.id).
+		WithMetadata(MetaInstance, instanceID)
+	if err := w.engine.Submit(start); err != nil {
+		w.Forget(instanceID)
+		return "", fmt.Errorf("start workflow '%s': %w", w.def.Name, err)
+	}
+	return instanceID, nil
+}
+
+// Status returns a snapshot of an instance's progress.
+func (w *Workflow) Status(instanceID string) (Status, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	r, ok := w.runs[instanceID]
+	if !ok {
+		return Status{}, false
+	}
+	return r.snapshot(), true
+}
+
+// Wait blocks until the instance finishes or ctx is done.
+func (w *Workflow) Wait(ctx context.Context, instanceID string) (Status, error) {
+	w.mu.Lock()
+	r, ok := w.runs[instanceID]
+	w.mu.Unlock()
+	if !ok {
+		return Status{}, fmt.Errorf("workflow instance '%s' not found", instanceID)
+	}
+
+	select {
+	case <-r.done:
+		status, _ := w.Status(instanceID)
+		return status, nil
+	case <-ctx.Done():
+		status, _ := w.Status(instanceID)
+		return status, ctx.Err()
+	}
+}
+
+// Outputs returns the output signals of a completed step in an instance.
+func (w *Workflow) Outputs(instanceID, stepID string) []*signal.Signal {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if r, ok := w.runs[instanceID]; ok {
+		if o, ok := r.outcomes[stepID]; ok {
+			return o.Signals
+		}
+	}
+	return nil
+}
+
+// Forget drops an instance's state. Finished instances are kept until then.
+func (w *Workflow) Forget(instanceID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.runs, instanceID)
+}
+
+// Process is the coordinator: it records step outcomes and dispatches the
+// steps (or saga compensations) that became ready.
+func (w *Workflow) Process(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+	instanceID := sig.Metadata[MetaInstance]
+	stepID := sig.Metadata[MetaStep]
+
+	w.mu.Lock()
+	r, ok := w.runs[instanceID]
+	if !ok {
+		w.mu.Unlock()
+		return signal.Err(fmt.Errorf("workflow '%s': unknown instance '%s'", w.def.Name, instanceID))
+	}
+
+	switch sig.Type {
+	case SignalWorkflowStart:
+		// Nothing to record; dispatch the root steps
+	case SignalStepDone, SignalCompensationDone:
+		outcome, ok := sig.Payload.(*StepOutcome)
+		if !ok {
+			w.mu.Unlock()
+			return signal.Err(fmt.Errorf("workflow '%s': invalid step outcome payload %T", w.def.Name, sig.Payload))
+		}
+		w.apply(r, sig.Type, stepID, outcome)
+	case SignalStepTimeout:
+		w.apply(r, sig.Type, stepID, nil)
+	default:
+		w.mu.Unlock()
+		return signal.Err(fmt.Errorf("workflow '%s': unexpected signal type '%s'", w.def.Name, sig.Type))
+	}
+
+	dispatch := w.advance(r)
+	status, finished := r.finished()
+	hook := w.onFinish
+	w.mu.Unlock()
+
+	if finished && hook != nil {
+		hook(status)
+	}
+
+	factory := signal.FactoryFrom(ctx)
+	out := make([]*signal.Signal, 0, len(dispatch))
+	for _, d := range dispatch {
+		out = append(out, factory.Derive(sig, d.typ, d.input).
+			WithDestination(d.agentID).
+			WithMetadata(MetaStep, d.stepID))
+	}
+	return signal.OK(out...)
+}
+
+// apply records an event of a run: the outcome of a step or compensation,
+// or a step's timeout (outcome is then nil). Caller must hold w.mu.
+func (w *Workflow) apply(r *run, typ signal.SignalType, stepID string, outcome *StepOutcome) {
+	switch typ {
+	case SignalStepDone:
+		w.record(r, stepID, outcome)
+	case SignalCompensationDone:
+		r.recordCompensation(stepID, outcome, w.engine.Clock().Now())
+	case SignalStepTimeout:
+		running := r.status.Steps[stepID].State == StepRunning
+		w.record(r, stepID, &StepOutcome{
+			Err: fmt.Errorf("step '%s' timed out after %v", stepID, w.steps[stepID].Timeout),
+		})
+		// A step that timed out may still succeed; a saga must then undo it
+		if running && w.saga && w.steps[stepID].Compensate != nil {
+			r.late[stepID] = true
+		}
+	}
+}
+
+// resolve applies an event whose signal could not be submitted to the
+// coordinator, such as a step timeout while the engine stops, and submits
+// what becomes ready. A step or compensation that cannot be submitted
+// either fails in turn, so the run still finishes.
+func (w *Workflow) resolve(instanceID, stepID string, typ signal.SignalType, outcome *StepOutcome) {
+	type event struct {
+		stepID  string
+		typ     signal.SignalType
+		outcome *StepOutcome
+	}
+	events := []event{{stepID, typ, outcome}}
+	for len(events) > 0 {
+		e := events[0]
+		events = events[1:]
+
+		w.mu.Lock()
+		r, ok := w.runs[instanceID]
+		if !ok {
+			w.mu.Unlock()
+			return
+		}
+		w.apply(r, e.typ, e.stepID, e.outcome)
+		if e.typ == SignalStepTimeout {
+			// The step's late outcome could not reach the coordinator either
+			delete(r.late, e.stepID)
+		}
+		dispatch := w.advance(r)
+		status, finished := r.finished()
+		hook := w.onFinish
+		w.mu.Unlock()
+
+		if finished && hook != nil {
+			hook(status)
+		}
+		for _, d := range dispatch {
+			sig := w.engine.Factory().New(d.typ, d.input).
+				WithDestination(d.agentID).
+				WithMetadata(MetaInstance, instanceID).
+				WithMetadata(MetaStep, d.stepID)
+			if err := w.engine.Submit(sig); err != nil {
+				done := SignalStepDone
+				if d.agentID == w.compensationAgentID(d.stepID) {
+					done = SignalCompensationDone
+				}
+				events = append(events, event{d.stepID, done, &StepOutcome{
+					Err: fmt.Errorf("dispatch step '%s': %w", d.stepID, err),
+				}})
+			}
+		}
+	}
+}
+
+// record stores a step's outcome. In a saga, a failure aborts the run.
+// Caller must hold w.mu.
+func (w *Workflow) record(r *run, stepID string, outcome *StepOutcome) {
+	if !r.record(stepID, outcome, w.engine.Clock().Now()) {
+		return
+	}
+	if outcome.Err != nil && w.saga {
+		r.aborted = true
+	}
+}
+
+// dispatchable is a signal the coordinator sends to a step or
+// compensation proxy.
+type dispatchable struct {
+	stepID  string
+	agentID string
+	typ     signal.SignalType
+	input   any
+}
+
+// advance resolves every pending step whose dependencies have finished:
+// it is skipped, failed (input mapping error) or marked running and returned
+// for dispatch. Resolution repeats until no more steps change, so skips
+// cascade. An aborted saga skips all pending steps and starts compensating
+// once no step is running. Caller must hold w.mu.
+func (w *Workflow) advance(r *run) []dispatchable {
+	var ready []dispatchable
+	now := w.engine.Clock().Now()
+
+	for changed := true; changed; {
+		changed = false
+		in := Inputs{Workflow: r.input, Steps: r.outcomes}
+
+		for _, s := range w.def.Steps {
+			st := r.status.Steps[s.ID]
+			if st.State != StepPending {
+				continue
+			}
+			if r.aborted {
+				r.status.Steps[s.ID] = StepStatus{State: StepSkipped, Finished: now}
+				continue
+			}
+			if !r.depsFinished(&s) {
+				continue
+			}
+			changed = true
+
+			if r.shouldSkip(&s, in) {
+				r.status.Steps[s.ID] = StepStatus{State: StepSkipped, Finished: now}
+				continue
+			}
+			step := w.steps[s.ID]
+			input, err := w.stepInput(step, in)
+			if err != nil {
+				w.record(r, s.ID, &StepOutcome{Err: err})
+				r.status.Steps[s.ID] = StepStatus{State: StepFailed, Err: err, Started: now, Finished: now}
+				continue
+			}
+			r.status.Steps[s.ID] = StepStatus{State: StepRunning, Started: now}
+			w.startTimer(r, step)
+			ready = append(ready, dispatchable{
+				stepID:  s.ID,
+				agentID: w.stepAgentID(s.ID),
+				typ:     step.Type,
+				input:   input,
+			})
+		}
+	}
+
+	if r.aborted {
+		ready = append(ready, w.compensate(r)...)
+	}
+	r.finishIfDone(now)
+	return ready
+}
+
+// startTimer schedules a timeout signal for a step with a Timeout.
+// Caller must hold w.mu.
+func (w *Workflow) startTimer(r *run, step *Step) {
+	if step.Timeout <= 0 {
+		return
+	}
+	instanceID := r.status.InstanceID
+	r.timers[step.ID] = w.engine.Clock().AfterFunc(step.Timeout, func() {
+		timeout := w.engine.Factory().New(SignalStepTimeout, nil).
+			WithDestination(w.id).
+			WithMetadata(MetaInstance, instanceID).
+			WithMetadata(MetaStep, step.ID)
+		if err := w.engine.Submit(timeout); err != nil {
+			// Time the step out directly, or the run would never finish
+			w.resolve(instanceID, step.ID, SignalStepTimeout, nil)
+		}
+	})
+}
+
+// stepInput builds the payload sent to a step's agent.
+func (w *Workflow) stepInput(s *Step, in Inputs) (any, error) {
+	if s.Map != nil {
+		return s.Map(in)
+	}
+	switch {
+	case s.Input == InputWorkflow:
+		return in.Workflow, nil
+	case s.Input != "":
+		return in.Output(s.Input), nil
+	case len(s.DependsOn) == 0:
+		return in.Workflow, nil
+	case len(s.DependsOn) == 1:
+		return in.Output(s.DependsOn[0]), nil
+	default:
+		outputs := make(map[string]any, len(s.DependsOn))
+		for _, dep := range s.DependsOn {
+			if o, ok := in.Steps[dep]; ok && o.Err == nil {
+				outputs[dep] = in.Output(dep)
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// stepAgentID returns the ID of the proxy agent for a step.
+func (w *Workflow) stepAgentID(stepID string) string {
+	return w.id + ":" + stepID
+}
+
+// compensationAgentID returns the ID of the compensation proxy for a step.
+func (w *Workflow) compensationAgentID(stepID string) string {
+	return w.stepAgentID(stepID) + ":compensate"
+}
+
+// record stores a running step's outcome, finished at now, and updates its
+// status. The late outcome of a timed-out step that succeeded anyway is kept
+// for compensation, though the step stays failed; other outcomes of steps
+// that are no longer running are ignored. Reports whether the outcome was
+// recorded as the step's result.
+func (r *run) record(stepID string, outcome *StepOutcome, now time.Time) bool {
+	st, ok := r.status.Steps[stepID]
+	if ok && r.late[stepID] {
+		delete(r.late, stepID)
+		if outcome.Err == nil {
+			r.outcomes[stepID] = outcome
+			r.completed = append(r.completed, stepID)
+		}
+		return false
+	}
+	if !ok || st.State != StepRunning {
+		return false
+	}
+	if timer, ok := r.timers[stepID]; ok {
+		timer.Stop()
+		delete(r.timers, stepID)
+	}
+	r.outcomes[stepID] = outcome
+	st.Finished = now
+	if outcome.Err != nil {
+		st.State = StepFailed
+		st.Err = outcome.Err
+	} else {
+		st.State = StepCompleted
+		r.completed = append(r.completed, stepID)
+	}
+	r.status.Steps[stepID] = st
+	return true
+}
+
+// depsFinished reports whether all of a step's dependencies are terminal.
+func (r *run) depsFinished(s *Step) bool {
+	for _, dep := range s.DependsOn {
+		if !r.status.Steps[dep].State.terminal() {
+			return false
+		}
+	}
+	return true
+}
+
+// shouldSkip reports whether a ready step must be skipped: a dependency
+// failed, every dependency was skipped, or its condition does not hold.
+func (r *run) shouldSkip(s *Step, in Inputs) bool {
+	skipped := 0
+	for _, dep := range s.DependsOn {
+		switch r.status.Steps[dep].State {
+		case StepFailed:
+			return true
+		case StepSkipped:
+			skipped++
+		}
+	}
+	if len(s.DependsOn) > 0 && skipped == len(s.DependsOn) {
+		return true
+	}
+	return s.When != nil && !s.When.matches(in)
+}
+
+// finishIfDone marks the run finished once every step is terminal and no
+// compensation is pending.
+func (r *run) finishIfDone(now time.Time) {
+	if r.status.State != RunRunning && r.status.State != RunCompensating {
+		return
+	}
+	if r.compensating != "" || len(r.compensations) > 0 || len(r.late) > 0 {
+		return
+	}
+	state := RunCompleted
+	if r.aborted {
+		state = RunFailed // Even once a step that failed late is compensated
+	}
+	for _, st := range r.status.Steps {
+		if !st.State.terminal() {
+			return
+		}
+		if st.State == StepFailed || st.State == StepCompensationFailed {
+			state = RunFailed
+		}
+	}
+	if state == RunFailed && r.status.State == RunCompensating && r.allCompensated() {
+		state = RunCompensated
+	}
+	r.status.State = state
+	r.status.Finished = now
+	close(r.done)
+}
+
+// finished returns the final status once the run has finished, the first
+// time it is asked, so that OnFinish is called once. Caller must hold w.mu.
+func (r *run) finished() (Status, bool) {
+	if r.notified || r.status.State == RunRunning || r.status.State == RunCompensating {
+		return Status{}, false
+	}
+	r.notified = true
+	return r.snapshot(), true
+}
+
+// snapshot returns a copy of the run's status.
+func (r *run) snapshot() Status {
+	status := r.status
+	status.Steps = make(map[string]StepStatus, len(r.status.Steps))
+	for id, st := range r.status.Steps {
+		status.Steps[id] = st
+	}
+	return status
+}
+
+// =============================================================================
+// STEP PROXY
+// =============================================================================
+
+// stepAgent executes one step (or compensation) on the engine by delivering
+// the step signal to the target agent (see signal.Engine.Deliver) and
+// reporting its outcome to the coordinator instead of routing its outputs.
+type stepAgent struct {
+	workflow *Workflow
+	id       string
+	agent    string            // Target agent ID
+	doneType signal.SignalType // Type of the outcome signal
+}
+
+// ID returns the proxy's agent ID.
+func (a *stepAgent) ID() string {
+	return a.id
+}
+
+// Process delivers the step to the target agent and defers its own result
+// until the target's result is in, so a paused target holds the step.
+func (a *stepAgent) Process(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+	complete, ok := signal.Defer(ctx)
+	if !ok {
+		return signal.Err(fmt.Errorf("step proxy '%s' must be called by the engine", a.id))
+	}
+	// The target's call outlives this one, which returns right away
+	a.workflow.engine.Deliver(context.WithoutCancel(ctx), a.agent, sig.WithDestination(a.agent), func(result signal.AgentResult) {
+		outcome := &StepOutcome{Signals: result.Signals, Err: result.Error}
+		complete(signal.OK(signal.FactoryFrom(ctx).Derive(sig, a.doneType, outcome).WithDestination(a.workflow.id)))
+	})
+	return signal.AgentResult{}
+}
@@ -0,0 +1,375 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taipm/go-signal-agent/signal"
+)
+
+// textAgent applies fn to a string payload and emits the result.
+func textAgent(id string, fn func(string) string) signal.Agent {
+	return signal.NewAgentFunc(id, func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+		text, _ := sig.Payload.(string)
+		return signal.OK(sig.Derive("text", fn(text)))
+	})
+}
+
+func newTestEngine(t *testing.T, agents ...signal.Agent) *signal.Engine {
+	t.Helper()
+	router := signal.NewRouter()
+	for _, a := range agents {
+		router.Register(a)
+	}
+	engine := signal.NewEngine(signal.DefaultConfig(), router)
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { engine.Stop() })
+	return engine
+}
+
+func waitRun(t *testing.T, w *Workflow, id string) Status {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	status, err := w.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	return status
+}
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDefinitionValidate(t *testing.T) {
+	router := signal.NewRouter()
+	router.Register(textAgent("summarizer", strings.ToUpper))
+
+	tests := []struct {
+		name    string
+		def     Definition
+		wantErr string
+	}{
+		{
+			name: "valid",
+			def:  Definition{Name: "ok", Steps: []Step{{ID: "a", Agent: "summarizer"}}},
+		},
+		{
+			name:    "unknown agent",
+			def:     Definition{Name: "bad", Steps: []Step{{ID: "a", Agent: "missing"}}},
+			wantErr: "unknown agent",
+		},
+		{
+			name: "unknown dependency",
+			def: Definition{Name: "bad", Steps: []Step{
+				{ID: "a", Agent: "summarizer", DependsOn: []string{"ghost"}},
+			}},
+			wantErr: "unknown step",
+		},
+		{
+			name: "duplicate step",
+			def: Definition{Name: "bad", Steps: []Step{
+				{ID: "a", Agent: "summarizer"}, {ID: "a", Agent: "summarizer"},
+			}},
+			wantErr: "duplicate step",
+		},
+		{
+			name: "input not a dependency",
+			def: Definition{Name: "bad", Steps: []Step{
+				{ID: "a", Agent: "summarizer"}, {ID: "b", Agent: "summarizer", Input: "a"},
+			}},
+			wantErr: "not a dependency",
+		},
+		{
+			name: "cycle",
+			def: Definition{Name: "bad", Steps: []Step{
+				{ID: "a", Agent: "summarizer", DependsOn: []string{"c"}},
+				{ID: "b", Agent: "summarizer", DependsOn: []string{"a"}},
+				{ID: "c", Agent: "summarizer", DependsOn: []string{"b"}},
+			}},
+			wantErr: "cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.def.Validate(router)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseDefinition(t *testing.T) {
+	def, err := ParseDefinition([]byte(`
+name: summarize-translate
+steps:
+  - id: summarize
+    agent: summary
+  - id: translate
+    agent: translation
+    depends_on: [summarize]
+    when:
+      step: summarize
+      metadata:
+        language: vi
+`))
+	if err != nil {
+		t.Fatalf("ParseDefinition() error = %v", err)
+	}
+	if def.Name != "summarize-translate" || len(def.Steps) != 2 {
+		t.Fatalf("Definition = %+v", def)
+	}
+	step := def.Steps[1]
+	if step.DependsOn[0] != "summarize" || step.When == nil || step.When.Metadata["language"] != "vi" {
+		t.Errorf("Step = %+v, want dependency and condition on summarize", step)
+	}
+}
+
+func TestWorkflowSequentialRun(t *testing.T) {
+	engine := newTestEngine(t,
+		textAgent("summary", strings.ToUpper),
+		textAgent("translation", func(s string) string { return "[vi] " + s }),
+	)
+
+	w, err := Compile(&Definition{
+		Name: "summarize-translate",
+		Steps: []Step{
+			{ID: "summarize", Agent: "summary"},
+			{ID: "translate", Agent: "translation", DependsOn: []string{"summarize"}},
+		},
+	}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	id, err := w.Start("hello")
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	status := waitRun(t, w, id)
+
+	if status.State != RunCompleted {
+		t.Fatalf("State = %v, want completed", status.State)
+	}
+	outputs := w.Outputs(id, "translate")
+	if len(outputs) != 1 || outputs[0].Payload != "[vi] HELLO" {
+		t.Errorf("Outputs = %v, want [vi] HELLO", outputs)
+	}
+	if outputs[0].Metadata[MetaInstance] != id {
+		t.Errorf("Output instance = %q, want %q", outputs[0].Metadata[MetaInstance], id)
+	}
+}
+
+func TestWorkflowTimesRunsByEngineClock(t *testing.T) {
+	clock := signal.NewManualClock(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	router := signal.NewRouter()
+	router.Register(textAgent("summary", strings.ToUpper))
+	config := signal.DefaultConfig()
+	config.Clock = clock
+	engine := signal.NewEngine(config, router)
+	engine.Start()
+	defer engine.Stop()
+
+	w, err := Compile(&Definition{Name: "clocked", Steps: []Step{{ID: "summarize", Agent: "summary"}}}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	id, _ := w.Start("hello")
+	status := waitRun(t, w, id)
+
+	step := status.Steps["summarize"]
+	for name, got := range map[string]time.Time{
+		"run started": status.Started, "run finished": status.Finished,
+		"step started": step.Started, "step finished": step.Finished,
+	} {
+		if !got.Equal(clock.Now()) {
+			t.Errorf("%s = %v, want the engine clock's %v", name, got, clock.Now())
+		}
+	}
+}
+
+func TestWorkflowConditionalBranches(t *testing.T) {
+	classify := signal.NewAgentFunc("classifier", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+		lang := "en"
+		if strings.HasPrefix(sig.Payload.(string), "xin") {
+			lang = "vi"
+		}
+		return signal.OK(sig.Derive("classified", sig.Payload).WithMetadata("language", lang))
+	})
+	engine := newTestEngine(t,
+		classify,
+		textAgent("translation", func(s string) string { return "translated: " + s }),
+		textAgent("echo", func(s string) string { return s }),
+		signal.NewAgentFunc("merge", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			return signal.OK(sig.Derive("merged", sig.Payload))
+		}),
+	)
+
+	w, err := Compile(&Definition{
+		Name: "branching",
+		Steps: []Step{
+			{ID: "classify", Agent: "classifier"},
+			{ID: "translate", Agent: "translation", DependsOn: []string{"classify"},
+				When: &Condition{Step: "classify", Metadata: map[string]string{"language": "vi"}}},
+			{ID: "passthrough", Agent: "echo", DependsOn: []string{"classify"},
+				When: &Condition{Step: "classify", Metadata: map[string]string{"language": "en"}}},
+			{ID: "merge", Agent: "merge", DependsOn: []string{"translate", "passthrough"}},
+		},
+	}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	id, _ := w.Start("xin chao")
+	status := waitRun(t, w, id)
+
+	if status.State != RunCompleted {
+		t.Fatalf("State = %v, want completed", status.State)
+	}
+	if got := status.Steps["passthrough"].State; got != StepSkipped {
+		t.Errorf("passthrough = %v, want skipped", got)
+	}
+	if got := status.Steps["translate"].State; got != StepCompleted {
+		t.Errorf("translate = %v, want completed", got)
+	}
+	merged := w.Outputs(id, "merge")
+	inputs, ok := merged[0].Payload.(map[string]any)
+	if !ok || inputs["translate"] != "translated: xin chao" {
+		t.Errorf("merge input = %v, want translate output only", merged[0].Payload)
+	}
+	if _, ok := inputs["passthrough"]; ok {
+		t.Error("Skipped step should not contribute input")
+	}
+}
+
+func TestWorkflowFailureSkipsDependents(t *testing.T) {
+	engine := newTestEngine(t,
+		signal.NewAgentFunc("broken", func(ctx context.Context, sig *signal.Signal) signal.AgentResult {
+			return signal.Err(errors.New("model not found"))
+		}),
+		textAgent("translation", strings.ToUpper),
+	)
+
+	w, err := Compile(&Definition{
+		Name: "failing",
+		Steps: []Step{
+			{ID: "summarize", Agent: "broken"},
+			{ID: "translate", Agent: "translation", DependsOn: []string{"summarize"}},
+		},
+	}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	id, _ := w.Start("hello")
+	status := waitRun(t, w, id)
+
+	if status.State != RunFailed {
+		t.Errorf("State = %v, want failed", status.State)
+	}
+	if st := status.Steps["summarize"]; st.State != StepFailed || st.Err == nil {
+		t.Errorf("summarize = %+v, want failed with error", st)
+	}
+	if got := status.Steps["translate"].State; got != StepSkipped {
+		t.Errorf("translate = %v, want skipped", got)
+	}
+}
+
+func TestWorkflowStepsRunThroughEngine(t *testing.T) {
+	router := signal.NewRouter()
+	router.Register(textAgent("summary", strings.ToUpper))
+	engine := signal.NewEngine(signal.DefaultConfig(), router)
+	processed := make(chan string, 10)
+	engine.OnSignalProcessed(func(sig *signal.Signal, result signal.AgentResult) {
+		processed <- sig.Destination
+	})
+	w, err := Compile(&Definition{Name: "summarize", Steps: []Step{{ID: "summarize", Agent: "summary"}}}, engine)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	engine.Start()
+	defer engine.Stop()
+
+	// A paused target holds the step
+	engine.PauseAgent("summary")
+	id, err := w.Start("hello")
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitFor(t, func() bool { return engine.Stats().Held["summary"] == 1 })
+	if status, _ := w.Status(id); status.State != RunRunning {
+		t.Errorf("State = %v while the target is paused, want running", status.State)
+	}
+
+	engine.ResumeAgent("summary")
+	if status := waitRun(t, w, id); status.State != RunCompleted {
+		t.Fatalf("State = %v, want completed", status.State)
+	}
+	seen := false
+	for len(processed) > 0 {
+		if <-processed == "summary" {
+			seen = true
+		}
+	}
+	if !seen {
+		t.Error("The target's call should reach the engine's hooks")
+	}
+}
+
+func TestWorkflowStepsAreCheckedByPolicy(t *testing.T) {
+	for _, allowed := range []bool{false, true} {
+		coordinatorTo := []string{"workflow:summarize:summarize"}
+		if allowed {
+			coordinatorTo = append(coordinatorTo, "summary")
+		}
+		config := signal.DefaultConfig()
+		config.Policy = &signal.Policy{Rules: []signal.PolicyRule{
+			{Source: "workflow:summarize", To: coordinatorTo},
+			{Source: "workflow:summarize:summarize", To: []string{"workflow:summarize"}},
+			{Source: "summary", Types: []signal.SignalType{"text"}},
+		}}
+		router := signal.NewRouter()
+		router.Register(textAgent("summary", strings.ToUpper))
+		engine := signal.NewEngine(config, router)
+		w, err := Compile(&Definition{Name: "summarize", Steps: []Step{{ID: "summarize", Agent: "summary"}}}, engine)
+		if err != nil {
+			t.Fatalf("Compile() error = %v", err)
+		}
+		engine.Start()
+
+		id, _ := w.Start("hello")
+		status := waitRun(t, w, id)
+		engine.Stop()
+		if allowed {
+			if status.State != RunCompleted {
+				t.Errorf("Permitted step: state = %v, want completed", status.State)
+			}
+			continue
+		}
+		if st := status.Steps["summarize"]; status.State != RunFailed || !errors.Is(st.Err, signal.ErrPolicyDenied) {
+			t.Errorf("Denied step: state = %v, step error = %v; want failed by the policy", status.State, st.Err)
+		}
+	}
+}